@@ -0,0 +1,244 @@
+// Package clipboard moves text between the editor and the host clipboard.
+// Writes go out as OSC 52 escape sequences so they reach the local machine over SSH and tmux,
+// and a local clipboard tool (wl-copy, xclip, xsel) is used for both directions when one is installed.
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var ErrNoProvider = errors.New("clipboard: no provider available for reading")
+
+// Backend is a single way of reaching the clipboard.
+type Backend interface {
+	Name() string
+	Read() (string, error)
+	Write(text string) error
+}
+
+// Setter is what OSC 52 needs from the terminal. tcell.Screen satisfies it.
+type Setter interface {
+	SetClipboard(data []byte)
+}
+
+// OSC52 writes through the terminal. Terminals don't reliably answer clipboard queries,
+// so reads return the last text written from this editor.
+type OSC52 struct {
+	setter Setter
+	last   string
+}
+
+func NewOSC52(setter Setter) *OSC52 {
+	return &OSC52{setter: setter}
+}
+
+func (o *OSC52) Name() string {
+	return "osc52"
+}
+
+func (o *OSC52) Read() (string, error) {
+	return o.last, nil
+}
+
+func (o *OSC52) Write(text string) error {
+	o.setter.SetClipboard([]byte(text))
+	o.last = text
+	return nil
+}
+
+// Runner executes a clipboard tool, feeding stdin and returning stdout. Swappable for tests.
+type Runner func(name string, args []string, stdin string) (string, error)
+
+// toolTimeout bounds a clipboard tool run. Registers are read on the UI goroutine, a hung tool (xclip waiting
+// on a dead X server) mustn't freeze the editor.
+const toolTimeout = 500 * time.Millisecond
+
+func execRunner(name string, args []string, stdin string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), toolTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	// Copy programs leave a child serving the selection, don't wait for it to close our pipes
+	cmd.WaitDelay = toolTimeout
+	cmd.Stdin = strings.NewReader(stdin)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("clipboard: %s: timed out after %v", name, toolTimeout)
+		}
+		return "", fmt.Errorf("clipboard: %s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return out.String(), nil
+}
+
+// Tool talks to a local clipboard program.
+type Tool struct {
+	name       string
+	copyCmd    []string
+	pasteCmd   []string // Nil when the paste program isn't installed, the tool only writes then
+	runCommand Runner
+}
+
+func (t *Tool) Name() string {
+	return t.name
+}
+
+func (t *Tool) Read() (string, error) {
+	if t.pasteCmd == nil {
+		return "", ErrNoProvider
+	}
+	return t.runCommand(t.pasteCmd[0], t.pasteCmd[1:], "")
+}
+
+func (t *Tool) Write(text string) error {
+	_, err := t.runCommand(t.copyCmd[0], t.copyCmd[1:], text)
+	return err
+}
+
+// Tools lists the supported clipboard programs by name.
+var Tools = map[string]Tool{
+	"wl-copy": {name: "wl-copy", copyCmd: []string{"wl-copy"}, pasteCmd: []string{"wl-paste", "--no-newline"}},
+	"xclip":   {name: "xclip", copyCmd: []string{"xclip", "-selection", "clipboard", "-in"}, pasteCmd: []string{"xclip", "-selection", "clipboard", "-out"}},
+	"xsel":    {name: "xsel", copyCmd: []string{"xsel", "--clipboard", "--input"}, pasteCmd: []string{"xsel", "--clipboard", "--output"}},
+}
+
+// Clipboard fans writes out to every backend and reads from the first backend that isn't OSC 52 and can read,
+// falling back to OSC 52's own copy of the last write.
+type Clipboard struct {
+	backends []Backend
+}
+
+func New(backends ...Backend) *Clipboard {
+	return &Clipboard{backends: backends}
+}
+
+func (c *Clipboard) Read() (string, error) {
+	var osc Backend
+	for _, b := range c.backends {
+		switch b := b.(type) {
+		case *OSC52:
+			osc = b
+		case *Tool:
+			if b.pasteCmd != nil {
+				return b.Read()
+			}
+		default:
+			return b.Read()
+		}
+	}
+	if osc == nil {
+		return "", ErrNoProvider
+	}
+	return osc.Read()
+}
+
+func (c *Clipboard) Write(text string) error {
+	var errs []error
+	for _, b := range c.backends {
+		if err := b.Write(text); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Names returns the active backend names, e.g. "osc52+xclip".
+func (c *Clipboard) Names() string {
+	names := make([]string, 0, len(c.backends))
+	for _, b := range c.backends {
+		names = append(names, b.Name())
+	}
+	return strings.Join(names, "+")
+}
+
+// Config controls provider detection.
+type Config struct {
+	// Provider is "auto" (default), "osc52", "none" or one of the Tools keys.
+	Provider string
+	// OSC52 disables the terminal escape sequence when false and a tool is found.
+	OSC52 bool
+
+	LookPath func(file string) (string, error)
+	Getenv   func(key string) string
+	Run      Runner
+}
+
+// ConfigFromEnv reads GO_EDITOR_CLIPBOARD (provider name) and GO_EDITOR_OSC52 ("0" to disable).
+func ConfigFromEnv() Config {
+	return Config{
+		Provider: os.Getenv("GO_EDITOR_CLIPBOARD"),
+		OSC52:    os.Getenv("GO_EDITOR_OSC52") != "0",
+	}
+}
+
+// Detect builds a Clipboard from the config. In auto mode it picks wl-copy under Wayland,
+// xclip or xsel under X11, and always keeps OSC 52 for writes unless disabled. A tool whose paste program
+// isn't installed only writes. With provider "none" there's no clipboard and a nil one is returned.
+func Detect(cfg Config, setter Setter) (*Clipboard, error) {
+	if cfg.LookPath == nil {
+		cfg.LookPath = exec.LookPath
+	}
+	if cfg.Getenv == nil {
+		cfg.Getenv = os.Getenv
+	}
+	if cfg.Run == nil {
+		cfg.Run = execRunner
+	}
+
+	osc := Backend(NewOSC52(setter))
+	tool := func(name string) (Backend, bool) {
+		t, ok := Tools[name]
+		if !ok {
+			return nil, false
+		}
+		if _, err := cfg.LookPath(t.copyCmd[0]); err != nil {
+			return nil, false
+		}
+		if _, err := cfg.LookPath(t.pasteCmd[0]); err != nil {
+			t.pasteCmd = nil
+		}
+		t.runCommand = cfg.Run
+		return &t, true
+	}
+	withOSC := func(b Backend) *Clipboard {
+		if cfg.OSC52 {
+			return New(osc, b)
+		}
+		return New(b)
+	}
+
+	switch cfg.Provider {
+	case "", "auto":
+		var candidates []string
+		if cfg.Getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, "wl-copy")
+		}
+		if cfg.Getenv("DISPLAY") != "" {
+			candidates = append(candidates, "xclip", "xsel")
+		}
+		for _, name := range candidates {
+			if b, ok := tool(name); ok {
+				return withOSC(b), nil
+			}
+		}
+		return New(osc), nil
+	case "osc52":
+		return New(osc), nil
+	case "none":
+		return nil, nil
+	default:
+		b, ok := tool(cfg.Provider)
+		if !ok {
+			return nil, fmt.Errorf("clipboard: provider %q not found", cfg.Provider)
+		}
+		return withOSC(b), nil
+	}
+}
@@ -0,0 +1,159 @@
+package clipboard
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSetter struct {
+	data []byte
+}
+
+func (f *fakeSetter) SetClipboard(data []byte) {
+	f.data = data
+}
+
+type fakeSystem struct {
+	installed map[string]bool
+	env       map[string]string
+	calls     []string
+	stdin     string
+	stdout    string
+}
+
+func (f *fakeSystem) config(provider string) Config {
+	return Config{
+		Provider: provider,
+		OSC52:    true,
+		LookPath: func(file string) (string, error) {
+			if f.installed[file] {
+				return "/usr/bin/" + file, nil
+			}
+			return "", errors.New("not found")
+		},
+		Getenv: func(key string) string {
+			return f.env[key]
+		},
+		Run: func(name string, args []string, stdin string) (string, error) {
+			f.calls = append(f.calls, name)
+			f.stdin = stdin
+			return f.stdout, nil
+		},
+	}
+}
+
+func TestDetectFallsBackToOSC52(t *testing.T) {
+	sys := &fakeSystem{installed: map[string]bool{"xclip": true}}
+	setter := &fakeSetter{}
+
+	// xclip is installed but there's no DISPLAY, e.g. over SSH
+	cb, err := Detect(sys.config("auto"), setter)
+	require.NoError(t, err)
+	require.Equal(t, "osc52", cb.Names())
+
+	require.NoError(t, cb.Write("hello"))
+	require.Equal(t, []byte("hello"), setter.data)
+
+	text, err := cb.Read()
+	require.NoError(t, err)
+	require.Equal(t, "hello", text)
+}
+
+func TestDetectPrefersLocalTool(t *testing.T) {
+	sys := &fakeSystem{
+		installed: map[string]bool{"wl-copy": true, "wl-paste": true, "xclip": true},
+		env:       map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"},
+		stdout:    "from host",
+	}
+	setter := &fakeSetter{}
+
+	cb, err := Detect(sys.config(""), setter)
+	require.NoError(t, err)
+	require.Equal(t, "osc52+wl-copy", cb.Names())
+
+	require.NoError(t, cb.Write("copied"))
+	require.Equal(t, []byte("copied"), setter.data)
+	require.Equal(t, []string{"wl-copy"}, sys.calls)
+	require.Equal(t, "copied", sys.stdin)
+
+	text, err := cb.Read()
+	require.NoError(t, err)
+	require.Equal(t, "from host", text)
+	require.Equal(t, []string{"wl-copy", "wl-paste"}, sys.calls)
+}
+
+func TestDetectWithoutPasteProgram(t *testing.T) {
+	// wl-clipboard half installed: writes go to wl-copy, reads can't use wl-paste
+	sys := &fakeSystem{
+		installed: map[string]bool{"wl-copy": true},
+		env:       map[string]string{"WAYLAND_DISPLAY": "wayland-0"},
+		stdout:    "from host",
+	}
+	cb, err := Detect(sys.config(""), &fakeSetter{})
+	require.NoError(t, err)
+	require.Equal(t, "osc52+wl-copy", cb.Names())
+
+	require.NoError(t, cb.Write("copied"))
+	text, err := cb.Read()
+	require.NoError(t, err)
+	require.Equal(t, "copied", text, "OSC 52's copy of the last write")
+	require.Equal(t, []string{"wl-copy"}, sys.calls)
+
+	config := sys.config("")
+	config.OSC52 = false
+	cb, err = Detect(config, &fakeSetter{})
+	require.NoError(t, err)
+	_, err = cb.Read()
+	require.ErrorIs(t, err, ErrNoProvider)
+}
+
+func TestDetectExplicitProvider(t *testing.T) {
+	sys := &fakeSystem{installed: map[string]bool{"xsel": true}}
+	setter := &fakeSetter{}
+
+	cb, err := Detect(sys.config("xsel"), setter)
+	require.NoError(t, err)
+	require.Equal(t, "osc52+xsel", cb.Names())
+	require.NoError(t, cb.Write("both"))
+	require.Equal(t, []byte("both"), setter.data, "OSC 52 writes stay on")
+
+	config := sys.config("xsel")
+	config.OSC52 = false
+	cb, err = Detect(config, &fakeSetter{})
+	require.NoError(t, err)
+	require.Equal(t, "xsel", cb.Names())
+
+	_, err = Detect(sys.config("xclip"), &fakeSetter{})
+	require.Error(t, err)
+
+	cb, err = Detect(sys.config("none"), &fakeSetter{})
+	require.NoError(t, err)
+	require.Nil(t, cb, "no clipboard, + and * act like named registers")
+}
+
+func TestFake(t *testing.T) {
+	fake := &Fake{}
+	cb := New(fake)
+
+	require.NoError(t, cb.Write("one"))
+	require.NoError(t, cb.Write("two"))
+	require.Equal(t, 2, fake.Writes)
+
+	text, err := cb.Read()
+	require.NoError(t, err)
+	require.Equal(t, "two", text)
+}
+
+func TestToolTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("no sleep program")
+	}
+	start := time.Now()
+	_, err := execRunner("sleep", []string{"5"}, "")
+	require.EqualError(t, err, "clipboard: sleep: timed out after 500ms")
+	require.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,27 @@
+package clipboard
+
+// Fake is an in-memory backend for tests.
+type Fake struct {
+	Text   string
+	Reads  int
+	Writes int
+	Err    error
+}
+
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+func (f *Fake) Read() (string, error) {
+	f.Reads++
+	return f.Text, f.Err
+}
+
+func (f *Fake) Write(text string) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Text = text
+	f.Writes++
+	return nil
+}
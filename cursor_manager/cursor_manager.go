@@ -80,6 +80,47 @@ func (cm *CursorManager) MoveToPrevWord() {
 	cm.cursor.position = i
 }
 
+// MoveToWordEnd moves to the last char of the current word, or the next word's if already at the end of one.
+func (cm *CursorManager) MoveToWordEnd() {
+	length := cm.buffer.Length()
+	i := cm.cursor.position + 1
+
+	// Skip whitespace, including newlines
+	for i < length && isSpace(cm.buffer.CharAt(i)) {
+		i++
+	}
+	if i >= length {
+		return
+	}
+
+	// Walk to the last char of the word
+	for i+1 < length && !isSpace(cm.buffer.CharAt(i+1)) {
+		i++
+	}
+
+	cm.cursor.position = i
+}
+
+// MoveToFirstNonBlank moves to the first char of the line that isn't a space or tab.
+func (cm *CursorManager) MoveToFirstNonBlank() {
+	line, _ := cm.GetLineColumn()
+	lineStart := cm.buffer.LineToChar(line)
+	lineLength := cm.buffer.LineLength(line)
+
+	col := 0
+	for col < lineLength {
+		if ch := cm.buffer.CharAt(lineStart + col); ch != ' ' && ch != '\t' {
+			break
+		}
+		col++
+	}
+	cm.cursor.position = lineStart + col
+}
+
+func isSpace(ch rune) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n'
+}
+
 func (cm *CursorManager) MoveToPosition(line, col int) error {
 	if line < 0 || line >= cm.buffer.LineCount() {
 		return fmt.Errorf("line out of bounds")
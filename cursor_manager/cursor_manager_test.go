@@ -572,3 +572,36 @@ func TestMoveToPrevWord(t *testing.T) {
 	cm.MoveToPrevWord()
 	require.Equal(t, 0, cm.cursor.position)
 }
+
+func TestMoveToWordEnd(t *testing.T) {
+	tb, _ := textbuffer.NewTextBuffer(100)
+	tb.InsertString(0, "Hello World\n  XXX")
+	cm := NewCursorManager(tb)
+
+	cm.SetPosition(0)
+	cm.MoveToWordEnd()
+	require.Equal(t, 4, cm.cursor.position)
+	cm.MoveToWordEnd()
+	require.Equal(t, 10, cm.cursor.position)
+	// Crosses the newline and leading indent
+	cm.MoveToWordEnd()
+	require.Equal(t, 16, cm.cursor.position)
+	// Nothing left, stays put
+	cm.MoveToWordEnd()
+	require.Equal(t, 16, cm.cursor.position)
+}
+
+func TestMoveToFirstNonBlank(t *testing.T) {
+	tb, _ := textbuffer.NewTextBuffer(100)
+	tb.InsertString(0, "abc\n \tdef\n   ")
+	cm := NewCursorManager(tb)
+
+	cm.SetPosition(8)
+	cm.MoveToFirstNonBlank()
+	require.Equal(t, 6, cm.cursor.position)
+
+	// Blank line ends up at its end
+	cm.SetPosition(11)
+	cm.MoveToFirstNonBlank()
+	require.Equal(t, 13, cm.cursor.position)
+}
@@ -1,13 +1,350 @@
 package editor
 
-// After Syntax HighlightingNext 4 Features (in order)1. Multiple Buffers + Buffer Switching
-// Why first: Can't be a real editor without opening multiple files
-//  Buffer struct: holds TextBuffer + metadata (filename, modified, cursor pos)
-//  BufferManager in editor: map of buffers, active buffer ID
-//  :e <file> - open file in new buffer
-//  :bn, :bp - next/previous buffer
-//  :bd - close buffer (warn if modified)
-//  :ls or :buffers - list open buffers
-//  Ctrl-6 - switch to last buffer
-//  Status bar shows buffer name + modified flag
-//  Handle closing last buffer
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/highlighter"
+	"github.com/ogzhanolguncu/go_editor/register"
+	"github.com/ogzhanolguncu/go_editor/search"
+	textbuffer "github.com/ogzhanolguncu/go_editor/text_buffer"
+	"github.com/ogzhanolguncu/go_editor/undo"
+)
+
+// ### BUFFERS
+
+// Buffer is everything that belongs to one open file. The current window embeds the buffer it shows, so
+// e.buffer, e.history and friends always mean the current buffer's.
+type Buffer struct {
+	id           int
+	buffer       *textbuffer.TextBuffer // Text storage and line tacking
+	lastCursor   int                    // Cursor position when a window last left the buffer, where it's shown again
+	filename     string                 // Empty until the buffer is loaded from or saved to a file
+	modified     bool                   // Required for tracking file modified flag on status line
+	history      *undo.History          // Undo/redo changes
+	marks        map[rune]int           // Mark name to char position, kept in place across edits
+	revision     int                    // Bumped on every edit, invalidates cached search matches
+	matchIndex   *search.Index          // Per line matches of the last search, edits only drop the lines they touch
+	nomodifiable bool                   // List windows' buffers, edits fail with E21
+
+	highlighter     *highlighter.Highlighter // Syntax tokens of the lines drawn so far
+	highlighterName string                   // File name the highlighter picked its language for
+}
+
+func newBuffer(content string) (*Buffer, error) {
+	text, err := textbuffer.NewTextBuffer(max(256, len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("editor: failed to create text buffer: %w", err)
+	}
+	text.InsertString(0, content)
+
+	return &Buffer{
+		buffer:     text,
+		history:    undo.NewHistory(undoLimit),
+		marks:      make(map[rune]int),
+		matchIndex: search.NewIndex(),
+	}, nil
+}
+
+func (b *Buffer) ID() int {
+	return b.id
+}
+
+// Name is the file name, or "[No Name]" for a buffer that has none yet.
+func (b *Buffer) Name() string {
+	if b.filename == "" {
+		return "[No Name]"
+	}
+	return b.filename
+}
+
+// isEmptyScratch reports a buffer that was never named or touched, which ":e" reuses instead of keeping around.
+func (b *Buffer) isEmptyScratch() bool {
+	return b.filename == "" && !b.modified && b.buffer.Length() == 0
+}
+
+// BufferManager holds the open buffers in the order they were opened, and remembers the alternate one for Ctrl-^.
+type BufferManager struct {
+	buffers   []*Buffer
+	nextID    int
+	alternate *Buffer
+}
+
+func NewBufferManager() *BufferManager {
+	return &BufferManager{nextID: 1}
+}
+
+// Add gives the buffer the next ID and appends it.
+func (m *BufferManager) Add(b *Buffer) {
+	b.id = m.nextID
+	m.nextID++
+	m.buffers = append(m.buffers, b)
+}
+
+// Replace puts b where old was, b takes over old's ID.
+func (m *BufferManager) Replace(old, b *Buffer) {
+	for i, existing := range m.buffers {
+		if existing == old {
+			b.id = old.id
+			m.buffers[i] = b
+		}
+	}
+	if m.alternate == old {
+		m.alternate = b
+	}
+}
+
+// Remove drops a buffer. It's no longer the alternate either.
+func (m *BufferManager) Remove(b *Buffer) {
+	for i, existing := range m.buffers {
+		if existing == b {
+			m.buffers = append(m.buffers[:i], m.buffers[i+1:]...)
+			break
+		}
+	}
+	if m.alternate == b {
+		m.alternate = nil
+	}
+}
+
+func (m *BufferManager) Get(id int) (*Buffer, bool) {
+	for _, b := range m.buffers {
+		if b.id == id {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// FindByPath finds the buffer editing path, comparing cleaned absolute paths.
+func (m *BufferManager) FindByPath(path string) (*Buffer, bool) {
+	want := absPath(path)
+	for _, b := range m.buffers {
+		if b.filename != "" && absPath(b.filename) == want {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// Find looks a buffer up the way ":b" does: a number, an exact name, or a unique part of a name.
+func (m *BufferManager) Find(arg string) (*Buffer, error) {
+	if id, err := strconv.Atoi(arg); err == nil {
+		if b, ok := m.Get(id); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("E86: Buffer %d does not exist", id)
+	}
+
+	if b, ok := m.FindByPath(arg); ok {
+		return b, nil
+	}
+	var found []*Buffer
+	for _, b := range m.buffers {
+		if strings.Contains(b.Name(), arg) {
+			found = append(found, b)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("E94: No matching buffer for %s", arg)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("E93: More than one match for %s", arg)
+	}
+}
+
+// Next returns the buffer after b, wrapping around. Prev goes the other way.
+func (m *BufferManager) Next(b *Buffer, n int) *Buffer {
+	return m.offset(b, n)
+}
+
+func (m *BufferManager) Prev(b *Buffer, n int) *Buffer {
+	return m.offset(b, -n)
+}
+
+func (m *BufferManager) offset(b *Buffer, n int) *Buffer {
+	if len(m.buffers) == 0 {
+		return nil
+	}
+	i := 0
+	for j, existing := range m.buffers {
+		if existing == b {
+			i = j
+		}
+	}
+	count := len(m.buffers)
+	return m.buffers[((i+n)%count+count)%count]
+}
+
+func (m *BufferManager) Alternate() *Buffer {
+	return m.alternate
+}
+
+// List returns the buffers in ID order.
+func (m *BufferManager) List() []*Buffer {
+	return append([]*Buffer(nil), m.buffers...)
+}
+
+func (m *BufferManager) Len() int {
+	return len(m.buffers)
+}
+
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+// switchTo makes b the active buffer. The one left behind becomes the alternate for Ctrl-^.
+func (e *Editor) switchTo(b *Buffer) {
+	if b == e.Buffer {
+		return
+	}
+	e.pushJump()
+	if e.id != 0 { // Buffers outside the list, like a list window's, can't be the alternate
+		e.buffers.alternate = e.Buffer
+	}
+	e.showBuffer(b)
+	e.registers.SetReadOnly(register.FileName, b.filename)
+}
+
+// fileInfo is the line Vim shows after switching buffers, e.g. `"main.go" [Modified] 42 lines --50%--`.
+func (e *Editor) fileInfo() string {
+	modified := ""
+	if e.modified {
+		modified = " [Modified]"
+	}
+	line, _ := e.GetLineColumn()
+	lines := e.buffer.LineCount()
+	return fmt.Sprintf("%q%s %s --%d%%--", e.Name(), modified, plural(lines, "line"), (line+1)*100/lines)
+}
+
+// NextBuffer and PrevBuffer cycle through the buffer list (":bn", ":bp").
+func (e *Editor) NextBuffer(n int) {
+	e.switchTo(e.buffers.Next(e.Buffer, max(1, n)))
+	e.SetMessage(e.fileInfo())
+}
+
+func (e *Editor) PrevBuffer(n int) {
+	e.switchTo(e.buffers.Prev(e.Buffer, max(1, n)))
+	e.SetMessage(e.fileInfo())
+}
+
+// GoToBuffer switches to a buffer by number or name (":b").
+func (e *Editor) GoToBuffer(arg string) error {
+	b, err := e.buffers.Find(arg)
+	if err != nil {
+		return err
+	}
+	e.switchTo(b)
+	e.SetMessage(e.fileInfo())
+	return nil
+}
+
+// AlternateBuffer switches back to the previous buffer (Ctrl-^), or to buffer {count} when one was typed.
+func (e *Editor) AlternateBuffer() bool {
+	target := e.buffers.Alternate()
+	if e.vimState.HasCount() {
+		id := e.GetCountAndClear()
+		b, ok := e.buffers.Get(id)
+		if !ok {
+			e.SetMessage(fmt.Sprintf("E86: Buffer %d does not exist", id))
+			return e.fail()
+		}
+		target = b
+	}
+	if target == nil {
+		e.SetMessage("E23: No alternate file")
+		return e.fail()
+	}
+	e.switchTo(target)
+	e.SetMessage(e.fileInfo())
+	return true
+}
+
+// DeleteBuffer closes a buffer (":bd"), the current one when arg is empty. Unsaved changes need force.
+// Closing the last buffer leaves an empty "[No Name]" one, so there's always something to edit.
+func (e *Editor) DeleteBuffer(arg string, force bool) error {
+	target := e.Buffer
+	if arg != "" {
+		b, err := e.buffers.Find(arg)
+		if err != nil {
+			return err
+		}
+		target = b
+	}
+	if target.modified && !force {
+		return fmt.Errorf("E89: No write since last change for buffer %d (add ! to override)", target.id)
+	}
+
+	if e.buffers.Len() == 1 {
+		empty, err := newBuffer("")
+		if err != nil {
+			return err
+		}
+		e.buffers.Remove(target)
+		e.buffers.Add(empty)
+		e.replaceInWindows(target, empty)
+		e.registers.SetReadOnly(register.FileName, "")
+		return nil
+	}
+
+	// Windows showing the buffer move on to the alternate one, or the next in the list
+	next := e.buffers.Alternate()
+	if next == nil || next == target {
+		next = e.buffers.Next(target, 1)
+	}
+	e.buffers.Remove(target)
+	e.replaceInWindows(target, next)
+	if e.buffers.alternate == e.Buffer {
+		// The alternate took the deleted buffer's place, Ctrl-^ would go nowhere
+		e.buffers.alternate = nil
+	}
+	e.registers.SetReadOnly(register.FileName, e.filename)
+	return nil
+}
+
+// checkModifiedBuffers finds a buffer with unsaved changes other than the current one, which ":q" must not drop.
+func (e *Editor) checkModifiedBuffers() error {
+	for _, b := range e.buffers.List() {
+		if b != e.Buffer && b.modified {
+			return fmt.Errorf("E162: No write since last change for buffer %q", b.Name())
+		}
+	}
+	return nil
+}
+
+// listBuffers is ":ls". Flags are Vim's: % current, # alternate, a shown, h hidden, + modified.
+func (e *Editor) listBuffers() {
+	lines := make([]string, 0, e.buffers.Len())
+	for _, b := range e.buffers.List() {
+		flag, state, mod := ' ', 'h', ' '
+		switch b {
+		case e.Buffer:
+			flag, state = '%', 'a'
+		case e.buffers.Alternate():
+			flag = '#'
+		}
+		if b.modified {
+			mod = '+'
+		}
+		line := b.buffer.CharToLine(e.cursorIn(b))
+		lines = append(lines, fmt.Sprintf("%3d %c%c %c %-30s line %d", b.id, flag, state, mod, fmt.Sprintf("%q", b.Name()), line+1))
+	}
+	e.SetOutput(lines)
+}
+
+// completeBuffer offers the names of open buffers for ":b" and ":bd".
+func completeBuffer(e *Editor, arg string) []string {
+	names := make([]string, 0, e.buffers.Len())
+	for _, b := range e.buffers.List() {
+		names = append(names, b.Name())
+	}
+	return names
+}
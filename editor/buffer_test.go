@@ -0,0 +1,81 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// openFiles opens a file per name, holding its name as text. The last one is current, the one before the alternate.
+func openFiles(t *testing.T, names ...string) *Editor {
+	t.Helper()
+	e, err := New()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(name), 0o644))
+		require.NoError(t, e.OpenFile(path))
+	}
+	return e
+}
+
+func bufferIDs(e *Editor) []int {
+	var ids []int
+	for _, b := range e.buffers.List() {
+		ids = append(ids, b.id)
+	}
+	return ids
+}
+
+func TestDeleteBuffer(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     []string
+		commands  []string
+		current   string // Text of the buffer shown after
+		ids       []int
+		alternate int // 0 when there's none
+	}{
+		{name: "current goes to the alternate", files: []string{"one", "two", "three"}, commands: []string{"bd"}, current: "two", ids: []int{1, 2}},
+		{name: "alternate is the target", files: []string{"one", "two", "three"}, commands: []string{"bd 2"}, current: "three", ids: []int{1, 3}},
+		{name: "by name", files: []string{"one", "two", "three"}, commands: []string{"bd one"}, current: "three", ids: []int{2, 3}, alternate: 2},
+		{name: "no alternate goes to the next", files: []string{"one", "two", "three"}, commands: []string{"bd 2", "bd"}, current: "one", ids: []int{1}},
+		{name: "last buffer leaves an empty one", files: []string{"one"}, commands: []string{"bd"}, current: "", ids: []int{2}},
+		{name: "last of several", files: []string{"one", "two"}, commands: []string{"bd 1", "bd"}, current: "", ids: []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := openFiles(t, tt.files...)
+			for _, command := range tt.commands {
+				require.NoError(t, e.ExecuteCommand(command))
+			}
+			require.Equal(t, tt.current, e.buffer.String())
+			require.Equal(t, tt.ids, bufferIDs(e))
+
+			if tt.alternate == 0 {
+				require.False(t, e.AlternateBuffer())
+				require.Equal(t, "E23: No alternate file", e.GetMessage())
+				return
+			}
+			require.True(t, e.AlternateBuffer())
+			require.Equal(t, tt.alternate, e.Buffer.id)
+		})
+	}
+}
+
+func TestDeleteBufferModified(t *testing.T) {
+	e := openFiles(t, "one")
+	e.InsertChar('x')
+	require.EqualError(t, e.ExecuteCommand("bd"), "E89: No write since last change for buffer 1 (add ! to override)")
+	require.Equal(t, "xone", e.buffer.String())
+
+	require.NoError(t, e.ExecuteCommand("bd!"))
+	require.Equal(t, "[No Name]", e.Name())
+	require.Equal(t, []int{2}, bufferIDs(e))
+
+	require.EqualError(t, e.ExecuteCommand("bd 7"), "E86: Buffer 7 does not exist")
+}
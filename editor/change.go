@@ -0,0 +1,346 @@
+package editor
+
+import (
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/register"
+)
+
+// ### CHANGES AND DOT REPEAT
+
+type changeKind int
+
+const (
+	changeNone         changeKind = iota
+	changeOperator                // {op}{motion} e.g. "d3w", "cw"
+	changeOperatorLine            // {op}{op} e.g. "dd", "cc"
+	changeInsert                  // i, a, A, I, o, O followed by typed text
+	changeDeleteChar              // x
+	changeReplaceChar             // r{char}
+	changePut                     // p, P
+)
+
+// backspaceKey marks a backspace inside change.typed, so replaying an insert can delete text it didn't type.
+const backspaceKey = '\b'
+
+// change is the last complete change in a form that can be replayed with ".". It's recorded once the command
+// ran; commands that end in insert mode are completed with the typed keys when insert mode is left.
+type change struct {
+	kind     changeKind
+	op       rune   // d, c or y for operators, the insert command for changeInsert
+	motion   string // Motion key for changeOperator
+	count    int
+	hasCount bool // A count was typed, which changes motions like "G"
+	register rune
+	char     rune // Replacement char for r
+	after    bool // p vs P
+	typed    []rune
+}
+
+func (c change) entersInsert() bool {
+	return c.kind == changeInsert || (c.op == 'c' && (c.kind == changeOperator || c.kind == changeOperatorLine))
+}
+
+// recordChange remembers a change for "." when ok says it ran, and passes ok on. A failed change is dropped
+// and "." keeps repeating the one before it. Nothing is recorded while "." itself is replaying.
+func (e *Editor) recordChange(c change, ok bool) bool {
+	if e.repeating {
+		return ok
+	}
+	if !ok {
+		e.pendingChange = nil
+		return false
+	}
+	if c.entersInsert() {
+		e.pendingChange = &c
+		return true
+	}
+	e.lastChange = c
+	return true
+}
+
+// ApplyOperator runs d, c or y over a motion ("dw", "c$", "yG"). It returns false if the motion failed.
+func (e *Editor) ApplyOperator(op rune, motion string) bool {
+	hasCount := e.vimState.HasCount()
+	n := e.GetCountAndClear()
+	name := e.vimState.GetRegisterAndClear()
+
+	ok := e.operate(op, motion, n, hasCount, name)
+	if op == 'y' {
+		return ok
+	}
+	return e.recordChange(change{kind: changeOperator, op: op, motion: motion, count: n, hasCount: hasCount, register: name}, ok)
+}
+
+// ApplyOperatorLine runs a doubled operator on count lines ("dd", "cc", "yy").
+func (e *Editor) ApplyOperatorLine(op rune) {
+	switch op {
+	case 'd':
+		e.DeleteLines()
+	case 'y':
+		e.YankLines()
+	case 'c':
+		n := e.GetCountAndClear()
+		name := e.vimState.GetRegisterAndClear()
+		e.changeLines(n, name)
+		e.recordChange(change{kind: changeOperatorLine, op: op, count: n, register: name}, true)
+	}
+}
+
+func (e *Editor) operate(op rune, motion string, n int, hasCount bool, name rune) bool {
+	pos := e.cursor.GetPosition()
+
+	// "cw" on a word behaves like "ce", it doesn't eat the whitespace after the word
+	if op == 'c' && motion == "w" && !isBlank(e.buffer.CharAt(pos)) {
+		motion = "e"
+	}
+
+	target, kind, ok := e.motionTarget(motion, n, hasCount)
+	if !ok {
+		return e.fail()
+	}
+
+	if kind == linewise {
+		first := e.buffer.CharToLine(min(pos, target))
+		last := e.buffer.CharToLine(max(pos, target))
+		_ = e.cursor.SetPosition(e.buffer.LineToChar(first))
+		switch op {
+		case 'd':
+			e.deleteLines(last-first+1, name)
+		case 'y':
+			e.yankLines(last-first+1, name)
+			e.moveToFirstNonBlank(first)
+		case 'c':
+			e.changeLines(last-first+1, name)
+		}
+		return true
+	}
+
+	start, end := min(pos, target), max(pos, target)
+	if kind == inclusive {
+		end = min(end+1, e.buffer.Length())
+	}
+	// An exclusive motion that ends at the start of a later line stops at the end of the previous one, so "dw"
+	// on the last word of a line leaves the newline alone
+	if kind == exclusive && end > start && e.buffer.CharAt(end-1) == '\n' {
+		end--
+	}
+
+	text := e.buffer.Substring(start, end)
+	if op == 'y' {
+		e.yank(name, text, false, 0)
+		_ = e.cursor.SetPosition(start)
+		return true
+	}
+
+	e.beginChange()
+	defer e.endChange()
+
+	e.deleteText(start, end)
+	_ = e.cursor.SetPosition(start)
+	if err := e.registers.Delete(name, register.Register{Text: text, Kind: register.Charwise}); err != nil {
+		e.SetMessage(err.Error())
+	}
+	if op == 'c' {
+		e.SetMode(ModeInsert)
+	}
+	return true
+}
+
+// changeLines empties count lines into one line and starts insert mode on it ("cc").
+func (e *Editor) changeLines(n int, name rune) {
+	line, _ := e.GetLineColumn()
+	start, end, _ := e.lineSpan(n)
+	text := e.buffer.Substring(start, end)
+	if strings.HasSuffix(text, "\n") {
+		end--
+	}
+
+	e.beginChange()
+	defer e.endChange()
+
+	e.deleteText(start, end)
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	if err := e.registers.Delete(name, register.Register{Text: text, Kind: register.Linewise}); err != nil {
+		e.SetMessage(err.Error())
+	}
+	_ = e.cursor.SetPosition(e.buffer.LineToChar(line))
+	e.SetMode(ModeInsert)
+}
+
+// StartInsert enters insert mode the way i, a, A, I, o and O do.
+func (e *Editor) StartInsert(cmd rune) {
+	n := e.GetCountAndClear()
+	e.startInsert(cmd)
+	e.recordChange(change{kind: changeInsert, op: cmd, count: n}, true)
+}
+
+func (e *Editor) startInsert(cmd rune) {
+	pos := e.cursor.GetPosition()
+	line, _ := e.GetLineColumn()
+
+	// The opened line is part of the insert session, so it's undone together with the typed text
+	e.SetMode(ModeInsert)
+
+	switch cmd {
+	case 'a':
+		if pos < e.buffer.Length() && e.buffer.CharAt(pos) != '\n' {
+			e.cursor.MoveRight()
+		}
+	case 'A':
+		_ = e.cursor.SetPosition(e.buffer.LineToChar(line) + e.buffer.LineLength(line))
+	case 'I':
+		e.cursor.MoveToFirstNonBlank()
+	case 'o', 'O':
+		e.openLine(line, cmd == 'O')
+	}
+}
+
+// openLine inserts an empty line below or above line and puts the cursor on it.
+func (e *Editor) openLine(line int, above bool) {
+	if above {
+		start := e.buffer.LineToChar(line)
+		e.insertText(start, "\n")
+		_ = e.cursor.SetPosition(start)
+		return
+	}
+	end := e.buffer.LineToChar(line) + e.buffer.LineLength(line)
+	e.insertText(end, "\n")
+	_ = e.cursor.SetPosition(end + 1)
+}
+
+// repeatInsert types an insert's keys n more times the way its command does: "3ohi<Esc>" gives three lines,
+// each copy after the first opens a line below the previous one.
+func (e *Editor) repeatInsert(cmd rune, typed []rune, n int) {
+	for range n {
+		if cmd == 'o' || cmd == 'O' {
+			line, _ := e.GetLineColumn()
+			e.openLine(line, false)
+		}
+		e.replayTyped(typed)
+	}
+}
+
+// ReplaceChar replaces count chars under the cursor with ch ("r{char}"). Fails if the line is too short.
+func (e *Editor) ReplaceChar(ch rune) bool {
+	n := e.GetCountAndClear()
+	e.vimState.GetRegisterAndClear()
+	return e.recordChange(change{kind: changeReplaceChar, count: n, char: ch}, e.replaceChars(n, ch))
+}
+
+func (e *Editor) replaceChars(n int, ch rune) bool {
+	pos := e.cursor.GetPosition()
+	line, col := e.GetLineColumn()
+	if col+n > e.buffer.LineLength(line) {
+		return e.fail()
+	}
+
+	e.beginChange()
+	defer e.endChange()
+
+	e.deleteText(pos, pos+n)
+	e.insertText(pos, strings.Repeat(string(ch), n))
+	_ = e.cursor.SetPosition(pos + n - 1)
+	return true
+}
+
+// finishInsert runs when insert mode is left: it completes a pending change with the typed keys
+// and repeats the insert for a count, e.g. "3ihi<Esc>".
+func (e *Editor) finishInsert() {
+	pending := e.pendingChange
+	e.pendingChange = nil
+	if pending == nil {
+		return
+	}
+
+	pending.typed = append([]rune(nil), e.typed...)
+	e.lastChange = *pending
+
+	if pending.kind == changeInsert && pending.count > 1 {
+		e.repeating = true
+		e.repeatInsert(pending.op, pending.typed, pending.count-1)
+		e.repeating = false
+	}
+}
+
+func (e *Editor) replayTyped(typed []rune) {
+	for _, r := range typed {
+		if r == backspaceKey {
+			e.Backspace()
+			continue
+		}
+		e.InsertChar(r)
+	}
+}
+
+// RepeatLastChange replays the last change at the cursor ("."). A count replaces the recorded one.
+func (e *Editor) RepeatLastChange() bool {
+	c := e.lastChange
+	if e.vimState.HasCount() {
+		c.count = e.GetCountAndClear()
+		c.hasCount = true
+	}
+	e.ClearCount()
+	if c.kind == changeNone {
+		return e.fail()
+	}
+
+	e.repeating = true
+	defer func() { e.repeating = false }()
+
+	e.beginChange()
+	defer e.endChange()
+
+	ok := true
+	switch c.kind {
+	case changeOperator:
+		ok = e.operate(c.op, c.motion, c.count, c.hasCount, c.register)
+	case changeOperatorLine:
+		if c.op == 'c' {
+			e.changeLines(c.count, c.register)
+		} else {
+			e.deleteLines(c.count, c.register)
+		}
+	case changeInsert:
+		e.startInsert(c.op)
+		e.replayTyped(c.typed)
+		e.repeatInsert(c.op, c.typed, c.count-1)
+	case changeDeleteChar:
+		ok = e.deleteChars(c.count, c.register)
+	case changeReplaceChar:
+		ok = e.replaceChars(c.count, c.char)
+	case changePut:
+		ok = e.paste(c.count, c.register, c.after)
+	}
+
+	if e.GetMode() == ModeInsert {
+		if c.kind != changeInsert {
+			e.replayTyped(c.typed)
+		}
+		e.SetMode(ModeNormal)
+	}
+	// Remember the count that was used, so a plain "." after "3." keeps deleting 3
+	e.lastChange.count, e.lastChange.hasCount = c.count, c.hasCount
+	return ok
+}
+
+// insertedText applies the recorded backspaces to the typed keys, giving what the '.' register holds.
+func insertedText(typed []rune) string {
+	var out []rune
+	for _, r := range typed {
+		if r == backspaceKey {
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+func isBlank(ch rune) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == 0
+}
@@ -0,0 +1,128 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// typeInsert types text in insert mode and leaves it, like "{text}<Esc>".
+func typeInsert(e *Editor, text string) {
+	for _, r := range text {
+		e.InsertChar(r)
+	}
+	e.SetMode(ModeNormal)
+}
+
+// typeCount types a count before a command.
+func typeCount(e *Editor, n string) {
+	for _, r := range n {
+		e.HandleDigit(r)
+	}
+}
+
+func TestRepeatLastChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		run    func(t *testing.T, e *Editor)
+		want   string
+		cursor int
+	}{
+		{
+			name: "failed dw at the end is not repeated",
+			text: "ab cd\n",
+			run: func(t *testing.T, e *Editor) {
+				_ = e.cursor.SetPosition(6)
+				require.False(t, e.ApplyOperator('d', "w"))
+				_ = e.cursor.SetPosition(0)
+				require.False(t, e.RepeatLastChange())
+			},
+			want:   "ab cd\n",
+			cursor: 0,
+		},
+		{
+			name: "failed dw keeps the change before it",
+			text: "ab cd\n",
+			run: func(t *testing.T, e *Editor) {
+				e.DeleteUnderCursor()
+				_ = e.cursor.SetPosition(5)
+				require.False(t, e.ApplyOperator('d', "w"))
+				_ = e.cursor.SetPosition(2)
+				require.True(t, e.RepeatLastChange())
+			},
+			want:   "b d\n",
+			cursor: 2,
+		},
+		{
+			name: "3rx on a short line is not recorded",
+			text: "ab\ncdef",
+			run: func(t *testing.T, e *Editor) {
+				e.ReplaceChar('z')
+				typeCount(e, "3")
+				require.False(t, e.ReplaceChar('x'))
+				_ = e.cursor.SetPosition(3)
+				require.True(t, e.RepeatLastChange())
+			},
+			want:   "zb\nzdef",
+			cursor: 3,
+		},
+		{
+			name: "failed cj leaves no pending change",
+			text: "abc",
+			run: func(t *testing.T, e *Editor) {
+				e.DeleteUnderCursor()
+				require.False(t, e.ApplyOperator('c', "j"))
+				require.Nil(t, e.pendingChange)
+				require.Equal(t, ModeNormal, e.GetMode())
+				require.True(t, e.RepeatLastChange())
+			},
+			want:   "c",
+			cursor: 0,
+		},
+		{
+			name: "cw then . on the next word",
+			text: "foo bar baz",
+			run: func(t *testing.T, e *Editor) {
+				require.True(t, e.ApplyOperator('c', "w"))
+				typeInsert(e, "qux")
+				_ = e.cursor.SetPosition(4)
+				require.True(t, e.RepeatLastChange())
+			},
+			want:   "qux qux baz",
+			cursor: 7,
+		},
+		{
+			name: "d3w then . deletes three more",
+			text: "a b c d e f g",
+			run: func(t *testing.T, e *Editor) {
+				e.HandleMotionDigit('3')
+				require.True(t, e.ApplyOperator('d', "w"))
+				require.True(t, e.RepeatLastChange())
+			},
+			want:   "g",
+			cursor: 0,
+		},
+		{
+			name: "3ohi then . opens three more lines",
+			text: "x",
+			run: func(t *testing.T, e *Editor) {
+				typeCount(e, "3")
+				e.StartInsert('o')
+				typeInsert(e, "hi")
+				require.True(t, e.RepeatLastChange())
+			},
+			want:   "x\nhi\nhi\nhi\nhi\nhi\nhi",
+			cursor: 19,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t, tt.text)
+			tt.run(t, e)
+			require.Equal(t, tt.want, e.buffer.String())
+			require.Equal(t, tt.cursor, e.cursor.GetPosition())
+		})
+	}
+}
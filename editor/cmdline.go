@@ -0,0 +1,215 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/ogzhanolguncu/go_editor/history"
+	"github.com/ogzhanolguncu/go_editor/register"
+)
+
+// ### COMMAND LINE
+
+// CommandLine is the line being typed after ":". Keeps its own cursor so the text can be edited before running it.
+type CommandLine struct {
+	prompt     rune
+	label      string // Shown instead of the prompt char by Input
+	text       []rune
+	cursor     int
+	browser    *history.Browser // Set while Up/Down walk the history, typing drops it
+	completion *completion      // Set while Tab cycles candidates, typing drops it
+
+	done func(text string) error // Set by Input, gets the text instead of it running as a command
+}
+
+// StartCommandLine switches to command mode. A count pre-fills a range, so "3:" gives ":.,.+2".
+func (e *Editor) StartCommandLine(prompt rune) {
+	e.cmdline = CommandLine{prompt: prompt}
+	if e.vimState.HasCount() {
+		if n := e.GetCountAndClear(); n > 1 && prompt == ':' {
+			e.cmdline.text = []rune(fmt.Sprintf(".,.+%d", n-1))
+			e.cmdline.cursor = len(e.cmdline.text)
+		}
+	}
+	e.CancelPending()
+	e.SetMode(ModeCommand)
+}
+
+// Input asks for a line of text on the command line, like Vim's input(). The text starts as text, done gets
+// what was submitted with Enter. Esc drops it without calling done.
+func (e *Editor) Input(label, text string, done func(text string) error) {
+	e.cmdline = CommandLine{prompt: '@', label: label, text: []rune(text), done: done}
+	e.cmdline.cursor = len(e.cmdline.text)
+	e.CancelPending()
+	e.SetMode(ModeCommand)
+}
+
+// GetCommandLine returns the prompt, the typed text and the cursor column within the text.
+func (e *Editor) GetCommandLine() (string, string, int) {
+	prompt := string(e.cmdline.prompt)
+	if e.cmdline.label != "" {
+		prompt = e.cmdline.label
+	}
+	return prompt, string(e.cmdline.text), e.cmdline.cursor
+}
+
+func (e *Editor) CmdInsert(r rune) {
+	c := &e.cmdline
+	c.text = append(c.text[:c.cursor], append([]rune{r}, c.text[c.cursor:]...)...)
+	c.cursor++
+	e.cmdlineEdited()
+}
+
+// CmdBackspace deletes before the cursor. Backspacing on an empty line leaves command mode like Vim does.
+func (e *Editor) CmdBackspace() {
+	c := &e.cmdline
+	if len(c.text) == 0 {
+		e.CancelCommandLine()
+		return
+	}
+	if c.cursor == 0 {
+		return
+	}
+	c.text = append(c.text[:c.cursor-1], c.text[c.cursor:]...)
+	c.cursor--
+	e.cmdlineEdited()
+}
+
+func (e *Editor) CmdDelete() {
+	c := &e.cmdline
+	if c.cursor >= len(c.text) {
+		return
+	}
+	c.text = append(c.text[:c.cursor], c.text[c.cursor+1:]...)
+	e.cmdlineEdited()
+}
+
+// CmdDeleteWord deletes the word before the cursor (Ctrl-W).
+func (e *Editor) CmdDeleteWord() {
+	c := &e.cmdline
+	i := c.cursor
+	for i > 0 && c.text[i-1] == ' ' {
+		i--
+	}
+	for i > 0 && c.text[i-1] != ' ' {
+		i--
+	}
+	c.text = append(c.text[:i], c.text[c.cursor:]...)
+	c.cursor = i
+	e.cmdlineEdited()
+}
+
+// CmdClear deletes everything before the cursor (Ctrl-U).
+func (e *Editor) CmdClear() {
+	c := &e.cmdline
+	c.text = c.text[c.cursor:]
+	c.cursor = 0
+	e.cmdlineEdited()
+}
+
+// cmdlineEdited runs after the text changed by typing. Up and Tab start over from the new text.
+func (e *Editor) cmdlineEdited() {
+	e.cmdline.browser = nil
+	e.cmdline.completion = nil
+	e.updateSearchPreview()
+}
+
+// CmdHistory replaces the text with an older (Up) or newer (Down) history entry. Only entries starting with
+// what was typed before browsing are offered.
+func (e *Editor) CmdHistory(older bool) {
+	c := &e.cmdline
+	if c.browser == nil {
+		c.browser = history.NewBrowser(e.cmdHistory.List(history.KindFor(c.prompt)), string(c.text))
+	}
+
+	var entry string
+	var ok bool
+	if older {
+		entry, ok = c.browser.Older()
+	} else {
+		entry, ok = c.browser.Newer()
+	}
+	if !ok {
+		return
+	}
+	c.text = []rune(entry)
+	c.cursor = len(c.text)
+	c.completion = nil
+	e.updateSearchPreview()
+}
+
+func (e *Editor) CmdMoveLeft() {
+	e.cmdline.cursor = max(0, e.cmdline.cursor-1)
+	e.cmdline.completion = nil
+}
+
+func (e *Editor) CmdMoveRight() {
+	e.cmdline.cursor = min(len(e.cmdline.text), e.cmdline.cursor+1)
+	e.cmdline.completion = nil
+}
+
+func (e *Editor) CmdMoveToStart() {
+	e.cmdline.cursor = 0
+	e.cmdline.completion = nil
+}
+
+func (e *Editor) CmdMoveToEnd() {
+	e.cmdline.cursor = len(e.cmdline.text)
+	e.cmdline.completion = nil
+}
+
+func (e *Editor) CancelCommandLine() {
+	if e.isSearching() {
+		e.cancelSearch()
+	}
+	e.cmdline = CommandLine{}
+	e.SetMode(ModeNormal)
+}
+
+// SubmitCommandLine leaves command mode and runs what was typed. Errors are shown on the status line.
+func (e *Editor) SubmitCommandLine() {
+	text := string(e.cmdline.text)
+	prompt := e.cmdline.prompt
+	done := e.cmdline.done
+	e.cmdline = CommandLine{}
+	e.SetMode(ModeNormal)
+
+	e.cmdHistory.Add(history.KindFor(prompt), text)
+	if done != nil {
+		if err := done(text); err != nil {
+			e.SetMessage(err.Error())
+			e.fail()
+		}
+		return
+	}
+	if prompt == '/' || prompt == '?' {
+		if err := e.submitSearch(text, prompt == '/'); err != nil {
+			e.SetMessage(err.Error())
+			e.fail()
+		}
+		return
+	}
+
+	if text == "" {
+		return
+	}
+	e.registers.SetReadOnly(register.LastCommand, text)
+	if err := e.ExecuteCommand(text); err != nil {
+		e.SetMessage(err.Error())
+		e.fail()
+	}
+}
+
+// SetOutput shows multi line output, e.g. ":registers", until the next key press.
+func (e *Editor) SetOutput(lines []string) {
+	e.output = lines
+}
+
+func (e *Editor) TakeOutput() []string {
+	lines := e.output
+	e.output = nil
+	return lines
+}
+
+func (e *Editor) ShouldQuit() bool {
+	return e.quit
+}
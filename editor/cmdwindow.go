@@ -0,0 +1,88 @@
+package editor
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/history"
+)
+
+// ### COMMAND-LINE WINDOW
+
+const cmdWindowName = "[Command Line]"
+
+var errCmdWindow = errors.New("E11: Invalid in command-line window; <CR> executes, CTRL-C quits")
+
+// cmdWindow is the "q:" window. It swaps a scratch buffer holding the history in, the file's buffer waits in saved.
+type cmdWindow struct {
+	prompt rune
+	saved  *Buffer
+}
+
+// OpenCommandWindow shows a history ("q:", "q/", "q?") as a buffer with an empty line at the end.
+// Lines can be edited like any text, Enter runs the one under the cursor.
+func (e *Editor) OpenCommandWindow(prompt rune) error {
+	if e.cmdWindow != nil {
+		return errCmdWindow
+	}
+	e.CancelPending()
+
+	entries := e.cmdHistory.List(history.KindFor(prompt)).Entries()
+	scratch, err := newBuffer(strings.Join(append(entries, ""), "\n"))
+	if err != nil {
+		return err
+	}
+	scratch.filename = cmdWindowName
+
+	e.cmdWindow = &cmdWindow{prompt: prompt, saved: e.Buffer}
+	e.showBuffer(scratch)
+	e.cursor.MoveToEnd()
+	return nil
+}
+
+func (e *Editor) InCommandWindow() bool {
+	return e.cmdWindow != nil
+}
+
+// CloseCommandWindow goes back to the file, whatever was edited in the window is dropped.
+func (e *Editor) CloseCommandWindow() {
+	if e.cmdWindow == nil {
+		return
+	}
+	if e.GetMode() == ModeInsert {
+		e.SetMode(ModeNormal)
+	}
+	e.showBuffer(e.cmdWindow.saved)
+	e.cmdWindow = nil
+}
+
+// ExecuteCommandWindowLine closes the window and runs the line under the cursor as if it was typed at the prompt.
+func (e *Editor) ExecuteCommandWindowLine() {
+	if e.cmdWindow == nil {
+		return
+	}
+	line, _ := e.GetLineColumn()
+	text := trimNewline(e.buffer.Line(line))
+	prompt := e.cmdWindow.prompt
+	e.CloseCommandWindow()
+
+	if text == "" {
+		return
+	}
+	e.cmdline = CommandLine{prompt: prompt, text: []rune(text)}
+	e.search.origin = e.cursor.GetPosition()
+	e.search.count = 0
+	e.SetMode(ModeCommand)
+	e.SubmitCommandLine()
+}
+
+// ### HISTORY FILE
+
+// LoadHistory merges command and search history, and the recently edited files, saved by an earlier session.
+func (e *Editor) LoadHistory(path string) error {
+	return e.cmdHistory.Load(path)
+}
+
+func (e *Editor) SaveHistory(path string) error {
+	return e.cmdHistory.Save(path)
+}
@@ -0,0 +1,528 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/ex"
+	"github.com/ogzhanolguncu/go_editor/layout"
+	"github.com/ogzhanolguncu/go_editor/register"
+	"github.com/ogzhanolguncu/go_editor/search"
+)
+
+// ### EX COMMANDS
+
+// ExCommand describes a ":" command. New commands are added with RegisterCommand, the command line
+// finds them by name so nothing else has to change.
+type ExCommand struct {
+	Name   string // Full name, e.g. "write"
+	MinLen int    // Shortest accepted abbreviation, e.g. 1 so ":w" works
+	Range  bool   // Accepts a line range
+	Bang   bool   // Accepts "!"
+	Run    func(e *Editor, args ExArgs) error
+
+	// Complete returns candidates for the argument word being typed. Tab filters them fuzzily, so it can
+	// return everything that fits the context. Nil means the argument doesn't complete.
+	Complete func(e *Editor, arg string) []string
+}
+
+// ExArgs is what a command gets to run with. Lines are 0-based and already resolved.
+type ExArgs struct {
+	Name     string
+	Line1    int
+	Line2    int
+	HasRange bool
+	Bang     bool
+	Args     string
+}
+
+type CommandRegistry struct {
+	commands []*ExCommand
+}
+
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{}
+}
+
+// Register adds a command, replacing an existing one with the same name.
+func (r *CommandRegistry) Register(cmd ExCommand) {
+	if cmd.MinLen <= 0 || cmd.MinLen > len(cmd.Name) {
+		cmd.MinLen = len(cmd.Name)
+	}
+	for i, existing := range r.commands {
+		if existing.Name == cmd.Name {
+			r.commands[i] = &cmd
+			return
+		}
+	}
+	r.commands = append(r.commands, &cmd)
+}
+
+// Lookup finds a command by its full name or any abbreviation at least MinLen long.
+func (r *CommandRegistry) Lookup(name string) (*ExCommand, bool) {
+	for _, cmd := range r.commands {
+		if cmd.Name == name {
+			return cmd, true
+		}
+	}
+	for _, cmd := range r.commands {
+		if len(name) >= cmd.MinLen && strings.HasPrefix(cmd.Name, name) {
+			return cmd, true
+		}
+	}
+	return nil, false
+}
+
+// Names returns every registered command name, sorted.
+func (r *CommandRegistry) Names() []string {
+	names := make([]string, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		names = append(names, cmd.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (e *Editor) RegisterCommand(cmd ExCommand) {
+	e.commands.Register(cmd)
+}
+
+// ExecuteCommand parses and runs a command line like "1,5d" or "w! out.txt".
+func (e *Editor) ExecuteCommand(line string) error {
+	parsed, err := ex.Parse(line)
+	if err != nil {
+		return err
+	}
+
+	start, end, err := parsed.Range.Resolve(exResolver{e})
+	if err != nil {
+		return err
+	}
+	args := ExArgs{
+		Name:     parsed.Name,
+		Line1:    max(0, start-1),
+		Line2:    max(0, end-1),
+		HasRange: !parsed.Range.IsEmpty(),
+		Bang:     parsed.Bang,
+		Args:     parsed.Args,
+	}
+
+	// ":{number}" jumps to the line
+	if parsed.Name == "" {
+		if !args.HasRange {
+			return nil
+		}
+		e.pushJump()
+		e.moveToFirstNonBlank(args.Line2)
+		return nil
+	}
+
+	cmd, ok := e.commands.Lookup(parsed.Name)
+	if !ok {
+		return fmt.Errorf("E492: Not an editor command: %s", strings.TrimSpace(line))
+	}
+	if args.Bang && !cmd.Bang {
+		return errors.New("E477: No ! allowed")
+	}
+	if args.HasRange && !cmd.Range {
+		return errors.New("E481: No range allowed")
+	}
+	return cmd.Run(e, args)
+}
+
+// exResolver answers address lookups for the parser, it speaks 1-based lines.
+type exResolver struct {
+	e *Editor
+}
+
+func (r exResolver) CurrentLine() int {
+	line, _ := r.e.GetLineColumn()
+	return line + 1
+}
+
+func (r exResolver) LastLine() int {
+	return r.e.buffer.LineCount()
+}
+
+func (r exResolver) MarkLine(mark rune) (int, error) {
+	pos, err := r.e.markPosition(mark)
+	if err != nil {
+		return 0, err
+	}
+	return r.e.buffer.CharToLine(pos) + 1, nil
+}
+
+// SearchLine finds the next line matching pattern after (or before) from, wrapping around the buffer.
+func (r exResolver) SearchLine(pattern string, from int, forward bool) (int, error) {
+	re, err := search.Compile(pattern, r.e.ignoreCase(pattern))
+	if err != nil {
+		return 0, err
+	}
+
+	n := r.e.buffer.LineCount()
+	for i := 1; i <= n; i++ {
+		line := from + i
+		if !forward {
+			line = from - i
+		}
+		line = (line-1+n)%n + 1
+		if re.MatchString(r.e.buffer.Line(line - 1)) {
+			return line, nil
+		}
+	}
+	return 0, fmt.Errorf("E486: Pattern not found: %s", pattern)
+}
+
+// ### BUILTIN COMMANDS
+
+var errNoWrite = errors.New("E37: No write since last change (add ! to override)")
+
+func registerBuiltinCommands(r *CommandRegistry) {
+	r.Register(ExCommand{Name: "write", MinLen: 1, Bang: true, Complete: completePath, Run: func(e *Editor, args ExArgs) error {
+		if e.cmdWindow != nil {
+			return errCmdWindow
+		}
+		return e.Save(args.Args)
+	}})
+	r.Register(ExCommand{Name: "quit", MinLen: 1, Bang: true, Run: func(e *Editor, args ExArgs) error {
+		if e.cmdWindow != nil {
+			e.CloseCommandWindow()
+			return nil
+		}
+		// With more windows open ":q" only closes the current one
+		if !e.lastWindow() {
+			return e.CloseWindow()
+		}
+		if e.modified && !args.Bang {
+			return errNoWrite
+		}
+		if err := e.checkModifiedBuffers(); err != nil && !args.Bang {
+			return err
+		}
+		e.quit = true
+		return nil
+	}})
+	r.Register(ExCommand{Name: "wq", Bang: true, Complete: completePath, Run: func(e *Editor, args ExArgs) error {
+		if e.cmdWindow != nil {
+			return errCmdWindow
+		}
+		if err := e.Save(args.Args); err != nil {
+			return err
+		}
+		if !e.lastWindow() {
+			return e.CloseWindow()
+		}
+		if err := e.checkModifiedBuffers(); err != nil && !args.Bang {
+			return err
+		}
+		e.quit = true
+		return nil
+	}})
+	r.Register(ExCommand{Name: "xit", MinLen: 1, Bang: true, Complete: completePath, Run: func(e *Editor, args ExArgs) error {
+		if e.cmdWindow != nil {
+			return errCmdWindow
+		}
+		if e.modified {
+			if err := e.Save(args.Args); err != nil {
+				return err
+			}
+		}
+		if !e.lastWindow() {
+			return e.CloseWindow()
+		}
+		if err := e.checkModifiedBuffers(); err != nil && !args.Bang {
+			return err
+		}
+		e.quit = true
+		return nil
+	}})
+	r.Register(ExCommand{Name: "edit", MinLen: 1, Bang: true, Complete: completePath, Run: func(e *Editor, args ExArgs) error {
+		if e.cmdWindow != nil {
+			return errCmdWindow
+		}
+		// Without a name ":e" reloads the current file, other files open in buffers of their own
+		if args.Args == "" {
+			if e.modified && !args.Bang {
+				return errNoWrite
+			}
+			return e.ReloadFile()
+		}
+		return e.OpenFile(args.Args)
+	}})
+	r.Register(ExCommand{Name: "split", MinLen: 2, Complete: completePath, Run: func(e *Editor, args ExArgs) error {
+		return e.SplitWindow(layout.Rows, args.Args)
+	}})
+	r.Register(ExCommand{Name: "vsplit", MinLen: 2, Complete: completePath, Run: func(e *Editor, args ExArgs) error {
+		return e.SplitWindow(layout.Columns, args.Args)
+	}})
+	r.Register(ExCommand{Name: "close", MinLen: 3, Run: func(e *Editor, args ExArgs) error {
+		return e.CloseWindow()
+	}})
+	r.Register(ExCommand{Name: "only", MinLen: 2, Run: func(e *Editor, args ExArgs) error {
+		return e.OnlyWindow()
+	}})
+	r.Register(ExCommand{Name: "tabnew", Complete: completePath, Run: func(e *Editor, args ExArgs) error {
+		return e.NewTab(args.Args)
+	}})
+	r.Register(ExCommand{Name: "tabclose", MinLen: 4, Run: func(e *Editor, args ExArgs) error {
+		return e.CloseTab(args.Args)
+	}})
+	r.Register(ExCommand{Name: "tabmove", MinLen: 4, Run: func(e *Editor, args ExArgs) error {
+		return e.MoveTab(args.Args)
+	}})
+	r.Register(ExCommand{Name: "tabnext", MinLen: 4, Run: func(e *Editor, args ExArgs) error {
+		if args.Args != "" {
+			n, err := countArg(args.Args)
+			if err != nil {
+				return err
+			}
+			e.GoToTab(n)
+			return nil
+		}
+		e.NextTabPage()
+		return nil
+	}})
+	r.Register(ExCommand{Name: "tabprevious", MinLen: 4, Run: func(e *Editor, args ExArgs) error {
+		n, err := countArg(args.Args)
+		if err != nil {
+			return err
+		}
+		e.prevTab(n)
+		return nil
+	}})
+	r.Register(ExCommand{Name: "buffer", MinLen: 1, Complete: completeBuffer, Run: func(e *Editor, args ExArgs) error {
+		if e.cmdWindow != nil {
+			return errCmdWindow
+		}
+		if args.Args == "" {
+			return nil
+		}
+		return e.GoToBuffer(args.Args)
+	}})
+	r.Register(ExCommand{Name: "bnext", MinLen: 2, Run: func(e *Editor, args ExArgs) error {
+		if e.cmdWindow != nil {
+			return errCmdWindow
+		}
+		n, err := countArg(args.Args)
+		if err != nil {
+			return err
+		}
+		e.NextBuffer(n)
+		return nil
+	}})
+	bprev := func(e *Editor, args ExArgs) error {
+		if e.cmdWindow != nil {
+			return errCmdWindow
+		}
+		n, err := countArg(args.Args)
+		if err != nil {
+			return err
+		}
+		e.PrevBuffer(n)
+		return nil
+	}
+	r.Register(ExCommand{Name: "bprevious", MinLen: 2, Run: bprev})
+	r.Register(ExCommand{Name: "bNext", MinLen: 2, Run: bprev})
+	r.Register(ExCommand{Name: "bdelete", MinLen: 2, Bang: true, Complete: completeBuffer, Run: func(e *Editor, args ExArgs) error {
+		if e.cmdWindow != nil {
+			return errCmdWindow
+		}
+		return e.DeleteBuffer(args.Args, args.Bang)
+	}})
+	listBuffers := func(e *Editor, args ExArgs) error {
+		e.listBuffers()
+		return nil
+	}
+	r.Register(ExCommand{Name: "ls", Run: listBuffers})
+	r.Register(ExCommand{Name: "buffers", Run: listBuffers})
+	r.Register(ExCommand{Name: "files", Run: listBuffers})
+	r.Register(ExCommand{Name: "registers", MinLen: 3, Run: func(e *Editor, args ExArgs) error {
+		e.SetOutput(e.ListRegisters(strings.Join(strings.Fields(args.Args), "")))
+		return nil
+	}})
+	r.Register(ExCommand{Name: "display", MinLen: 2, Run: func(e *Editor, args ExArgs) error {
+		e.SetOutput(e.ListRegisters(strings.Join(strings.Fields(args.Args), "")))
+		return nil
+	}})
+	r.Register(ExCommand{Name: "marks", Run: func(e *Editor, args ExArgs) error {
+		e.SetOutput(e.ListMarks())
+		return nil
+	}})
+	r.Register(ExCommand{Name: "delete", MinLen: 1, Range: true, Run: func(e *Editor, args ExArgs) error {
+		_ = e.cursor.SetPosition(e.buffer.LineToChar(args.Line1))
+		e.deleteLines(args.Line2-args.Line1+1, registerArg(args.Args))
+		return nil
+	}})
+	r.Register(ExCommand{Name: "set", MinLen: 2, Complete: completeOption, Run: func(e *Editor, args ExArgs) error {
+		return e.setOptions(args.Args)
+	}})
+	r.Register(ExCommand{Name: "substitute", MinLen: 1, Range: true, Run: func(e *Editor, args ExArgs) error {
+		return e.substitute(args)
+	}})
+	r.Register(ExCommand{Name: "global", MinLen: 1, Range: true, Bang: true, Run: func(e *Editor, args ExArgs) error {
+		return e.global(args, args.Bang)
+	}})
+	r.Register(ExCommand{Name: "vglobal", MinLen: 1, Range: true, Run: func(e *Editor, args ExArgs) error {
+		return e.global(args, true)
+	}})
+	r.Register(ExCommand{Name: "nohlsearch", MinLen: 3, Run: func(e *Editor, args ExArgs) error {
+		e.NoHighlight()
+		return nil
+	}})
+	r.Register(ExCommand{Name: "normal", MinLen: 4, Range: true, Bang: true, Run: func(e *Editor, args ExArgs) error {
+		return e.normal(args)
+	}})
+	r.Register(ExCommand{Name: "yank", MinLen: 1, Range: true, Run: func(e *Editor, args ExArgs) error {
+		start, end, lines := e.lineRange(args.Line1, args.Line2)
+		e.yank(registerArg(args.Args), e.buffer.Substring(start, end), true, lines)
+		return nil
+	}})
+	r.Register(ExCommand{Name: "compiler", MinLen: 4, Complete: completeCompiler, Run: func(e *Editor, args ExArgs) error {
+		if args.Args == "" {
+			return errors.New("E471: Argument required")
+		}
+		return e.SetCompiler(args.Args)
+	}})
+	r.Register(ExCommand{Name: "gotest", MinLen: 3, Complete: completeTestScope, Run: func(e *Editor, args ExArgs) error {
+		return e.GoTest(args.Args)
+	}})
+	r.Register(ExCommand{Name: "cancel", MinLen: 3, Run: func(e *Editor, args ExArgs) error {
+		if !e.StopJobs() {
+			return errors.New("No job running")
+		}
+		return nil
+	}})
+	registerQuickfixCommands(r, false)
+	registerQuickfixCommands(r, true)
+}
+
+// registerQuickfixCommands adds the ":c" commands for the quickfix list, or with loc the ":l" ones for the
+// window's location list. Both families share names past the first letter, ":grep" and ":make" aside.
+func registerQuickfixCommands(r *CommandRegistry, loc bool) {
+	prefix, grepName, makeName := "c", "grep", "make"
+	if loc {
+		prefix, grepName, makeName = "l", "lgrep", "lmake"
+	}
+	// Abbreviations differ a little between the two, ":cl" is ":clist" but ":ll" is its own command
+	short := func(c, l int) int {
+		if loc {
+			return l
+		}
+		return c
+	}
+
+	r.Register(ExCommand{Name: grepName, MinLen: short(2, 3), Bang: true, Run: func(e *Editor, args ExArgs) error {
+		return e.Grep(args.Args, loc, !args.Bang)
+	}})
+	r.Register(ExCommand{Name: makeName, MinLen: short(3, 4), Bang: true, Run: func(e *Editor, args ExArgs) error {
+		return e.Make(args.Args, loc, !args.Bang)
+	}})
+	next := func(e *Editor, args ExArgs) error {
+		n, err := countArg(args.Args)
+		if err != nil {
+			return err
+		}
+		return e.QuickfixNext(loc, n)
+	}
+	prev := func(e *Editor, args ExArgs) error {
+		n, err := countArg(args.Args)
+		if err != nil {
+			return err
+		}
+		return e.QuickfixPrev(loc, n)
+	}
+	goTo := func(last bool) func(e *Editor, args ExArgs) error {
+		return func(e *Editor, args ExArgs) error {
+			if args.Args != "" {
+				n, err := countArg(args.Args)
+				if err != nil {
+					return err
+				}
+				return e.QuickfixGo(loc, n)
+			}
+			if last {
+				return e.QuickfixGo(loc, -1)
+			}
+			return e.QuickfixGo(loc, 1)
+		}
+	}
+	history := func(dir int) func(e *Editor, args ExArgs) error {
+		return func(e *Editor, args ExArgs) error {
+			n, err := countArg(args.Args)
+			if err != nil {
+				return err
+			}
+			return e.QuickfixHistory(loc, dir*n)
+		}
+	}
+
+	r.Register(ExCommand{Name: prefix + "next", MinLen: short(2, 3), Run: next})
+	r.Register(ExCommand{Name: prefix + "previous", MinLen: 2, Run: prev})
+	r.Register(ExCommand{Name: prefix + "Next", MinLen: 2, Run: prev})
+	r.Register(ExCommand{Name: prefix + "first", MinLen: 4, Run: goTo(false)})
+	r.Register(ExCommand{Name: prefix + "last", MinLen: 3, Run: goTo(true)})
+	r.Register(ExCommand{Name: prefix + prefix, MinLen: 2, Run: func(e *Editor, args ExArgs) error {
+		n := 0
+		if args.Args != "" {
+			var err error
+			if n, err = countArg(args.Args); err != nil {
+				return err
+			}
+		}
+		return e.QuickfixGo(loc, n)
+	}})
+	r.Register(ExCommand{Name: prefix + "list", MinLen: short(2, 3), Run: func(e *Editor, args ExArgs) error {
+		lines, err := e.ListQuickfix(loc)
+		if err != nil {
+			return err
+		}
+		e.SetOutput(lines)
+		return nil
+	}})
+	r.Register(ExCommand{Name: prefix + "older", MinLen: 3, Run: history(-1)})
+	r.Register(ExCommand{Name: prefix + "newer", MinLen: 4, Run: history(1)})
+	r.Register(ExCommand{Name: prefix + "file", MinLen: 2, Complete: completePath, Run: func(e *Editor, args ExArgs) error {
+		return e.LoadErrorFile(loc, args.Args, true)
+	}})
+	r.Register(ExCommand{Name: prefix + "open", MinLen: short(4, 3), Run: func(e *Editor, args ExArgs) error {
+		height := listWindowHeight
+		if args.Args != "" {
+			var err error
+			if height, err = countArg(args.Args); err != nil {
+				return err
+			}
+		}
+		return e.OpenListWindow(loc, height)
+	}})
+	r.Register(ExCommand{Name: prefix + "close", MinLen: 3, Run: func(e *Editor, args ExArgs) error {
+		return e.CloseListWindow(loc)
+	}})
+}
+
+// registerArg picks the register out of ":d x" style arguments.
+func registerArg(args string) rune {
+	args = strings.TrimSpace(args)
+	if args == "" {
+		return 0
+	}
+	name := []rune(args)[0]
+	if !register.IsValid(name) {
+		return 0
+	}
+	return name
+}
+
+// countArg reads the optional count some commands take, ":bn 3". No argument means 1.
+func countArg(arg string) (int, error) {
+	if arg == "" {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("E488: Trailing characters: %s", arg)
+	}
+	return n, nil
+}
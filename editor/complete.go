@@ -0,0 +1,158 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/ex"
+	"github.com/ogzhanolguncu/go_editor/fuzzy"
+)
+
+// ### COMPLETION
+
+// completion is the list Tab cycles through. index -1 is the word as it was typed.
+type completion struct {
+	items    []string
+	index    int
+	start    int    // Where the completed word starts in the command line
+	original []rune // The word as typed
+}
+
+// CmdComplete completes the word before the cursor, the command name or its argument. Repeated Tabs
+// (forward) and Shift-Tabs cycle through the candidates and back to what was typed.
+func (e *Editor) CmdComplete(forward bool) {
+	c := &e.cmdline
+	if c.prompt != ':' {
+		return
+	}
+	if c.completion == nil {
+		comp := e.startCompletion()
+		if comp == nil {
+			return
+		}
+		c.completion = comp
+	}
+
+	comp := c.completion
+	slots := len(comp.items) + 1 // The extra slot is the typed word
+	if forward {
+		comp.index = (comp.index+2)%slots - 1
+	} else {
+		comp.index = (comp.index+slots)%slots - 1
+	}
+
+	word := comp.original
+	if comp.index >= 0 {
+		word = []rune(comp.items[comp.index])
+	}
+	c.text = append(append(append([]rune{}, c.text[:comp.start]...), word...), c.text[c.cursor:]...)
+	c.cursor = comp.start + len(word)
+
+	// A single candidate is just filled in, the next Tab completes from there (e.g. into a directory)
+	if len(comp.items) == 1 {
+		c.completion = nil
+	}
+}
+
+// startCompletion works out what is being completed and collects the candidates.
+func (e *Editor) startCompletion() *completion {
+	c := &e.cmdline
+	before := string(c.text[:c.cursor])
+
+	parsed, err := ex.Parse(before)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []string
+	word := before[strings.LastIndexAny(before, " \t")+1:]
+	switch {
+	case parsed.Name != "" && parsed.Args == "" && !parsed.Bang && strings.HasSuffix(before, parsed.Name):
+		// Still typing the command name
+		word = parsed.Name
+		candidates = e.commands.Names()
+	default:
+		cmd, ok := e.commands.Lookup(parsed.Name)
+		if !ok || cmd.Complete == nil {
+			return nil
+		}
+		candidates = cmd.Complete(e, word)
+	}
+
+	matches := fuzzy.Filter(word, candidates)
+	if len(matches) == 0 {
+		return nil
+	}
+	items := make([]string, len(matches))
+	for i, m := range matches {
+		items[i] = m.Str
+	}
+	return &completion{
+		items:    items,
+		index:    -1,
+		start:    c.cursor - len([]rune(word)),
+		original: []rune(word),
+	}
+}
+
+// GetCompletion returns the candidates being cycled and the selected one, -1 while on the typed word.
+func (e *Editor) GetCompletion() ([]string, int, bool) {
+	comp := e.cmdline.completion
+	if comp == nil || e.GetMode() != ModeCommand {
+		return nil, 0, false
+	}
+	return comp.items, comp.index, true
+}
+
+// completePath lists the directory the argument points into. Directories end in "/" so Tab can go on into them.
+func completePath(e *Editor, arg string) []string {
+	dir, _ := filepath.Split(arg)
+	listDir := dir
+	if strings.HasPrefix(listDir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			listDir = filepath.Join(home, listDir[2:])
+		}
+	}
+	if listDir == "" {
+		listDir = "."
+	}
+
+	entries, err := os.ReadDir(listDir)
+	if err != nil {
+		return nil
+	}
+	base := filepath.Base(arg + "x") // "x" keeps a trailing "/" from making the base the directory
+	showHidden := strings.HasPrefix(base, ".")
+
+	var candidates []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !showHidden {
+			continue
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+		candidates = append(candidates, dir+name)
+	}
+	return candidates
+}
+
+// completeOption offers option names, keeping a "no" or "inv" prefix that was typed.
+func completeOption(e *Editor, arg string) []string {
+	prefix := ""
+	for _, p := range []string{"no", "inv"} {
+		if strings.HasPrefix(arg, p) {
+			prefix = p
+		}
+	}
+	var candidates []string
+	for _, opt := range optionTable {
+		candidates = append(candidates, prefix+opt.name)
+	}
+	if prefix == "" {
+		candidates = append(candidates, "all")
+	}
+	return candidates
+}
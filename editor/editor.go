@@ -2,9 +2,14 @@ package editor
 
 import (
 	"fmt"
-
-	cursor "github.com/ogzhanolguncu/go_editor/cursor_manager"
-	textbuffer "github.com/ogzhanolguncu/go_editor/text_buffer"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/ex"
+	"github.com/ogzhanolguncu/go_editor/gotest"
+	"github.com/ogzhanolguncu/go_editor/highlighter"
+	"github.com/ogzhanolguncu/go_editor/history"
+	"github.com/ogzhanolguncu/go_editor/register"
+	"github.com/ogzhanolguncu/go_editor/undo"
 )
 
 // # Essential Vim Commands Only
@@ -54,60 +59,191 @@ import (
 // - [ ] `Ctrl-r` - redo -> If nothing to do update status like with "Already at newest change"
 
 type Editor struct {
-	buffer   *textbuffer.TextBuffer // Text storage and line tacking
-	cursor   *cursor.CursorManager  // Track cursor position
-	filename string                 // Required for tracking file name when file is loaded from a file or saved to a file
-	modified bool                   // Required for tracking file modified flag on status line
-	message  string                 // Required for showing confirmation messages. e.g "Are you sure you want to save" etc...
-
-	vimState *VimState
+	*Window                  // Current window, its cursor and the buffer it shows
+	buffers   *BufferManager // Every open buffer
+	tabs      []*tabPage     // Tab pages in the order the tabline shows them
+	tab       *tabPage       // Current tab page, the windows on screen
+	nextWinID int
+	fileTree  *treePanel // Ctrl-N sidebar, nil while closed
+	jumps     []jump     // Where jumps started, oldest first
+	message   string     // Required for showing confirmation messages. e.g "Are you sure you want to save" etc...
+
+	vimState  *VimState
+	registers *register.Store // Yank/delete/paste storage
+	typed     []rune          // Keys typed during the current insert session, backspaces included
+
+	lastChange    change  // What "." replays
+	pendingChange *change // Change waiting for its insert session to end
+	repeating     bool    // Set while "." replays, so the replay doesn't record itself
+	failed        bool    // Last command failed (motion couldn't move, nothing to put...), aborts a running macro
+
+	commands *CommandRegistry // ":" commands
+	cmdline  CommandLine      // Text typed after ":"
+	output   []string         // Multi line command output waiting to be shown
+	quit     bool             // Set by ":q" and friends
+
+	lastSearch     string             // Last search pattern, also used by ":s//"
+	search         searchState        // "/", "?", "n", "*" and match highlighting
+	options        Options            // Settings changed with ":set"
+	cmdHistory     *history.Store     // What was typed at ":" and "/" prompts
+	cmdWindow      *cmdWindow         // Open "q:" window
+	lastSubstitute *ex.Substitute     // For ":s" without a pattern
+	substitution   *substituteSession // ":s///c" waiting for confirmation
+	anchors        *lineAnchors       // Lines marked by a running ":g"
+	keyExecutor    func(keys string)  // Feeds keys through the screen's key dispatch for ":normal"
+	keyReplay      int                // Depth of macros and ":normal" running, see BeginKeyReplay
+	redraw         func()             // Asks the screen for a redraw and a Poll, safe from any goroutine
+	quickfix       *qfStack           // Quickfix lists from ":grep" and ":cfile"
+	grep           *grepJob           // Running ":grep"
+	makeJob        *makeJob           // Running ":make"
+	testJob        *testJob           // Running ":gotest"
+	lastTest       *testSpec          // What ":gotest last" runs
+
+	testResults map[string]map[string]gotest.Status // Package directory to test name to its last result
+	postSyntax  func(highlighter.Update) bool       // Hands background highlighting to the event loop, safe from any goroutine
 }
 
 func New() (*Editor, error) {
-	buffer, err := textbuffer.NewTextBuffer(256)
+	buffer, err := newBuffer("")
 	if err != nil {
-		return nil, fmt.Errorf("editor: failed to create text buffer: %w", err)
+		return nil, err
 	}
+	buffers := NewBufferManager()
+	buffers.Add(buffer)
+
+	window := newWindow(1, buffer)
+	tab := newTabPage(window)
+
+	commands := NewCommandRegistry()
+	registerBuiltinCommands(commands)
 
 	return &Editor{
-		buffer:   buffer,
-		cursor:   cursor.NewCursorManager(buffer),
-		filename: "",
-		modified: false,
-		message:  "",
-		vimState: NewVimState(),
+		Window:     window,
+		buffers:    buffers,
+		tabs:       []*tabPage{tab},
+		tab:        tab,
+		nextWinID:  2,
+		message:    "",
+		vimState:   NewVimState(),
+		registers:  register.NewStore(),
+		commands:   commands,
+		options:    defaultOptions(),
+		cmdHistory: history.NewStore(history.DefaultLimit),
+		quickfix:   &qfStack{},
 	}, nil
 }
 
 func (e *Editor) InsertChar(ch rune) {
 	pos := e.cursor.GetPosition()
-	e.buffer.Insert(pos, ch)
-	e.cursor.ApplyTextChange(pos, +1)
-	e.modified = true
+	e.insertText(pos, string(ch))
+	e.typed = append(e.typed, ch)
 }
 
 func (e *Editor) InsertString(text string) {
 	pos := e.cursor.GetPosition()
-	e.buffer.InsertString(pos, text)
-	e.cursor.ApplyTextChange(pos, len([]rune(text)))
-	e.modified = true
+	e.insertText(pos, text)
+	e.typed = append(e.typed, []rune(text)...)
 }
 
 func (e *Editor) Backspace() {
 	pos := e.cursor.GetPosition()
-	e.buffer.Delete(pos - 1)
-	e.cursor.ApplyTextChange(pos-1, -1)
+	if pos == 0 {
+		return
+	}
+	e.deleteText(pos-1, pos)
+	e.typed = append(e.typed, backspaceKey)
+}
+
+// PasteText inserts text that arrived through bracketed paste. It skips InsertChar on purpose so pasted
+// text is never auto-indented or auto-paired, and it's a single undo step in both normal and insert mode.
+func (e *Editor) PasteText(text string) {
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+	if text == "" {
+		return
+	}
+
+	e.beginChange()
+	defer e.endChange()
+
+	e.insertText(e.cursor.GetPosition(), text)
+	if e.GetMode() == ModeInsert {
+		e.typed = append(e.typed, []rune(text)...)
+		return
+	}
+	// Normal mode leaves the cursor on the last pasted char
+	e.cursor.MoveLeft()
+}
+
+// insertText is the single place inserts go through, so undo history, cursor and modified flag stay in sync.
+func (e *Editor) insertText(pos int, text string) {
+	if text == "" {
+		return
+	}
+	if e.nomodifiable {
+		e.SetMessage(errNotModifiable.Error())
+		e.fail()
+		return
+	}
+	e.history.Record(undo.Edit{Pos: pos, Inserted: text}, e.cursor.GetPosition())
+	e.applyInsert(pos, text)
+}
+
+// deleteText removes [start, end) and returns what was removed.
+func (e *Editor) deleteText(start, end int) string {
+	start = max(0, start)
+	end = min(e.buffer.Length(), end)
+	if start >= end {
+		return ""
+	}
+	if e.nomodifiable {
+		e.SetMessage(errNotModifiable.Error())
+		e.fail()
+		return ""
+	}
+	removed := e.buffer.Substring(start, end)
+	e.history.Record(undo.Edit{Pos: start, Deleted: removed}, e.cursor.GetPosition())
+	e.applyDelete(start, end)
+	return removed
+}
+
+func (e *Editor) applyInsert(pos int, text string) {
+	if text == "" {
+		return
+	}
+	line, added := e.buffer.CharToLine(pos), strings.Count(text, "\n")
+	e.matchIndex.Edit(line, 0, added)
+	e.editSyntax(line, 0, added)
+	e.buffer.InsertString(pos, text)
+	e.applyToCursors(pos, len([]rune(text)))
+	e.adjustMarks(pos, len([]rune(text)))
+	if e.anchors != nil {
+		e.anchors.afterInsert(pos, len([]rune(text)))
+	}
+	e.revision++
+	e.modified = true
+}
+
+func (e *Editor) applyDelete(start, end int) {
+	if start >= end {
+		return
+	}
+	if e.anchors != nil {
+		e.anchors.beforeDelete(start, end, e.lineEndAt)
+	}
+	line, removed := e.buffer.CharToLine(start), strings.Count(e.buffer.Substring(start, end), "\n")
+	e.matchIndex.Edit(line, removed, 0)
+	e.editSyntax(line, removed, 0)
+	e.buffer.DeleteRange(start, end)
+	e.applyToCursors(start, -(end - start))
+	e.adjustMarks(start, -(end - start))
+	e.revision++
 	e.modified = true
 }
 
 func (e *Editor) Delete() {
 	n := e.GetCountAndClear()
 	pos := e.cursor.GetPosition()
-	for range n {
-		e.buffer.Delete(pos)
-		e.cursor.ApplyTextChange(pos, -1)
-	}
-	e.modified = true
+	e.deleteText(pos, pos+n)
 }
 
 // ### EDITOR STATES AND MESSAGES
@@ -144,6 +280,10 @@ func (e *Editor) GetVisibleContent(startLine, numLines int) []string {
 	return lines
 }
 
+func trimNewline(line string) string {
+	return strings.TrimSuffix(line, "\n")
+}
+
 func (e *Editor) SetMessage(msg string) {
 	e.message = msg
 }
@@ -156,7 +296,8 @@ func (e *Editor) GetStatusLine() string {
 
 	line, col := e.GetLineColumn()
 
-	return fmt.Sprintf("%s%s | Line %d, Col %d | %d lines | %d chars",
+	return fmt.Sprintf("[%d] %s%s | Line %d, Col %d | %d lines | %d chars",
+		e.id,
 		e.GetFilename(),
 		modFlag,
 		line+1, // Display as 1-indexed
@@ -165,6 +306,18 @@ func (e *Editor) GetStatusLine() string {
 		e.GetLength())
 }
 
+// TakeFailure reports whether a command failed since the last call, and clears the flag.
+func (e *Editor) TakeFailure() bool {
+	failed := e.failed
+	e.failed = false
+	return failed
+}
+
+func (e *Editor) fail() bool {
+	e.failed = true
+	return false
+}
+
 // ### VIM STATES
 
 func (e *Editor) GetMode() Mode {
@@ -172,13 +325,30 @@ func (e *Editor) GetMode() Mode {
 }
 
 func (e *Editor) SetMode(mode Mode) {
+	prev := e.vimState.mode
 	e.vimState.mode = mode
+
+	switch {
+	case prev != ModeInsert && mode == ModeInsert:
+		e.typed = e.typed[:0]
+		// Whole insert session is one undo step
+		e.beginChange()
+	case prev == ModeInsert && mode != ModeInsert:
+		e.registers.SetReadOnly(register.LastInsert, insertedText(e.typed))
+		e.finishInsert()
+		e.endChange()
+	}
 }
 
 func (e *Editor) HandleDigit(r rune) bool {
 	return e.vimState.HandleDigit(r)
 }
 
+// HandleMotionDigit takes a digit typed after an operator, the count of its motion ("d3w").
+func (e *Editor) HandleMotionDigit(r rune) bool {
+	return e.vimState.HandleMotionDigit(r)
+}
+
 func (e *Editor) GetCountAndClear() int {
 	return e.vimState.GetCountAndClear()
 }
@@ -187,6 +357,21 @@ func (e *Editor) ClearCount() {
 	e.vimState.ClearCount()
 }
 
+func (e *Editor) GetPending() string {
+	return e.vimState.pending
+}
+
+func (e *Editor) SetPending(keys string) {
+	e.vimState.pending = keys
+}
+
+// CancelPending drops a half typed command along with its count and register.
+func (e *Editor) CancelPending() {
+	e.vimState.pending = ""
+	e.vimState.register = 0
+	e.ClearCount()
+}
+
 // ### PASSTHROUGH FUNCS
 
 func (e *Editor) MoveLeft() bool {
@@ -253,6 +438,18 @@ func (e *Editor) GetLineColumn() (int, int) {
 	return e.cursor.GetLineColumn()
 }
 
+// lineEndAt returns the position of the newline ending pos's line, or the buffer length on the last line.
+func (e *Editor) lineEndAt(pos int) int {
+	line := e.buffer.CharToLine(pos)
+	return e.buffer.LineToChar(line) + e.buffer.LineLength(line)
+}
+
+// LineColumnAt converts a char position into a 0-based line and column.
+func (e *Editor) LineColumnAt(pos int) (int, int) {
+	line := e.buffer.CharToLine(pos)
+	return line, pos - e.buffer.LineToChar(line)
+}
+
 func (e *Editor) GetLine(lineNum int) string {
 	return e.buffer.Line(lineNum)
 }
@@ -0,0 +1,30 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestEditor returns an editor over text with the cursor at its start.
+func newTestEditor(t *testing.T, text string) *Editor {
+	t.Helper()
+	e, err := New()
+	require.NoError(t, err)
+	b, err := newBuffer(text)
+	require.NoError(t, err)
+	e.buffers.Replace(e.Buffer, b)
+	e.showBuffer(b)
+	return e
+}
+
+func TestPasteText(t *testing.T) {
+	e := newTestEditor(t, "x")
+	e.PasteText("if a {\r\n\treturn\r\n}")
+	require.Equal(t, "if a {\n\treturn\n}x", e.buffer.String())
+	require.Equal(t, len("if a {\n\treturn\n}")-1, e.cursor.GetPosition())
+
+	// One undo step takes the whole paste back
+	e.Undo()
+	require.Equal(t, "x", e.buffer.String())
+}
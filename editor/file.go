@@ -0,0 +1,118 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/history"
+	"github.com/ogzhanolguncu/go_editor/register"
+)
+
+// ### FILES
+
+// OpenFile edits path in a buffer of its own. A buffer already holding the file is switched to, and an
+// untouched "[No Name]" buffer is reused. A missing file opens as an empty new file with that name.
+func (e *Editor) OpenFile(path string) error {
+	if b, ok := e.buffers.FindByPath(path); ok {
+		e.switchTo(b)
+		e.cmdHistory.Add(history.File, absPath(path))
+		e.SetMessage(e.fileInfo())
+		return nil
+	}
+
+	b, message, err := loadBuffer(path)
+	if err != nil {
+		return err
+	}
+	if e.isEmptyScratch() && len(e.windowsShowing(e.Buffer)) == 1 {
+		e.buffers.Replace(e.Buffer, b)
+		e.showBuffer(b)
+		e.registers.SetReadOnly(register.FileName, path)
+	} else {
+		e.buffers.Add(b)
+		e.switchTo(b)
+	}
+	e.cmdHistory.Add(history.File, absPath(path))
+	e.SetMessage(message)
+	return nil
+}
+
+// ReloadFile reads the current file again, dropping unsaved changes (":e!").
+func (e *Editor) ReloadFile() error {
+	if e.filename == "" {
+		return errors.New("E32: No file name")
+	}
+	b, message, err := loadBuffer(e.filename)
+	if err != nil {
+		return err
+	}
+	b.id = e.id
+	b.lastCursor = e.cursor.GetPosition()
+	line, _ := e.GetLineColumn()
+	e.buffers.Replace(e.Buffer, b)
+	e.replaceInWindows(e.Buffer, b)
+	e.moveToFirstNonBlank(min(line, e.buffer.LineCount()-1))
+	e.SetMessage(message)
+	return nil
+}
+
+// loadBuffer reads a file into a new buffer, along with the message Vim shows after loading it.
+func loadBuffer(path string) (*Buffer, string, error) {
+	content, err := os.ReadFile(path)
+	isNew := errors.Is(err, fs.ErrNotExist)
+	if err != nil && !isNew {
+		return nil, "", fmt.Errorf("E484: Can't open file %s: %w", path, err)
+	}
+
+	b, err := newBuffer(string(content))
+	if err != nil {
+		return nil, "", err
+	}
+	b.filename = path
+
+	if isNew {
+		return b, fmt.Sprintf("%q [New]", path), nil
+	}
+	return b, fmt.Sprintf("%q %dL, %dB", path, countLines(string(content)), len(content)), nil
+}
+
+// Save writes the buffer to path, or to the current file name when path is empty.
+func (e *Editor) Save(path string) error {
+	if path == "" {
+		path = e.filename
+	}
+	if path == "" {
+		return errors.New("E32: No file name")
+	}
+	if e.nomodifiable {
+		return errors.New("E382: Cannot write, 'buftype' option is set")
+	}
+
+	content := e.buffer.String()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("E212: Can't open file for writing: %w", err)
+	}
+
+	if e.filename == "" {
+		e.filename = path
+		e.registers.SetReadOnly(register.FileName, path)
+		e.cmdHistory.Add(history.File, absPath(path))
+	}
+	if path == e.filename {
+		e.modified = false
+	}
+	e.SetMessage(fmt.Sprintf("%q %dL, %dB written", path, countLines(content), len(content)))
+	return nil
+}
+
+// countLines counts lines the way Vim reports them, a trailing newline doesn't start another line.
+func countLines(content string) int {
+	n := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,178 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/ex"
+	"github.com/ogzhanolguncu/go_editor/search"
+)
+
+// ### GLOBAL
+
+// lineAnchors pins the lines ":g" marked. Positions move with edits like marks do, and an anchor whose
+// whole line gets deleted dies, so commands run on each original line exactly once no matter what earlier ones did.
+type lineAnchors struct {
+	pos  []int
+	dead []bool
+}
+
+func (a *lineAnchors) add(pos int) {
+	a.pos = append(a.pos, pos)
+	a.dead = append(a.dead, false)
+}
+
+func (a *lineAnchors) afterInsert(pos, n int) {
+	for i, p := range a.pos {
+		if p > pos || (p == pos && pos > 0) {
+			a.pos[i] = p + n
+		}
+	}
+}
+
+// beforeDelete runs before start..end is removed, while the text can still tell where each anchored line ends.
+func (a *lineAnchors) beforeDelete(start, end int, lineEnd func(pos int) int) {
+	for i, p := range a.pos {
+		switch {
+		case p >= start && p < end && lineEnd(p) < end:
+			a.dead[i] = true
+		case p > start && p < end:
+			a.pos[i] = start
+		case p >= end:
+			a.pos[i] = p - (end - start)
+		}
+	}
+}
+
+// global runs ":g/pat/cmd" (or ":v" / ":g!" on the lines that don't match) as a single undo step.
+func (e *Editor) global(args ExArgs, invert bool) error {
+	if e.anchors != nil {
+		return errors.New("E147: Cannot do :global recursive")
+	}
+
+	pattern, command, err := splitGlobalArgs(args.Args)
+	if err != nil {
+		return err
+	}
+	if pattern == "" {
+		pattern = e.lastSearch
+	}
+	if pattern == "" {
+		return errors.New("E35: No previous regular expression")
+	}
+	re, err := search.Compile(pattern, e.ignoreCase(pattern))
+	if err != nil {
+		return err
+	}
+	e.lastSearch = pattern
+
+	first, last := args.Line1, args.Line2
+	if !args.HasRange {
+		first, last = 0, e.buffer.LineCount()-1
+	}
+
+	// First pass marks the lines, second pass runs the command on each of them
+	anchors := &lineAnchors{}
+	for line := first; line <= last; line++ {
+		if re.MatchString(trimNewline(e.buffer.Line(line))) != invert {
+			anchors.add(e.buffer.LineToChar(line))
+		}
+	}
+	if len(anchors.pos) == 0 {
+		if invert {
+			return fmt.Errorf("Pattern found in every line: %s", pattern)
+		}
+		return fmt.Errorf("Pattern not found: %s", pattern)
+	}
+
+	// Without a command matching lines are listed
+	if strings.TrimSpace(command) == "" {
+		lines := make([]string, 0, len(anchors.pos))
+		for _, pos := range anchors.pos {
+			line := e.buffer.CharToLine(pos)
+			lines = append(lines, fmt.Sprintf("%4d %s", line+1, trimNewline(e.buffer.Line(line))))
+		}
+		e.SetOutput(lines)
+		return nil
+	}
+
+	e.anchors = anchors
+	e.beginChange()
+	defer func() {
+		e.endChange()
+		e.anchors = nil
+	}()
+
+	for i := range anchors.pos {
+		if anchors.dead[i] {
+			continue
+		}
+		_ = e.cursor.SetPosition(min(anchors.pos[i], e.buffer.Length()))
+		if err := e.ExecuteCommand(command); err != nil {
+			return err
+		}
+		if e.quit {
+			break
+		}
+	}
+	return nil
+}
+
+// splitGlobalArgs splits "/pat/cmd". The delimiter is the first char, a missing closing one ends the pattern.
+func splitGlobalArgs(args string) (string, string, error) {
+	runes := []rune(args)
+	if len(runes) == 0 {
+		return "", "", errors.New("E35: No previous regular expression")
+	}
+	delim := runes[0]
+	if err := ex.CheckDelimiter(delim); err != nil {
+		return "", "", err
+	}
+
+	var pattern []rune
+	for i := 1; i < len(runes); i++ {
+		ch := runes[i]
+		if ch == '\\' && i+1 < len(runes) {
+			if runes[i+1] == delim {
+				pattern = append(pattern, delim)
+			} else {
+				pattern = append(pattern, ch, runes[i+1])
+			}
+			i++
+			continue
+		}
+		if ch == delim {
+			return string(pattern), string(runes[i+1:]), nil
+		}
+		pattern = append(pattern, ch)
+	}
+	return string(pattern), "", nil
+}
+
+// normal runs keys as if typed in normal mode, once per line of the range (":normal", ":g/x/normal A;").
+func (e *Editor) normal(args ExArgs) error {
+	if e.keyExecutor == nil {
+		return errors.New("E492: Not an editor command: normal")
+	}
+	if args.Args == "" {
+		return nil
+	}
+	if !args.HasRange {
+		e.keyExecutor(args.Args)
+		return nil
+	}
+
+	e.beginChange()
+	defer e.endChange()
+	for line := args.Line1; line <= args.Line2 && line < e.buffer.LineCount(); line++ {
+		_ = e.cursor.SetPosition(e.buffer.LineToChar(line))
+		e.keyExecutor(args.Args)
+	}
+	return nil
+}
+
+// SetKeyExecutor connects ":normal" to the key dispatch, which lives in the screen.
+func (e *Editor) SetKeyExecutor(run func(keys string)) {
+	e.keyExecutor = run
+}
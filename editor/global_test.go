@@ -0,0 +1,55 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobal(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		command string
+		want    string
+		cursor  int
+	}{
+		{name: "delete matching", text: "a1\nb\na2\na3\nc", command: "g/a/d", want: "b\nc", cursor: 2},
+		{name: "invert", text: "a1\nb\na2\nc", command: "v/a/d", want: "a1\na2", cursor: 3},
+		{name: "bang inverts", text: "a1\nb\na2\nc", command: "g!/a/d", want: "a1\na2", cursor: 3},
+		{name: "range", text: "a\na\na\na", command: "2,3g/a/s/a/b/", want: "a\nb\nb\na", cursor: 4},
+		// Deleting a marked line with the one before kills its anchor, it isn't run on again
+		{name: "anchor dies with next line", text: "a\na\nb\nc\na\nx", command: "g/a/.,+1d", want: "b\nc", cursor: 2},
+		{name: "anchor moves up", text: "x\na\na\nb", command: "g/a/-1d", want: "a\nb", cursor: 0},
+		{name: "lines added below", text: "a\nb\na", command: `g/a/s/$/\rz/`, want: "a\nz\nb\na\nz", cursor: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t, tt.text)
+			require.NoError(t, e.ExecuteCommand(tt.command))
+			require.Equal(t, tt.want, e.buffer.String())
+			require.Equal(t, tt.cursor, e.cursor.GetPosition())
+
+			// The whole run is one undo step
+			e.Undo()
+			require.Equal(t, tt.text, e.buffer.String())
+		})
+	}
+}
+
+func TestGlobalErrors(t *testing.T) {
+	e := newTestEditor(t, "a\nb")
+	require.EqualError(t, e.ExecuteCommand("g/x/d"), "Pattern not found: x")
+	require.EqualError(t, e.ExecuteCommand("v/./d"), "Pattern found in every line: .")
+	require.EqualError(t, e.ExecuteCommand("g/a/g/b/d"), "E147: Cannot do :global recursive")
+	require.EqualError(t, e.ExecuteCommand("g"), "E35: No previous regular expression")
+
+	// Without a command the matching lines are listed
+	require.NoError(t, e.ExecuteCommand("g/b"))
+	require.Equal(t, "a\nb", e.buffer.String())
+
+	// An empty pattern is the last one used
+	require.NoError(t, e.ExecuteCommand("g//d"))
+	require.Equal(t, "a", e.buffer.String())
+}
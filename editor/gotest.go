@@ -0,0 +1,298 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ogzhanolguncu/go_editor/errorformat"
+	"github.com/ogzhanolguncu/go_editor/gotest"
+	"github.com/ogzhanolguncu/go_editor/runner"
+)
+
+// ### GO TESTS
+
+const testResultsName = "[Test Results]"
+
+// testSpec is what a ":gotest" runs, and what ":gotest last" runs again.
+type testSpec struct {
+	dir  string // Package directory
+	test string // Test function, empty for the whole package
+}
+
+// testJob is a running ":gotest". Its "go test -json" output is read into a report as it comes.
+type testJob struct {
+	run     *runner.Run
+	spec    testSpec
+	report  *gotest.Report
+	taken   int // Output bytes already in the report
+	started time.Time
+}
+
+// String is the command line the spec runs, for messages.
+func (s testSpec) String() string {
+	dir := relativePath(s.dir)
+	if dir != s.dir {
+		dir = "./" + filepath.ToSlash(dir)
+	}
+	if s.test == "" {
+		return "go test " + dir
+	}
+	return fmt.Sprintf("go test -run '^%s$' %s", s.test, dir)
+}
+
+// GoTest runs Go tests in the background (":gotest [func|package|last]"): the test function under the cursor,
+// every test of the file's package, or whatever ran last. With no scope it's the function under the cursor when
+// there is one, the package otherwise. Results show next to the test functions, failures go to a results window
+// and the quickfix list.
+func (e *Editor) GoTest(scope string) error {
+	var spec testSpec
+	switch scope {
+	case "last":
+		if e.lastTest == nil {
+			return errors.New("gotest: no tests were run yet")
+		}
+		spec = *e.lastTest
+	case "", "func", "package":
+		if e.filename == "" || filepath.Ext(e.filename) != ".go" {
+			return errors.New("gotest: not a Go file")
+		}
+		spec.dir = filepath.Dir(absPath(e.filename))
+		if scope == "package" {
+			break
+		}
+		line, _ := e.GetLineColumn()
+		lines := make([]string, line+1)
+		for i := range lines {
+			lines[i] = trimNewline(e.buffer.Line(i))
+		}
+		name, ok := gotest.FuncAt(lines, line)
+		if !ok && scope == "func" {
+			return errors.New("gotest: no test function under the cursor")
+		}
+		spec.test = name
+	default:
+		return fmt.Errorf("E475: Invalid argument: %s", scope)
+	}
+	return e.startTest(spec)
+}
+
+func (e *Editor) startTest(spec testSpec) error {
+	args := []string{"test", "-json"}
+	if spec.test != "" {
+		args = append(args, "-run", "^"+regexp.QuoteMeta(spec.test)+"$")
+	}
+	run, err := runner.Start(spec.dir, "go", append(args, "."), e.redraw)
+	if err != nil {
+		return fmt.Errorf("gotest: %w", err)
+	}
+	e.StopTest()
+
+	// Results the run is about to replace would only mislead while it goes
+	if e.testResults == nil {
+		e.testResults = make(map[string]map[string]gotest.Status)
+	}
+	results := e.testResults[spec.dir]
+	if results == nil || spec.test == "" {
+		results = make(map[string]gotest.Status)
+		e.testResults[spec.dir] = results
+	}
+	delete(results, spec.test)
+
+	e.testJob = &testJob{run: run, spec: spec, report: gotest.NewReport(), started: time.Now()}
+	e.lastTest = &spec
+	e.SetMessage(fmt.Sprintf("gotest: running %s", spec))
+	return nil
+}
+
+// StopTest kills a running ":gotest", the results it had so far stay. Returns false when none was running.
+func (e *Editor) StopTest() bool {
+	job := e.testJob
+	if job == nil {
+		return false
+	}
+	job.run.Stop()
+	e.testJob = nil
+	e.takeTestResults(job, true)
+	e.SetMessage(fmt.Sprintf("gotest: stopped %s", job.spec))
+	return true
+}
+
+// TestSigns returns the results of the test functions declared on lines first to first+count-1 of the current
+// buffer, by line, for the gutter.
+func (e *Editor) TestSigns(first, count int) map[int]gotest.Status {
+	if !strings.HasSuffix(e.filename, "_test.go") {
+		return nil
+	}
+	results := e.testResults[filepath.Dir(absPath(e.filename))]
+	if len(results) == 0 {
+		return nil
+	}
+	signs := make(map[int]gotest.Status)
+	for i := first; i < min(first+count, e.buffer.LineCount()); i++ {
+		if name, ok := gotest.FuncName(e.buffer.Line(i)); ok {
+			if status, ok := results[name]; ok {
+				signs[i] = status
+			}
+		}
+	}
+	return signs
+}
+
+func (e *Editor) pollTest() {
+	job := e.testJob
+	if job == nil {
+		return
+	}
+	output, done := job.run.Output()
+	_, _ = job.report.Write(output[job.taken:])
+	job.taken = len(output)
+	if !done {
+		e.takeTestResults(job, false)
+		return
+	}
+	e.testJob = nil
+	job.report.Close()
+	e.takeTestResults(job, true)
+	e.finishTest(job)
+}
+
+// takeTestResults copies the statuses of the job's top level tests to the gutter's results. Once the run is over
+// tests it never finished, cut short by a panic or a stop, lose their running sign.
+func (e *Editor) takeTestResults(job *testJob, over bool) {
+	results := e.testResults[job.spec.dir]
+	for _, t := range job.report.Tests {
+		if strings.Contains(t.Name, "/") {
+			continue
+		}
+		if over && t.Status == gotest.Running {
+			delete(results, t.Name)
+			continue
+		}
+		results[t.Name] = t.Status
+	}
+}
+
+func (e *Editor) finishTest(job *testJob) {
+	if err := job.run.Err(); err != nil {
+		e.SetMessage(fmt.Sprintf("gotest: %s: %v", job.spec, err))
+		return
+	}
+	report := job.report
+	failed := report.FailedTests()
+
+	// What to show: the failed tests' output, or the package's when it failed without a test failing,
+	// which is a build error or a panic outside the tests
+	var lines []string
+	for _, t := range failed {
+		lines = append(lines, t.Output...)
+	}
+	if len(failed) == 0 && report.Failed {
+		lines = report.Output
+	}
+	if entries := testLocations(job.spec.dir, lines); len(entries) > 0 {
+		_ = e.setQuickfix(false, ":gotest "+job.spec.String(), entries, false)
+	}
+	if len(lines) > 0 {
+		e.showTestResults(lines)
+	} else {
+		e.closeTestResults()
+	}
+
+	ran := countTopLevel(report.Tests)
+	elapsed := time.Since(job.started).Round(100 * time.Millisecond)
+	switch {
+	case len(failed) > 0:
+		e.SetMessage(fmt.Sprintf("gotest: FAIL, %d of %s failed (%s)", countTopLevel(failed), plural(ran, "test"), elapsed))
+	case report.Failed:
+		e.SetMessage(fmt.Sprintf("gotest: FAIL, %s didn't build or run", job.spec))
+	case ran == 0:
+		e.SetMessage("gotest: no tests to run")
+	default:
+		e.SetMessage(fmt.Sprintf("gotest: ok, %s passed (%s)", plural(ran, "test"), elapsed))
+	}
+}
+
+// countTopLevel counts tests leaving subtests out.
+func countTopLevel(tests []*gotest.Test) int {
+	n := 0
+	for _, t := range tests {
+		if !strings.Contains(t.Name, "/") {
+			n++
+		}
+	}
+	return n
+}
+
+// testLocations picks the "file:line: message" lines out of test output. go test prints paths relative to the
+// package directory.
+func testLocations(dir string, lines []string) []QuickfixEntry {
+	formats, err := errorformat.CompileList(errorformat.Go)
+	if err != nil {
+		return nil
+	}
+	var entries []QuickfixEntry
+	for _, entry := range quickfixEntries(errorformat.Parse(strings.Join(lines, "\n"), formats)) {
+		if !entry.valid() {
+			continue
+		}
+		if !filepath.IsAbs(entry.File) {
+			entry.File = relativePath(filepath.Join(dir, entry.File))
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// showTestResults puts lines in the current tab's results window, opening one across the bottom if there's
+// none. The cursor stays where it is.
+func (e *Editor) showTestResults(lines []string) {
+	b, err := newBuffer(strings.Join(lines, "\n"))
+	if err != nil {
+		return
+	}
+	b.filename = testResultsName
+	b.nomodifiable = true
+
+	if w := e.testResultsWindow(); w != nil {
+		w.show(b)
+		return
+	}
+	w := newWindow(e.nextWinID, b)
+	if err := e.tab.layout.SplitBottom(w.winID, listWindowHeight); err != nil {
+		return
+	}
+	e.nextWinID++
+	e.tab.windows[w.winID] = w
+}
+
+// closeTestResults closes the current tab's results window once nothing fails anymore.
+func (e *Editor) closeTestResults() {
+	w := e.testResultsWindow()
+	switch {
+	case w == nil:
+	case w == e.Window:
+		_ = e.CloseWindow()
+	default:
+		if _, ok := e.tab.layout.Close(w.winID); ok {
+			delete(e.tab.windows, w.winID)
+		}
+	}
+}
+
+func (e *Editor) testResultsWindow() *Window {
+	for _, id := range e.tab.layout.Windows() {
+		if w := e.tab.windows[id]; w.nomodifiable && w.filename == testResultsName {
+			return w
+		}
+	}
+	return nil
+}
+
+// completeTestScope offers the scopes ":gotest" takes.
+func completeTestScope(e *Editor, arg string) []string {
+	return []string{"func", "last", "package"}
+}
@@ -0,0 +1,162 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/grep"
+)
+
+// ### GREP
+
+// grepJob is a running ":grep" or ":lgrep". Its matches move into its list as they arrive, whichever list of the
+// stack is current.
+type grepJob struct {
+	search  *grep.Search
+	pattern string
+	stack   *qfStack
+	list    *quickfixList
+	taken   int  // Matches already in the list
+	jump    bool // Go to the first match once there is one, ":grep!" doesn't
+}
+
+// Grep searches the files under the working directory (":grep [-F] [-i] {pattern}"). The pattern is a Go regexp,
+// -F makes it plain text and -i ignores case. Matches go to a new quickfix list, or location list for ":lgrep",
+// while the search runs, and the first one is jumped to unless jump is false.
+func (e *Editor) Grep(args string, loc, jump bool) error {
+	opts, pattern := parseGrepArgs(args)
+	if pattern == "" {
+		return errors.New("E471: Argument required")
+	}
+	stack, err := e.qfStackFor(loc, true)
+	if err != nil {
+		return err
+	}
+	search, err := grep.Start(".", pattern, opts, e.redraw)
+	if err != nil {
+		return fmt.Errorf("E486: Invalid pattern: %w", err)
+	}
+
+	e.StopGrep()
+	title := ":grep "
+	if loc {
+		title = ":lgrep "
+	}
+	list := &quickfixList{title: title + strings.TrimSpace(args), index: -1}
+	stack.push(list)
+	e.updateListWindows()
+	e.grep = &grepJob{search: search, pattern: pattern, stack: stack, list: list, jump: jump}
+	e.SetMessage(fmt.Sprintf("grep: searching for %s...", pattern))
+	return nil
+}
+
+// StopGrep ends a running ":grep", keeping what it found. Returns false when none was running.
+func (e *Editor) StopGrep() bool {
+	job := e.grep
+	if job == nil {
+		return false
+	}
+	job.search.Stop()
+	e.takeGrepMatches(job)
+	e.grep = nil
+	e.SetMessage(fmt.Sprintf("grep: stopped, %s", plural(len(job.list.entries), "match")))
+	return true
+}
+
+// GrepRunning reports whether a ":grep" is still searching.
+func (e *Editor) GrepRunning() bool {
+	return e.grep != nil
+}
+
+func (e *Editor) pollGrep() {
+	job := e.grep
+	if job == nil {
+		return
+	}
+	// A list pushed after ":colder" drops the newer ones, the grep's among them, and what it finds has nowhere to go
+	if !slices.Contains(job.stack.lists, job.list) {
+		job.search.Stop()
+		e.grep = nil
+		return
+	}
+	done := e.takeGrepMatches(job)
+
+	// Opening a file under the user's typing would be rude, the jump waits for normal mode. After ":colder" the
+	// user is looking at another list, it waits for ":cnewer"
+	if job.jump && len(job.list.entries) > 0 && e.GetMode() == ModeNormal && e.cmdWindow == nil && job.stack.list() == job.list {
+		job.jump = false
+		if err := e.goToQuickfix(job.list, 0); err != nil {
+			e.SetMessage(err.Error())
+		}
+	}
+
+	count := len(job.list.entries)
+	switch {
+	case !done:
+		if job.list.index < 0 {
+			e.SetMessage(fmt.Sprintf("grep: %s in %s so far...", plural(count, "match"), plural(job.search.Files(), "file")))
+		}
+	case job.search.Err() != nil:
+		e.grep = nil
+		e.SetMessage(fmt.Sprintf("grep: %v", job.search.Err()))
+	case count == 0:
+		e.grep = nil
+		e.SetMessage(fmt.Sprintf("E480: No match: %s", job.pattern))
+	case job.list.index >= 0:
+		e.grep = nil
+		e.SetMessage(quickfixMessage(job.list))
+	default:
+		e.grep = nil
+		e.SetMessage(fmt.Sprintf("grep: %s in %s", plural(count, "match"), plural(job.search.Files(), "file")))
+	}
+}
+
+// takeGrepMatches appends the matches found since the last call to the job's list and reports whether the search is over.
+func (e *Editor) takeGrepMatches(job *grepJob) bool {
+	matches, done := job.search.Matches()
+	if len(matches) == job.taken {
+		return done
+	}
+	for _, m := range matches[job.taken:] {
+		job.list.entries = append(job.list.entries, QuickfixEntry{
+			File: filepath.FromSlash(m.Path),
+			Line: m.Line,
+			Col:  m.Col,
+			Text: m.Text,
+		})
+	}
+	job.taken = len(matches)
+	e.updateListWindows()
+	return done
+}
+
+// parseGrepArgs splits the leading -F and -i flags from the pattern. "--" ends the flags and a pattern in matching
+// quotes loses them, out of habit from the shell.
+func parseGrepArgs(args string) (grep.Options, string) {
+	var opts grep.Options
+	rest := strings.TrimSpace(args)
+	for {
+		flag, after, _ := strings.Cut(rest, " ")
+		switch flag {
+		case "-F":
+			opts.Literal = true
+		case "-i":
+			opts.IgnoreCase = true
+		case "--":
+			return opts, unquote(strings.TrimSpace(after))
+		default:
+			return opts, unquote(rest)
+		}
+		rest = strings.TrimSpace(after)
+	}
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
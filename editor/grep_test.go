@@ -0,0 +1,102 @@
+package editor
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// grepDir makes the working directory a temp dir with two files to grep, and an editor on a scratch buffer.
+func grepDir(t *testing.T) (*Editor, chan struct{}) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("a.txt", []byte("foo\n"), 0o644))
+	require.NoError(t, os.WriteFile("b.txt", []byte("x foo\n"), 0o644))
+	e := newTestEditor(t, "scratch")
+	return e, withRedraw(e)
+}
+
+// finishGrep polls until the running grep is done.
+func finishGrep(t *testing.T, e *Editor, redraws chan struct{}) {
+	t.Helper()
+	for e.GrepRunning() {
+		waitPoll(t, e, redraws)
+	}
+}
+
+func entryTexts(l *quickfixList) []string {
+	var texts []string
+	for _, entry := range l.entries {
+		texts = append(texts, entry.Text)
+	}
+	return texts
+}
+
+func TestGrepAfterColder(t *testing.T) {
+	e, redraws := grepDir(t)
+	require.NoError(t, e.ExecuteCommand("grep! x"))
+	finishGrep(t, e, redraws)
+
+	require.NoError(t, e.ExecuteCommand("grep foo"))
+	require.NoError(t, e.ExecuteCommand("colder"))
+	finishGrep(t, e, redraws)
+
+	// The matches went to the grep's list, and it didn't jump while another list was shown
+	require.Equal(t, []string{"x foo"}, entryTexts(e.quickfix.list()))
+	require.Equal(t, "scratch", e.buffer.String())
+	require.NoError(t, e.ExecuteCommand("cnewer"))
+	require.ElementsMatch(t, []string{"foo", "x foo"}, entryTexts(e.quickfix.list()))
+}
+
+func TestGrepListDropped(t *testing.T) {
+	e, redraws := grepDir(t)
+	require.NoError(t, e.ExecuteCommand("grep! x"))
+	finishGrep(t, e, redraws)
+
+	// A list pushed after ":colder" drops the grep's, the grep ends
+	require.NoError(t, e.ExecuteCommand("grep foo"))
+	require.NoError(t, e.ExecuteCommand("colder"))
+	e.quickfix.push(&quickfixList{title: "other", index: -1})
+	e.Poll()
+	require.False(t, e.GrepRunning())
+	require.Len(t, e.quickfix.lists, 2)
+	require.Equal(t, "scratch", e.buffer.String())
+}
+
+func TestCancelJobs(t *testing.T) {
+	t.Run("grep", func(t *testing.T) {
+		e, _ := grepDir(t)
+		require.NoError(t, e.ExecuteCommand("grep foo"))
+		require.Equal(t, "grep", e.Running())
+		require.NoError(t, e.ExecuteCommand("cancel"))
+		require.False(t, e.GrepRunning())
+		require.Empty(t, e.Running())
+		require.Contains(t, e.GetMessage(), "grep: stopped")
+		require.EqualError(t, e.ExecuteCommand("cancel"), "No job running")
+	})
+
+	t.Run("make", func(t *testing.T) {
+		e, redraws := grepDir(t)
+		e.options.MakePrg = "sleep 10"
+		require.NoError(t, e.ExecuteCommand("make"))
+		run := e.makeJob.run
+		require.True(t, e.MakeRunning())
+		require.NoError(t, e.ExecuteCommand("cancel"))
+		require.False(t, e.MakeRunning())
+		require.Equal(t, "make: stopped sleep 10", e.GetMessage())
+
+		// The command is killed, not left running
+		select {
+		case <-redraws:
+		case <-time.After(5 * time.Second):
+			t.Fatal("sleep wasn't killed")
+		}
+		require.Eventually(t, func() bool {
+			_, done := run.Output()
+			return done
+		}, 5*time.Second, 10*time.Millisecond)
+		require.EqualError(t, run.Err(), "stopped")
+	})
+}
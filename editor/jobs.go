@@ -0,0 +1,51 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ### BACKGROUND JOBS
+
+// SetRedraw gives the editor a way to ask for a redraw from background work. The screen answers it by
+// calling Poll. It must be safe to call from any goroutine.
+func (e *Editor) SetRedraw(redraw func()) {
+	e.redraw = redraw
+}
+
+// Poll picks up what background work produced since the last call, like the matches of a running ":grep"
+// or the output of a finished ":make" or ":gotest".
+func (e *Editor) Poll() {
+	e.pollSearch()
+	e.pollGrep()
+	e.pollMake()
+	e.pollTest()
+	e.pollFileTree()
+}
+
+// Running describes the background work in progress for the status line, like "make 3s". Empty when there's none.
+func (e *Editor) Running() string {
+	var jobs []string
+	if e.search.jump != nil {
+		jobs = append(jobs, "search")
+	}
+	if e.grep != nil {
+		jobs = append(jobs, "grep")
+	}
+	if e.makeJob != nil {
+		jobs = append(jobs, fmt.Sprintf("make %ds", int(time.Since(e.makeJob.started).Seconds())))
+	}
+	if e.testJob != nil {
+		jobs = append(jobs, fmt.Sprintf("test %ds", int(time.Since(e.testJob.started).Seconds())))
+	}
+	return strings.Join(jobs, ", ")
+}
+
+// StopJobs stops everything running in the background (":cancel"). Returns false when nothing was.
+func (e *Editor) StopJobs() bool {
+	stopped := e.StopSearch()
+	stopped = e.StopGrep() || stopped
+	stopped = e.StopMake() || stopped
+	return e.StopTest() || stopped
+}
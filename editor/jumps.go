@@ -0,0 +1,82 @@
+package editor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ### JUMP LIST
+
+// jumpListLimit is how many jumps are remembered, Vim keeps 100 too.
+const jumpListLimit = 100
+
+// jump is where the cursor was before "G", "gg", a search, a mark, ":{number}" or a buffer switch moved it away.
+// Lines rather than char positions are kept so edits elsewhere in the buffer don't throw it far off.
+type jump struct {
+	buffer *Buffer
+	line   int
+	col    int
+}
+
+// JumpInfo is a jump list entry for the jumps picker.
+type JumpInfo struct {
+	BufferID int
+	Name     string
+	Line     int // 0-based
+	Col      int
+	Text     string
+}
+
+// pushJump remembers the cursor position before a jump. An older entry for the same line is dropped, so
+// each line is listed once.
+func (e *Editor) pushJump() {
+	line, col := e.GetLineColumn()
+	e.jumps = slices.DeleteFunc(e.jumps, func(j jump) bool {
+		return j.buffer == e.Buffer && j.line == line
+	})
+	e.jumps = append(e.jumps, jump{buffer: e.Buffer, line: line, col: col})
+	if over := len(e.jumps) - jumpListLimit; over > 0 {
+		e.jumps = slices.Delete(e.jumps, 0, over)
+	}
+}
+
+// Jumps returns the jump list newest first, without entries of buffers that were closed since.
+func (e *Editor) Jumps() []JumpInfo {
+	jumps := make([]JumpInfo, 0, len(e.jumps))
+	for _, j := range slices.Backward(e.jumps) {
+		if b, ok := e.buffers.Get(j.buffer.id); !ok || b != j.buffer {
+			continue
+		}
+		line := min(j.line, j.buffer.buffer.LineCount()-1)
+		jumps = append(jumps, JumpInfo{
+			BufferID: j.buffer.id,
+			Name:     j.buffer.Name(),
+			Line:     line,
+			Col:      j.col,
+			Text:     strings.TrimSpace(trimNewline(j.buffer.buffer.Line(line))),
+		})
+	}
+	return jumps
+}
+
+// GoToJump goes back to a jump list entry. Leaving counts as a jump itself, so it can be undone the same way.
+func (e *Editor) GoToJump(j JumpInfo) error {
+	b, ok := e.buffers.Get(j.BufferID)
+	if !ok {
+		return fmt.Errorf("E86: Buffer %d does not exist", j.BufferID)
+	}
+	if e.cmdWindow != nil && b != e.Buffer {
+		return errCmdWindow
+	}
+	e.pushJump()
+	e.switchTo(b)
+	e.moveToLineColumn(j.Line, j.Col)
+	return nil
+}
+
+// moveToLineColumn puts the cursor at line and col, clamped to what the buffer has now.
+func (e *Editor) moveToLineColumn(line, col int) {
+	line = max(0, min(line, e.buffer.LineCount()-1))
+	_ = e.cursor.MoveToPosition(line, max(0, min(col, e.buffer.LineLength(line))))
+}
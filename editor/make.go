@@ -0,0 +1,131 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ogzhanolguncu/go_editor/errorformat"
+	"github.com/ogzhanolguncu/go_editor/runner"
+)
+
+// ### MAKE
+
+// makeJob is a running ":make". Its output is read into a list with 'errorformat' once the command exits.
+type makeJob struct {
+	run     *runner.Run
+	command string
+	formats []*errorformat.Format
+	loc     bool // ":lmake", the list is the window's location list
+	jump    bool // Go to the first error when it's done, ":make!" doesn't
+	started time.Time
+}
+
+// compilers are the ":compiler" settings, the build command and the errorformat preset for its output.
+var compilers = map[string]struct{ makeprg, errorformat string }{
+	"go":      {"go build ./...", "go"},
+	"govet":   {"go vet ./...", "go"},
+	"gcc":     {"make", "gcc"},
+	"generic": {"make", "generic"},
+}
+
+// Make runs 'makeprg' in the background (":make [args]"). args replace "$*" in it, or go at its end. When it exits
+// the output becomes a new quickfix list, or location list for ":lmake", and the first error is jumped to unless
+// jump is false.
+func (e *Editor) Make(args string, loc, jump bool) error {
+	formats, err := errorformat.CompileList(e.options.ErrorFormat)
+	if err != nil {
+		return err
+	}
+	command := e.options.MakePrg
+	if strings.Contains(command, "$*") {
+		command = strings.ReplaceAll(command, "$*", args)
+	} else if args != "" {
+		command += " " + args
+	}
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return errors.New("E471: Argument required")
+	}
+
+	name, argv := runner.Shell(command)
+	run, err := runner.Start(".", name, argv, e.redraw)
+	if err != nil {
+		return fmt.Errorf("make: %w", err)
+	}
+	e.StopMake()
+	e.makeJob = &makeJob{run: run, command: command, formats: formats, loc: loc, jump: jump, started: time.Now()}
+	e.SetMessage(fmt.Sprintf("make: running %s", command))
+	return nil
+}
+
+// StopMake kills a running ":make", its output is dropped. Returns false when none was running.
+func (e *Editor) StopMake() bool {
+	job := e.makeJob
+	if job == nil {
+		return false
+	}
+	job.run.Stop()
+	e.makeJob = nil
+	e.SetMessage(fmt.Sprintf("make: stopped %s", job.command))
+	return true
+}
+
+// MakeRunning reports whether a ":make" is still running.
+func (e *Editor) MakeRunning() bool {
+	return e.makeJob != nil
+}
+
+func (e *Editor) pollMake() {
+	job := e.makeJob
+	if job == nil {
+		return
+	}
+	output, done := job.run.Output()
+	if !done {
+		return
+	}
+	// Opening a file under the user's typing would be rude, a jump waits for normal mode
+	if job.jump && (e.GetMode() != ModeNormal || e.cmdWindow != nil) {
+		return
+	}
+	e.makeJob = nil
+
+	if err := job.run.Err(); err != nil {
+		e.SetMessage(fmt.Sprintf("make: %s: %v", job.command, err))
+		return
+	}
+	entries := quickfixEntries(errorformat.Parse(string(output), job.formats))
+	code := job.run.ExitCode()
+	err := e.setQuickfix(job.loc, ":"+job.command, entries, job.jump)
+	switch {
+	case err == nil:
+	case !errors.Is(err, errNoErrors):
+		e.SetMessage(err.Error())
+	case code == 0:
+		e.SetMessage(fmt.Sprintf("make: %s succeeded", job.command))
+	default:
+		// Nothing matched 'errorformat', the output itself says what went wrong
+		lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+		e.SetOutput(append(lines, fmt.Sprintf("shell returned %d", code)))
+	}
+}
+
+// SetCompiler switches 'makeprg' and 'errorformat' to a known tool (":compiler go").
+func (e *Editor) SetCompiler(name string) error {
+	c, ok := compilers[name]
+	if !ok {
+		return fmt.Errorf("E666: Compiler not supported: %s", name)
+	}
+	formats, _ := errorformat.Preset(c.errorformat)
+	e.options.MakePrg, e.options.ErrorFormat = c.makeprg, formats
+	return nil
+}
+
+// completeCompiler offers the names ":compiler" takes.
+func completeCompiler(e *Editor, arg string) []string {
+	return slices.Sorted(maps.Keys(compilers))
+}
@@ -0,0 +1,63 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ### MARKS
+
+// SetMark puts mark a-z at the cursor ("ma").
+func (e *Editor) SetMark(name rune) bool {
+	if name < 'a' || name > 'z' {
+		e.SetMessage("E191: Argument must be a letter or forward/backward quote")
+		return e.fail()
+	}
+	e.marks[name] = e.cursor.GetPosition()
+	return true
+}
+
+// JumpToMark moves to a mark, to its line's first non-blank for "'a" or the exact position for "`a".
+func (e *Editor) JumpToMark(name rune, exact bool) bool {
+	pos, err := e.markPosition(name)
+	if err != nil {
+		e.SetMessage(err.Error())
+		return e.fail()
+	}
+	e.pushJump()
+	if exact {
+		_ = e.cursor.SetPosition(pos)
+		return true
+	}
+	e.moveToFirstNonBlank(e.buffer.CharToLine(pos))
+	return true
+}
+
+func (e *Editor) markPosition(name rune) (int, error) {
+	pos, ok := e.marks[name]
+	if !ok {
+		return 0, errors.New("E20: Mark not set")
+	}
+	return min(pos, e.buffer.Length()), nil
+}
+
+// adjustMarks keeps marks on the same text when something is inserted or deleted before them.
+func (e *Editor) adjustMarks(pos, delta int) {
+	for name, markPos := range e.marks {
+		switch {
+		case delta > 0 && markPos >= pos:
+			e.marks[name] = markPos + delta
+		case delta < 0 && markPos > pos:
+			e.marks[name] = max(pos, markPos+delta)
+		}
+	}
+}
+
+// ListMarks formats the marks the way ":marks" shows them.
+func (e *Editor) ListMarks() []string {
+	lines := []string{"mark line  col text"}
+	for _, m := range e.Marks() {
+		lines = append(lines, fmt.Sprintf(" %c %6d %4d %s", m.Name, m.Line+1, m.Col, m.Text))
+	}
+	return lines
+}
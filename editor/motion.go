@@ -0,0 +1,112 @@
+package editor
+
+// ### MOTIONS
+
+type motionKind int
+
+const (
+	exclusive motionKind = iota // Range stops before the target, e.g. "w"
+	inclusive                   // Range includes the target char, e.g. "e"
+	linewise                    // Range covers whole lines, e.g. "j"
+)
+
+// IsMotion reports whether key is a motion an operator can take.
+func IsMotion(key string) bool {
+	switch key {
+	case "h", "l", "j", "k", "w", "b", "e", "0", "^", "$", "G", "gg":
+		return true
+	}
+	return false
+}
+
+// Motion moves the cursor with the pending count. It returns false when the motion can't move,
+// e.g. "j" on the last line, which is what aborts a macro.
+func (e *Editor) Motion(key string) bool {
+	hasCount := e.vimState.HasCount()
+	n := e.GetCountAndClear()
+
+	target, _, ok := e.motionTarget(key, n, hasCount)
+	if !ok {
+		return e.fail()
+	}
+	if key == "G" || key == "gg" {
+		e.pushJump()
+	}
+	_ = e.cursor.SetPosition(target)
+	return true
+}
+
+// motionTarget runs a motion on the cursor and puts the cursor back, returning where the motion would land.
+func (e *Editor) motionTarget(key string, n int, hasCount bool) (int, motionKind, bool) {
+	start := e.cursor.GetPosition()
+	defer func() { _ = e.cursor.SetPosition(start) }()
+
+	line, col := e.GetLineColumn()
+	kind := exclusive
+
+	switch key {
+	case "h":
+		if col == 0 {
+			return start, kind, false
+		}
+		_ = e.cursor.SetPosition(start - min(n, col))
+	case "l":
+		lineLen := e.buffer.LineLength(line)
+		if col >= lineLen {
+			return start, kind, false
+		}
+		_ = e.cursor.SetPosition(start + min(n, lineLen-col))
+	case "j", "k":
+		kind = linewise
+		for range n {
+			moved := e.cursor.MoveDown
+			if key == "k" {
+				moved = e.cursor.MoveUp
+			}
+			if !moved() {
+				break
+			}
+		}
+		if l, _ := e.GetLineColumn(); l == line {
+			return start, kind, false
+		}
+	case "w", "b":
+		for range n {
+			if key == "w" {
+				e.cursor.MoveToNextWord()
+			} else {
+				e.cursor.MoveToPrevWord()
+			}
+		}
+	case "e":
+		kind = inclusive
+		for range n {
+			e.cursor.MoveToWordEnd()
+		}
+	case "0":
+		e.cursor.MoveToLineStart()
+	case "^":
+		e.cursor.MoveToFirstNonBlank()
+	case "$":
+		target := min(line+n-1, e.buffer.LineCount()-1)
+		_ = e.cursor.SetPosition(e.buffer.LineToChar(target) + e.buffer.LineLength(target))
+	case "G", "gg":
+		kind = linewise
+		target := e.buffer.LineCount() - 1
+		if key == "gg" {
+			target = 0
+		}
+		if hasCount {
+			target = min(n, e.buffer.LineCount()) - 1
+		}
+		e.moveToFirstNonBlank(target)
+	default:
+		return start, kind, false
+	}
+
+	target := e.cursor.GetPosition()
+	if target == start && kind != linewise && key != "0" && key != "^" && key != "$" {
+		return start, kind, false
+	}
+	return target, kind, true
+}
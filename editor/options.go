@@ -0,0 +1,176 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/errorformat"
+)
+
+// ### OPTIONS
+
+// Options are the settings ":set" changes.
+type Options struct {
+	IgnoreCase bool // Searches ignore case
+	SmartCase  bool // ...unless the pattern has an uppercase letter
+	IncSearch  bool // Show where the search lands while typing it
+	HLSearch   bool // Highlight every match of the last search
+	WrapScan   bool // Searches wrap around the end of the buffer
+
+	MakePrg     string // What ":make" runs
+	ErrorFormat string // How ":make" and ":cfile" read locations out of output
+}
+
+func defaultOptions() Options {
+	return Options{
+		IncSearch:   true,
+		HLSearch:    true,
+		WrapScan:    true,
+		MakePrg:     "go build ./...",
+		ErrorFormat: errorformat.Go,
+	}
+}
+
+// option is a boolean setting when value is set, a string one ("makeprg=make") when text is.
+type option struct {
+	name  string
+	short string
+	value func(o *Options) *bool
+	text  func(o *Options) *string
+}
+
+var optionTable = []option{
+	{"errorformat", "efm", nil, func(o *Options) *string { return &o.ErrorFormat }},
+	{"hlsearch", "hls", func(o *Options) *bool { return &o.HLSearch }, nil},
+	{"ignorecase", "ic", func(o *Options) *bool { return &o.IgnoreCase }, nil},
+	{"incsearch", "is", func(o *Options) *bool { return &o.IncSearch }, nil},
+	{"makeprg", "mp", nil, func(o *Options) *string { return &o.MakePrg }},
+	{"smartcase", "scs", func(o *Options) *bool { return &o.SmartCase }, nil},
+	{"wrapscan", "ws", func(o *Options) *bool { return &o.WrapScan }, nil},
+}
+
+func lookupOption(name string) (option, bool) {
+	for _, opt := range optionTable {
+		if opt.name == name || opt.short == name {
+			return opt, true
+		}
+	}
+	return option{}, false
+}
+
+func (e *Editor) GetOptions() Options {
+	return e.options
+}
+
+// setOptions runs ":set". It takes "ic", "noic", "invic", "ic!" and "ic?", "mp=make" for string options, and with
+// no arguments lists what differs from the defaults ("all" lists everything). "\ " is a space inside a value.
+func (e *Editor) setOptions(args string) error {
+	fields := splitSetArgs(args)
+	if len(fields) == 0 || (len(fields) == 1 && fields[0] == "all") {
+		e.listOptions(len(fields) == 1)
+		return nil
+	}
+
+	var shown []string
+	for _, arg := range fields {
+		if i := strings.IndexAny(arg, "=:"); i > 0 {
+			opt, ok := lookupOption(arg[:i])
+			if !ok {
+				return fmt.Errorf("E518: Unknown option: %s", arg)
+			}
+			if opt.text == nil {
+				return fmt.Errorf("E474: Invalid argument: %s", arg)
+			}
+			*opt.text(&e.options) = arg[i+1:]
+			continue
+		}
+		if opt, ok := lookupOption(strings.TrimSuffix(arg, "?")); ok && opt.text != nil {
+			shown = append(shown, opt.name+"="+*opt.text(&e.options))
+			continue
+		}
+
+		name, action := arg, "set"
+		switch {
+		case strings.HasSuffix(arg, "?"):
+			name, action = strings.TrimSuffix(arg, "?"), "show"
+		case strings.HasSuffix(arg, "!"):
+			name, action = strings.TrimSuffix(arg, "!"), "toggle"
+		case strings.HasPrefix(arg, "inv"):
+			name, action = strings.TrimPrefix(arg, "inv"), "toggle"
+		case strings.HasPrefix(arg, "no"):
+			name, action = strings.TrimPrefix(arg, "no"), "unset"
+		}
+
+		opt, ok := lookupOption(name)
+		if !ok {
+			return fmt.Errorf("E518: Unknown option: %s", arg)
+		}
+		if opt.value == nil {
+			return fmt.Errorf("E474: Invalid argument: %s", arg)
+		}
+		value := opt.value(&e.options)
+		switch action {
+		case "set":
+			*value = true
+		case "unset":
+			*value = false
+		case "toggle":
+			*value = !*value
+		case "show":
+			shown = append(shown, formatOption(opt.name, *value))
+		}
+	}
+	if len(shown) > 0 {
+		e.SetMessage(strings.Join(shown, " "))
+	}
+	return nil
+}
+
+func (e *Editor) listOptions(all bool) {
+	defaults := defaultOptions()
+	lines := []string{"--- Options ---"}
+	for _, opt := range optionTable {
+		if opt.text != nil {
+			if value := *opt.text(&e.options); all || value != *opt.text(&defaults) {
+				lines = append(lines, "  "+opt.name+"="+value)
+			}
+			continue
+		}
+		value := *opt.value(&e.options)
+		if all || value != *opt.value(&defaults) {
+			lines = append(lines, "  "+formatOption(opt.name, value))
+		}
+	}
+	e.SetOutput(lines)
+}
+
+// splitSetArgs splits ":set" arguments on spaces, except the ones escaped with a backslash.
+func splitSetArgs(args string) []string {
+	var fields []string
+	var cur strings.Builder
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == '\\' && i+1 < len(args) && (args[i+1] == ' ' || args[i+1] == '\\'):
+			cur.WriteByte(args[i+1])
+			i++
+		case args[i] == ' ' || args[i] == '\t':
+			if cur.Len() > 0 {
+				fields = append(fields, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteByte(args[i])
+		}
+	}
+	if cur.Len() > 0 {
+		fields = append(fields, cur.String())
+	}
+	return fields
+}
+
+func formatOption(name string, value bool) string {
+	if value {
+		return name
+	}
+	return "no" + name
+}
@@ -0,0 +1,197 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/history"
+	"github.com/ogzhanolguncu/go_editor/layout"
+	"github.com/ogzhanolguncu/go_editor/register"
+)
+
+// ### PICKERS
+//
+// What the screen's fuzzy pickers list and do. The pickers themselves live in the screen, these are the
+// editor's side of them: listings, previews and ways to open what was picked.
+
+// OpenTarget is where a picked file or buffer opens.
+type OpenTarget int
+
+const (
+	OpenHere   OpenTarget = iota // Enter, in the current window
+	OpenVSplit                   // Ctrl-V, in a new window on the left
+	OpenSplit                    // Ctrl-X, in a new window above
+)
+
+// previewMaxBytes caps how much of a file that isn't open is read for a preview.
+const previewMaxBytes = 256 * 1024
+
+// OpenFileIn edits path in the current window or a new split. Keys go back to the window if the file tree had them.
+func (e *Editor) OpenFileIn(path string, target OpenTarget) error {
+	if e.cmdWindow != nil {
+		return errCmdWindow
+	}
+	e.focusTree(false)
+	switch target {
+	case OpenVSplit:
+		return e.SplitWindow(layout.Columns, path)
+	case OpenSplit:
+		return e.SplitWindow(layout.Rows, path)
+	}
+	return e.OpenFile(path)
+}
+
+// BufferInfo is an open buffer for the buffers picker.
+type BufferInfo struct {
+	ID       int
+	Name     string
+	Modified bool
+	Current  bool
+	Line     int // Where its cursor is, 0-based
+}
+
+// Buffers lists the open buffers in buffer number order.
+func (e *Editor) Buffers() []BufferInfo {
+	buffers := make([]BufferInfo, 0, e.buffers.Len())
+	for _, b := range e.buffers.List() {
+		buffers = append(buffers, BufferInfo{
+			ID:       b.id,
+			Name:     b.Name(),
+			Modified: b.modified,
+			Current:  b == e.Buffer,
+			Line:     b.buffer.CharToLine(e.cursorIn(b)),
+		})
+	}
+	return buffers
+}
+
+// ShowBufferIn shows buffer id in the current window or a new split.
+func (e *Editor) ShowBufferIn(id int, target OpenTarget) error {
+	b, ok := e.buffers.Get(id)
+	if !ok {
+		return fmt.Errorf("E86: Buffer %d does not exist", id)
+	}
+	if e.cmdWindow != nil {
+		return errCmdWindow
+	}
+	e.focusTree(false)
+	switch target {
+	case OpenVSplit:
+		if err := e.SplitWindow(layout.Columns, ""); err != nil {
+			return err
+		}
+	case OpenSplit:
+		if err := e.SplitWindow(layout.Rows, ""); err != nil {
+			return err
+		}
+	}
+	e.switchTo(b)
+	e.SetMessage(e.fileInfo())
+	return nil
+}
+
+// GoToLine moves to the first non-blank of a 0-based line, as a jump.
+func (e *Editor) GoToLine(line int) {
+	e.pushJump()
+	e.moveToFirstNonBlank(max(0, min(line, e.buffer.LineCount()-1)))
+}
+
+// MarkInfo is a set mark for the marks picker and ":marks".
+type MarkInfo struct {
+	Name rune
+	Line int // 0-based
+	Col  int
+	Text string
+}
+
+// Marks lists the current buffer's marks a-z.
+func (e *Editor) Marks() []MarkInfo {
+	var marks []MarkInfo
+	for name := 'a'; name <= 'z'; name++ {
+		pos, ok := e.marks[name]
+		if !ok {
+			continue
+		}
+		line, col := e.LineColumnAt(min(pos, e.buffer.Length()))
+		marks = append(marks, MarkInfo{Name: name, Line: line, Col: col, Text: trimNewline(e.buffer.Line(line))})
+	}
+	return marks
+}
+
+// Registers lists the registers that hold something, in ":registers" order. The clipboard registers are left
+// out, like ":registers" does without arguments.
+func (e *Editor) Registers() []register.Entry {
+	return e.registers.List("")
+}
+
+// CommandNames lists every ":" command, sorted.
+func (e *Editor) CommandNames() []string {
+	return e.commands.Names()
+}
+
+// RecentFiles lists files edited in this and earlier sessions, newest first. Files that are gone are left out.
+func (e *Editor) RecentFiles() []string {
+	entries := e.cmdHistory.List(history.File).Entries()
+	files := make([]string, 0, len(entries))
+	for _, path := range slices.Backward(entries) {
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, relativePath(path))
+		}
+	}
+	return files
+}
+
+// Preview is a slice of a buffer or file around a line of interest, for picker preview panes.
+type Preview struct {
+	Lines []string
+	First int // 0-based line number of Lines[0]
+	Focus int // Line to highlight, -1 for none
+}
+
+// BufferPreview returns about height lines of buffer id centered on line.
+func (e *Editor) BufferPreview(id, line, height int) Preview {
+	b, ok := e.buffers.Get(id)
+	if !ok {
+		return Preview{Focus: -1}
+	}
+	count := b.buffer.LineCount()
+	first := previewStart(line, height, count)
+	lines := make([]string, 0, height)
+	for i := first; i < min(count, first+height); i++ {
+		lines = append(lines, trimNewline(b.buffer.Line(i)))
+	}
+	return Preview{Lines: lines, First: first, Focus: line}
+}
+
+// FilePreview is BufferPreview for a path. An open buffer is shown as edited, other files are read from disk.
+func (e *Editor) FilePreview(path string, line, height int) Preview {
+	if b, ok := e.buffers.FindByPath(path); ok {
+		return e.BufferPreview(b.id, line, height)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Preview{Lines: []string{err.Error()}, Focus: -1}
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, previewMaxBytes))
+	if err != nil {
+		return Preview{Lines: []string{err.Error()}, Focus: -1}
+	}
+	if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return Preview{Lines: []string{"[binary file]"}, Focus: -1}
+	}
+
+	all := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	first := previewStart(line, height, len(all))
+	return Preview{Lines: all[first:min(len(all), first+height)], First: first, Focus: line}
+}
+
+// previewStart picks the first line so line sits in the middle, without running past either end.
+func previewStart(line, height, count int) int {
+	return max(0, min(line-height/2, count-height))
+}
@@ -0,0 +1,82 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewStart(t *testing.T) {
+	tests := []struct {
+		name                string
+		line, height, count int
+		want                int
+	}{
+		{name: "centered", line: 50, height: 10, count: 100, want: 45},
+		{name: "near the top", line: 2, height: 10, count: 100, want: 0},
+		{name: "near the end", line: 98, height: 10, count: 100, want: 90},
+		{name: "shorter than the pane", line: 3, height: 10, count: 5, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, previewStart(tt.line, tt.height, tt.count))
+		})
+	}
+}
+
+func TestFilePreview(t *testing.T) {
+	dir := t.TempDir()
+	text := filepath.Join(dir, "text")
+	require.NoError(t, os.WriteFile(text, []byte("a\nb\nc\nd\ne\n"), 0o644))
+	binary := filepath.Join(dir, "binary")
+	require.NoError(t, os.WriteFile(binary, []byte("a\x00b"), 0o644))
+
+	e := newTestEditor(t, "")
+	require.Equal(t, Preview{Lines: []string{"b", "c", "d"}, First: 1, Focus: 2}, e.FilePreview(text, 2, 3))
+	require.Equal(t, Preview{Lines: []string{"[binary file]"}, Focus: -1}, e.FilePreview(binary, 0, 3))
+	require.Equal(t, -1, e.FilePreview(filepath.Join(dir, "missing"), 0, 3).Focus)
+
+	// An open buffer is shown with its unsaved edits
+	require.NoError(t, e.OpenFile(text))
+	require.NoError(t, e.ExecuteCommand("s/a/x/"))
+	require.Equal(t, Preview{Lines: []string{"x", "b"}, First: 0, Focus: 0}, e.FilePreview(text, 0, 2))
+}
+
+func TestJumps(t *testing.T) {
+	lines := func(e *Editor) []int {
+		var lines []int
+		for _, j := range e.Jumps() {
+			lines = append(lines, j.Line)
+		}
+		return lines
+	}
+
+	e := newTestEditor(t, "l1\nl2\nl3\nl4\nl5")
+	for _, command := range []string{"3", "5", "1", "3"} {
+		require.NoError(t, e.ExecuteCommand(command))
+	}
+	// Newest first, and line 3 is listed once although it was left twice
+	require.Equal(t, []int{0, 4, 2}, lines(e))
+	require.Equal(t, "l5", e.Jumps()[1].Text)
+
+	require.NoError(t, e.GoToJump(e.Jumps()[1]))
+	line, _ := e.GetLineColumn()
+	require.Equal(t, 4, line)
+	// Leaving counts as a jump
+	require.Equal(t, []int{2, 0, 4}, lines(e))
+
+	// Lines past the end after an edit are clamped
+	require.NoError(t, e.ExecuteCommand("3,5d"))
+	require.Equal(t, []int{1, 0, 1}, lines(e))
+
+	// Jumps into closed buffers are left out
+	id := e.ID()
+	require.NoError(t, e.OpenFile(filepath.Join(t.TempDir(), "other")))
+	require.NoError(t, e.ExecuteCommand(fmt.Sprintf("bd! %d", id)))
+	require.Empty(t, e.Jumps())
+	require.Error(t, e.GoToJump(JumpInfo{BufferID: id}))
+}
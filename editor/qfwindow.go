@@ -0,0 +1,220 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/layout"
+)
+
+// ### QUICKFIX WINDOW
+
+const (
+	quickfixWindowName = "[Quickfix List]"
+	locationWindowName = "[Location List]"
+	listWindowHeight   = 10
+)
+
+var errNotModifiable = errors.New("E21: Cannot make changes, 'modifiable' is off")
+
+// listView makes a window the quickfix or location list window (":copen", ":lopen"). The window shows a read-only
+// buffer with a line per entry, built again whenever the list changes.
+type listView struct {
+	loc    bool
+	owner  int // Window the location list belongs to, jumps go there
+	stack  *qfStack
+	buffer *Buffer
+	shown  *quickfixList // List the buffer was built from
+	index  int           // Its current entry back then
+}
+
+// InListWindow reports whether the current window is a quickfix or location list window.
+func (e *Editor) InListWindow() bool {
+	return e.listView != nil && e.listView.buffer == e.Buffer
+}
+
+// OpenListWindow opens the quickfix list window across the bottom of the tab (":copen"), or the location list
+// window under the current one (":lopen"), and moves into it. An open one is just gone to.
+func (e *Editor) OpenListWindow(loc bool, height int) error {
+	if e.cmdWindow != nil {
+		return errCmdWindow
+	}
+	stack, err := e.qfStackFor(loc, false)
+	if err != nil {
+		return err
+	}
+	if w := e.findListWindow(loc, stack); w != nil {
+		e.Window = w
+		return nil
+	}
+
+	lv := &listView{loc: loc, owner: e.winID, stack: stack}
+	b, err := lv.build()
+	if err != nil {
+		return err
+	}
+	w := newWindow(e.nextWinID, b)
+	w.listView = lv
+	if loc {
+		err = e.tab.layout.SplitBelow(e.winID, w.winID, height)
+	} else {
+		err = e.tab.layout.SplitBottom(w.winID, height)
+	}
+	if err != nil {
+		return err
+	}
+	e.nextWinID++
+	e.tab.windows[w.winID] = w
+	e.focusTree(false)
+	e.Window = w
+	return nil
+}
+
+// CloseListWindow closes the current tab's quickfix or location list window (":cclose", ":lclose").
+func (e *Editor) CloseListWindow(loc bool) error {
+	if e.cmdWindow != nil {
+		return errCmdWindow
+	}
+	stack, err := e.qfStackFor(loc, false)
+	if err != nil {
+		return nil // No location list, so no window either
+	}
+	w := e.findListWindow(loc, stack)
+	if w == nil {
+		return nil
+	}
+	if w == e.Window {
+		return e.CloseWindow()
+	}
+	if _, ok := e.tab.layout.Close(w.winID); !ok {
+		return errLastWindow
+	}
+	delete(e.tab.windows, w.winID)
+	return nil
+}
+
+// findListWindow is the current tab's window showing the quickfix list, or the location list in stack.
+func (e *Editor) findListWindow(loc bool, stack *qfStack) *Window {
+	for _, id := range e.tab.layout.Windows() {
+		w := e.tab.windows[id]
+		if lv := w.listView; lv != nil && w.Buffer == lv.buffer && lv.loc == loc && lv.stack == stack {
+			return w
+		}
+	}
+	return nil
+}
+
+// OpenListEntry jumps to the entry under the cursor in a list window (Enter there). The jump happens in the window
+// the location list belongs to, or the first window that isn't a list.
+func (e *Editor) OpenListEntry() error {
+	if !e.InListWindow() {
+		return nil
+	}
+	l := e.listView.stack.list()
+	if l == nil {
+		return errNoErrors
+	}
+	line, _ := e.GetLineColumn()
+	target := nextValid(l, line, 1)
+	if target < 0 {
+		target = nextValid(l, line, -1)
+	}
+	if target < 0 {
+		return errNoErrors
+	}
+	return e.goToQuickfix(l, target)
+}
+
+// leaveListWindow moves out of a list window before a jump opens a file in it. With no other window in the tab,
+// one is split off above the list.
+func (e *Editor) leaveListWindow() error {
+	if !e.InListWindow() {
+		return nil
+	}
+	if e.listView.loc {
+		if w, ok := e.tab.windows[e.listView.owner]; ok {
+			e.Window = w
+			return nil
+		}
+	}
+	for _, id := range e.tab.layout.Windows() {
+		if w := e.tab.windows[id]; w.listView == nil || w.Buffer != w.listView.buffer {
+			e.Window = w
+			return nil
+		}
+	}
+	return e.SplitWindow(layout.Rows, "")
+}
+
+// updateListWindows rebuilds the list windows of every tab after a list changed. The cursor follows the current
+// entry, and stays where it was when only new entries came in.
+func (e *Editor) updateListWindows() {
+	for _, tab := range e.tabs {
+		for _, w := range tab.windows {
+			lv := w.listView
+			if lv == nil || w.Buffer != lv.buffer {
+				continue
+			}
+			l := lv.stack.list()
+			keep := l != nil && l == lv.shown && l.index == lv.index
+			line := w.buffer.CharToLine(w.cursor.GetPosition())
+			yOffset := w.yOffset
+
+			b, err := lv.build()
+			if err != nil {
+				continue
+			}
+			if keep {
+				b.lastCursor = b.buffer.LineToChar(min(line, b.buffer.LineCount()-1))
+			}
+			w.show(b)
+			if keep {
+				w.yOffset = yOffset
+			}
+		}
+	}
+}
+
+// build makes the buffer for the list, a line per entry like Vim's "main.go|12 col 5 error| undefined: foo".
+func (lv *listView) build() (*Buffer, error) {
+	l := lv.stack.list()
+	var lines []string
+	cursorLine, index := 0, -1
+	if l != nil {
+		for _, entry := range l.entries {
+			lines = append(lines, listLine(entry))
+		}
+		cursorLine, index = max(0, l.index), l.index
+	}
+
+	b, err := newBuffer(strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, err
+	}
+	b.filename = quickfixWindowName
+	if lv.loc {
+		b.filename = locationWindowName
+	}
+	b.nomodifiable = true
+	b.lastCursor = b.buffer.LineToChar(min(cursorLine, b.buffer.LineCount()-1))
+	lv.buffer, lv.shown, lv.index = b, l, index
+	return b, nil
+}
+
+func listLine(entry QuickfixEntry) string {
+	if !entry.valid() {
+		return "|| " + entry.Text
+	}
+	var pos strings.Builder
+	if entry.Line > 0 {
+		fmt.Fprintf(&pos, "%d", entry.Line)
+	}
+	if entry.Col > 0 {
+		fmt.Fprintf(&pos, " col %d", entry.Col)
+	}
+	if kind := entryKind(entry.Type); kind != "" {
+		pos.WriteString(" " + kind)
+	}
+	return fmt.Sprintf("%s|%s| %s", entry.File, pos.String(), strings.TrimSpace(entry.Text))
+}
@@ -0,0 +1,349 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/errorformat"
+)
+
+// ### QUICKFIX
+
+// QuickfixEntry is a location in a quickfix or location list. Lines and columns are 1-based like Vim shows them,
+// a zero column means the line's first non-blank. Entries without a file are output lines no errorformat matched,
+// they're listed but navigation skips them.
+type QuickfixEntry struct {
+	File string
+	Line int
+	Col  int
+	Type rune // 'E' error, 'W' warning... 0 when unknown
+	Text string
+}
+
+func (q QuickfixEntry) valid() bool {
+	return q.File != ""
+}
+
+// quickfixList is a list of locations to step through with ":cn" and ":cp".
+type quickfixList struct {
+	title   string // The command that made the list
+	entries []QuickfixEntry
+	index   int // Current entry, -1 before the first jump
+}
+
+// qfStack keeps the last few lists so ":colder" and ":cnewer" can go back to them. The editor has one for the
+// quickfix list and every window can have one for its location list.
+type qfStack struct {
+	lists   []*quickfixList
+	current int
+}
+
+const qfStackLimit = 10
+
+var (
+	errNoErrors  = errors.New("E42: No Errors")
+	errNoMoreQf  = errors.New("E553: No more items")
+	errNoLocList = errors.New("E776: No location list")
+)
+
+// list is the current list, nil when nothing was pushed yet.
+func (s *qfStack) list() *quickfixList {
+	if s == nil || len(s.lists) == 0 {
+		return nil
+	}
+	return s.lists[s.current]
+}
+
+// push makes l the current list. Lists newer than the current one are dropped, like Vim does.
+func (s *qfStack) push(l *quickfixList) {
+	if len(s.lists) > 0 {
+		s.lists = s.lists[:s.current+1]
+	}
+	s.lists = append(s.lists, l)
+	if over := len(s.lists) - qfStackLimit; over > 0 {
+		s.lists = slices.Delete(s.lists, 0, over)
+	}
+	s.current = len(s.lists) - 1
+}
+
+// clone copies the stack for a new window, moving in one doesn't move in the other.
+func (s *qfStack) clone() *qfStack {
+	if s == nil {
+		return nil
+	}
+	c := &qfStack{current: s.current}
+	for _, l := range s.lists {
+		copied := *l
+		copied.entries = slices.Clone(l.entries)
+		c.lists = append(c.lists, &copied)
+	}
+	return c
+}
+
+// qfStackFor is the stack ":c" commands (loc false) or ":l" commands (loc true) work on. In a location list window
+// that's the list it shows. create makes the current window a location list when it has none.
+func (e *Editor) qfStackFor(loc, create bool) (*qfStack, error) {
+	if !loc {
+		return e.quickfix, nil
+	}
+	if e.InListWindow() && e.listView.loc {
+		return e.listView.stack, nil
+	}
+	if e.loclist == nil {
+		if !create {
+			return nil, errNoLocList
+		}
+		e.loclist = &qfStack{}
+	}
+	return e.loclist, nil
+}
+
+// currentList is the list ":cn" or ":ln" would step through, or an error when it's missing or has nothing valid.
+func (e *Editor) currentList(loc bool) (*quickfixList, error) {
+	e.pollGrep()
+	stack, err := e.qfStackFor(loc, false)
+	if err != nil {
+		return nil, err
+	}
+	l := stack.list()
+	if l == nil || !slices.ContainsFunc(l.entries, QuickfixEntry.valid) {
+		return nil, errNoErrors
+	}
+	return l, nil
+}
+
+// QuickfixNext goes n valid entries forward in the quickfix list (":cn"), or the location list (":ln").
+func (e *Editor) QuickfixNext(loc bool, n int) error {
+	return e.quickfixStep(loc, max(1, n))
+}
+
+// QuickfixPrev goes n valid entries back (":cp", ":lp").
+func (e *Editor) QuickfixPrev(loc bool, n int) error {
+	return e.quickfixStep(loc, -max(1, n))
+}
+
+func (e *Editor) quickfixStep(loc bool, delta int) error {
+	l, err := e.currentList(loc)
+	if err != nil {
+		return err
+	}
+	target := l.index
+	if l.index < 0 && delta > 0 {
+		target = -1 // Nothing visited yet, ":cn" starts at the first entry
+	}
+	step := 1
+	if delta < 0 {
+		step = -1
+	}
+	for moved := 0; moved != delta; moved += step {
+		next := nextValid(l, target+step, step)
+		if next < 0 {
+			return errNoMoreQf
+		}
+		target = next
+	}
+	return e.goToQuickfix(l, target)
+}
+
+// QuickfixGo goes to entry nr (":cc 3", ":ll 3", ":cfirst" is 1). 0 is the current entry and -1 the last (":clast").
+func (e *Editor) QuickfixGo(loc bool, nr int) error {
+	l, err := e.currentList(loc)
+	if err != nil {
+		return err
+	}
+	target := min(nr, len(l.entries)) - 1
+	switch nr {
+	case 0:
+		target = max(0, l.index)
+	case -1:
+		target = len(l.entries) - 1
+	}
+	// An invalid entry sends the jump to the nearest valid one, forward first
+	if next := nextValid(l, target, 1); next >= 0 {
+		target = next
+	} else {
+		target = nextValid(l, target, -1)
+	}
+	return e.goToQuickfix(l, target)
+}
+
+// nextValid is the first entry with a file from i on, going in direction step. -1 when there's none.
+func nextValid(l *quickfixList, i, step int) int {
+	for ; i >= 0 && i < len(l.entries); i += step {
+		if l.entries[i].valid() {
+			return i
+		}
+	}
+	return -1
+}
+
+// goToQuickfix opens entry i's file and puts the cursor on its line and column.
+func (e *Editor) goToQuickfix(l *quickfixList, i int) error {
+	if e.cmdWindow != nil {
+		return errCmdWindow
+	}
+	if err := e.leaveListWindow(); err != nil {
+		return err
+	}
+	entry := l.entries[i]
+	if e.filename == "" || absPath(e.filename) != absPath(entry.File) {
+		if err := e.OpenFile(entry.File); err != nil {
+			return err
+		}
+	} else {
+		e.pushJump()
+	}
+	e.focusTree(false)
+
+	if entry.Col > 0 {
+		e.moveToLineColumn(entry.Line-1, entry.Col-1)
+	} else {
+		e.moveToFirstNonBlank(max(0, min(entry.Line-1, e.buffer.LineCount()-1)))
+	}
+	l.index = i
+	e.updateListWindows()
+	e.SetMessage(quickfixMessage(l))
+	return nil
+}
+
+// quickfixMessage is Vim's "(3 of 12): text" for the current entry.
+func quickfixMessage(l *quickfixList) string {
+	entry := l.entries[l.index]
+	text := strings.TrimSpace(entry.Text)
+	if kind := entryKind(entry.Type); kind != "" {
+		text = kind + ": " + text
+	}
+	return fmt.Sprintf("(%d of %d): %s", l.index+1, len(l.entries), text)
+}
+
+// entryKind spells out the type letters compilers use.
+func entryKind(t rune) string {
+	switch t {
+	case 0:
+		return ""
+	case 'E':
+		return "error"
+	case 'W':
+		return "warning"
+	case 'I':
+		return "info"
+	case 'N':
+		return "note"
+	}
+	return "error " + string(t)
+}
+
+// ListQuickfix formats the list the way ":clist" and ":llist" show it, the current entry marked with ">".
+func (e *Editor) ListQuickfix(loc bool) ([]string, error) {
+	e.pollGrep()
+	stack, err := e.qfStackFor(loc, false)
+	if err != nil {
+		return nil, err
+	}
+	l := stack.list()
+	if l == nil || len(l.entries) == 0 {
+		return nil, errNoErrors
+	}
+	lines := make([]string, 0, len(l.entries))
+	for i, entry := range l.entries {
+		mark := ' '
+		if i == l.index {
+			mark = '>'
+		}
+		if !entry.valid() {
+			lines = append(lines, fmt.Sprintf("%c%2d %s", mark, i+1, entry.Text))
+			continue
+		}
+		pos := fmt.Sprintf("%s:%d", entry.File, entry.Line)
+		if entry.Col > 0 {
+			pos += fmt.Sprintf(" col %d", entry.Col)
+		}
+		if kind := entryKind(entry.Type); kind != "" {
+			pos += " " + kind
+		}
+		lines = append(lines, fmt.Sprintf("%c%2d %s: %s", mark, i+1, pos, strings.TrimSpace(entry.Text)))
+	}
+	return lines, nil
+}
+
+// QuickfixHistory moves n lists back (":colder") or forward (":cnewer") in the list stack.
+func (e *Editor) QuickfixHistory(loc bool, n int) error {
+	stack, err := e.qfStackFor(loc, false)
+	if err != nil {
+		return err
+	}
+	target := stack.current + n
+	switch {
+	case target < 0:
+		return errors.New("E380: At bottom of quickfix stack")
+	case target >= len(stack.lists):
+		return errors.New("E381: At top of quickfix stack")
+	}
+	stack.current = target
+	e.updateListWindows()
+
+	l := stack.list()
+	kind := "error"
+	if loc {
+		kind = "location"
+	}
+	e.SetMessage(fmt.Sprintf("%s list %d of %d; %s  %s", kind, target+1, len(stack.lists), plural(len(l.entries), "error"), l.title))
+	return nil
+}
+
+// setQuickfix pushes a new list and jumps to its first valid entry when jump is set.
+func (e *Editor) setQuickfix(loc bool, title string, entries []QuickfixEntry, jump bool) error {
+	stack, err := e.qfStackFor(loc, true)
+	if err != nil {
+		return err
+	}
+	l := &quickfixList{title: title, entries: entries, index: -1}
+	stack.push(l)
+	e.updateListWindows()
+	first := nextValid(l, 0, 1)
+	if first < 0 {
+		return errNoErrors
+	}
+	if !jump {
+		e.SetMessage(fmt.Sprintf("%s  %s", plural(len(entries), "error"), title))
+		return nil
+	}
+	return e.goToQuickfix(l, first)
+}
+
+// LoadErrorFile reads an errors file into a new list and jumps to the first error (":cfile", ":lfile").
+// Lines are parsed with 'errorformat', "errors.err" is read when path is empty.
+func (e *Editor) LoadErrorFile(loc bool, path string, jump bool) error {
+	if path == "" {
+		path = "errors.err"
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("E40: Can't open errorfile %s", path)
+	}
+	formats, err := errorformat.CompileList(e.options.ErrorFormat)
+	if err != nil {
+		return err
+	}
+	prefix := ":cfile "
+	if loc {
+		prefix = ":lfile "
+	}
+	return e.setQuickfix(loc, prefix+path, quickfixEntries(errorformat.Parse(string(content), formats)), jump)
+}
+
+// quickfixEntries turns parsed output lines into list entries.
+func quickfixEntries(parsed []errorformat.Entry) []QuickfixEntry {
+	entries := make([]QuickfixEntry, 0, len(parsed))
+	for _, p := range parsed {
+		if p.File != "" {
+			p.File = filepath.Clean(p.File) // "./main.go" is the buffer "main.go"
+		}
+		entries = append(entries, QuickfixEntry{File: p.File, Line: p.Line, Col: p.Col, Type: p.Type, Text: p.Text})
+	}
+	return entries
+}
@@ -0,0 +1,252 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/register"
+)
+
+// ### REGISTERS, YANK, DELETE AND PUT
+
+// SetClipboard backs the '+' and '*' registers with the system clipboard.
+func (e *Editor) SetClipboard(c register.ClipboardProvider) {
+	e.registers.SetClipboard(c)
+}
+
+// SelectRegister stores the register picked with '"x' for the next yank, delete or put.
+func (e *Editor) SelectRegister(name rune) bool {
+	if !register.IsValid(name) {
+		e.SetMessage(fmt.Sprintf("E354: Invalid register name: '%c'", name))
+		return false
+	}
+	e.vimState.register = name
+	return true
+}
+
+// GetRegister returns the text of a register, used to replay macros.
+func (e *Editor) GetRegister(name rune) (string, bool) {
+	reg, ok := e.registers.Get(name)
+	return reg.Text, ok
+}
+
+// SetRegister stores a recorded macro. Uppercase names append like they do for yanks.
+func (e *Editor) SetRegister(name rune, text string) error {
+	return e.registers.Yank(name, register.Register{Text: text, Kind: register.Charwise})
+}
+
+// YankLines yanks count lines starting from the cursor line ("yy", "Y").
+func (e *Editor) YankLines() {
+	n := e.GetCountAndClear()
+	name := e.vimState.GetRegisterAndClear()
+	e.yankLines(n, name)
+}
+
+// DeleteLines deletes count lines starting from the cursor line ("dd").
+func (e *Editor) DeleteLines() {
+	n := e.GetCountAndClear()
+	name := e.vimState.GetRegisterAndClear()
+	e.deleteLines(n, name)
+	e.recordChange(change{kind: changeOperatorLine, op: 'd', count: n, register: name}, true)
+}
+
+// DeleteUnderCursor deletes count chars under and after the cursor without crossing the end of line ("x").
+func (e *Editor) DeleteUnderCursor() {
+	n := e.GetCountAndClear()
+	name := e.vimState.GetRegisterAndClear()
+	e.recordChange(change{kind: changeDeleteChar, count: n, register: name}, e.deleteChars(n, name))
+}
+
+// DeleteToLineEnd deletes from the cursor to the end of line ("D").
+func (e *Editor) DeleteToLineEnd() {
+	n := e.GetCountAndClear()
+	name := e.vimState.GetRegisterAndClear()
+	e.recordChange(change{kind: changeOperator, op: 'd', motion: "$", count: n, register: name}, e.operate('d', "$", n, false, name))
+}
+
+// Paste puts the selected register count times, after the cursor for "p" and before it for "P".
+func (e *Editor) Paste(after bool) {
+	n := e.GetCountAndClear()
+	name := e.vimState.GetRegisterAndClear()
+	e.recordChange(change{kind: changePut, count: n, register: name, after: after}, e.paste(n, name, after))
+}
+
+func (e *Editor) yankLines(n int, name rune) {
+	start, end, lines := e.lineSpan(n)
+	e.yank(name, e.buffer.Substring(start, end), true, lines)
+}
+
+// yank stores text in a register, linewise text is normalised to end with a newline.
+func (e *Editor) yank(name rune, text string, linewise bool, lines int) {
+	reg := register.Register{Text: text, Kind: register.Charwise}
+	if linewise {
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		reg = register.Register{Text: text, Kind: register.Linewise}
+	}
+
+	if err := e.registers.Yank(name, reg); err != nil {
+		e.SetMessage(err.Error())
+		return
+	}
+	if lines > 2 {
+		e.SetMessage(fmt.Sprintf("%d lines yanked", lines))
+	}
+}
+
+func (e *Editor) deleteLines(n int, name rune) {
+	line, _ := e.GetLineColumn()
+	start, end, lines := e.lineSpan(n)
+	// Last line has no trailing newline, so take the newline in front of it instead
+	if end == e.buffer.Length() && start > 0 && !strings.HasSuffix(e.buffer.Substring(start, end), "\n") {
+		start--
+		line--
+	}
+
+	text := e.deleteText(start, end)
+	text = strings.TrimPrefix(text, "\n")
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	if err := e.registers.Delete(name, register.Register{Text: text, Kind: register.Linewise}); err != nil {
+		e.SetMessage(err.Error())
+	}
+	if lines > 2 {
+		e.SetMessage(fmt.Sprintf("%d fewer lines", lines))
+	}
+	e.moveToFirstNonBlank(max(0, min(line, e.buffer.LineCount()-1)))
+}
+
+func (e *Editor) deleteChars(n int, name rune) bool {
+	pos := e.cursor.GetPosition()
+	line, col := e.GetLineColumn()
+	if col >= e.buffer.LineLength(line) {
+		return false
+	}
+	end := min(pos+n, e.buffer.LineToChar(line)+e.buffer.LineLength(line))
+
+	text := e.deleteText(pos, end)
+	if err := e.registers.Delete(name, register.Register{Text: text, Kind: register.Charwise}); err != nil {
+		e.SetMessage(err.Error())
+	}
+	return true
+}
+
+func (e *Editor) paste(n int, name rune, after bool) bool {
+	reg, ok := e.registers.Get(name)
+	if !ok {
+		if name == 0 {
+			name = register.Unnamed
+		}
+		e.SetMessage(fmt.Sprintf("E353: Nothing in register %c", name))
+		return e.fail()
+	}
+
+	e.beginChange()
+	defer e.endChange()
+
+	switch reg.Kind {
+	case register.Linewise:
+		e.pasteLines(reg.Text, n, after)
+	case register.Blockwise:
+		e.pasteBlock(reg.Text, n, after)
+	default:
+		e.pasteChars(reg.Text, n, after)
+	}
+	return true
+}
+
+func (e *Editor) pasteChars(text string, n int, after bool) {
+	pos := e.cursor.GetPosition()
+	if after {
+		if ch := e.buffer.CharAt(pos); pos < e.buffer.Length() && ch != '\n' {
+			pos++
+		}
+	}
+
+	text = strings.Repeat(text, n)
+	e.insertText(pos, text)
+	// Cursor lands on the last pasted char
+	_ = e.cursor.SetPosition(pos + len([]rune(text)) - 1)
+}
+
+func (e *Editor) pasteLines(text string, n int, after bool) {
+	line, _ := e.GetLineColumn()
+	text = strings.Repeat(text, n)
+
+	target := line
+	pos := e.buffer.LineToChar(line)
+	if after {
+		target = line + 1
+		if target < e.buffer.LineCount() {
+			pos = e.buffer.LineToChar(target)
+		} else {
+			// Pasting below the last line, which has no newline to hang the text on
+			pos = e.buffer.Length()
+			text = "\n" + strings.TrimSuffix(text, "\n")
+		}
+	}
+
+	e.insertText(pos, text)
+	if lines := strings.Count(text, "\n"); lines > 2 {
+		e.SetMessage(fmt.Sprintf("%d more lines", lines))
+	}
+	e.moveToFirstNonBlank(target)
+}
+
+// pasteBlock puts every block row at the same column on consecutive lines, padding short lines with spaces.
+func (e *Editor) pasteBlock(text string, n int, after bool) {
+	line, col := e.GetLineColumn()
+	if after && col < e.buffer.LineLength(line) {
+		col++
+	}
+
+	for i, row := range strings.Split(text, "\n") {
+		target := line + i
+		if target >= e.buffer.LineCount() {
+			e.insertText(e.buffer.Length(), "\n")
+		}
+
+		row = strings.Repeat(row, n)
+		lineLen := e.buffer.LineLength(target)
+		if lineLen < col {
+			row = strings.Repeat(" ", col-lineLen) + row
+		}
+		e.insertText(e.buffer.LineToChar(target)+min(col, lineLen), row)
+	}
+	_ = e.cursor.MoveToPosition(line, col)
+}
+
+// ListRegisters formats the register contents the way ":registers" shows them, only the registers in names
+// when it isn't empty.
+func (e *Editor) ListRegisters(names string) []string {
+	lines := []string{"Type Name Content"}
+	for _, entry := range e.registers.List(names) {
+		content := strings.NewReplacer("\n", "^J", "\t", "^I").Replace(entry.Text)
+		lines = append(lines, fmt.Sprintf("  %s  \"%c   %s", entry.Kind, entry.Name, content))
+	}
+	return lines
+}
+
+// lineSpan returns the char range covering n lines from the cursor line, clamped to the buffer, and how many lines it spans.
+func (e *Editor) lineSpan(n int) (int, int, int) {
+	line, _ := e.GetLineColumn()
+	return e.lineRange(line, min(line+n, e.buffer.LineCount())-1)
+}
+
+// lineRange returns the char range covering lines first..last, including the newline of the last one.
+func (e *Editor) lineRange(first, last int) (int, int, int) {
+	start := e.buffer.LineToChar(first)
+	end := e.buffer.Length()
+	if last+1 < e.buffer.LineCount() {
+		end = e.buffer.LineToChar(last + 1)
+	}
+	return start, end, last - first + 1
+}
+
+func (e *Editor) moveToFirstNonBlank(line int) {
+	_ = e.cursor.SetPosition(e.buffer.LineToChar(line))
+	e.cursor.MoveToFirstNonBlank()
+}
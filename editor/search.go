@@ -0,0 +1,532 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"unicode"
+
+	"github.com/ogzhanolguncu/go_editor/history"
+	"github.com/ogzhanolguncu/go_editor/search"
+)
+
+// ### SEARCH
+
+// Match is a search hit in char positions, End is exclusive.
+type Match struct {
+	Start int
+	End   int
+}
+
+// searchTimeout bounds how long a jump among replayed keys waits for its search, a slow pattern on a huge file
+// gives up instead of freezing the screen. A typed jump doesn't wait, it's made when Poll finds the search done.
+const searchTimeout = time.Second
+
+type searchState struct {
+	forward   bool // Direction of the last "/" or "?", "n" follows it and "N" goes the other way
+	exactWord bool // Last search came from "*" or "#", which ignore smartcase
+	highlight bool // Matches stay highlighted until ":nohlsearch"
+	origin    int  // Cursor when "/" was typed, Esc goes back there
+	count     int  // Count typed before "/"
+	preview   Match
+	previewOK bool
+	// previewPending is set while the preview waits for the search of what's typed
+	previewPending bool
+
+	// Matches of one pattern, valid until the buffer changes
+	cacheBuffer   *Buffer
+	cachePattern  string
+	cacheOptions  search.Options
+	cacheRevision int
+	cache         []Match
+
+	compiled    *search.Pattern // Last pattern compiled, for compiledKey
+	compiledKey string
+
+	job  *searchJob  // Search running in the background, nil when none
+	jump *searchJump // Jump waiting for job, nil when none
+}
+
+// searchJump is a typed "/", "n" or "*" whose matches weren't known yet. Poll makes it once they are, unless the
+// cursor moved or the buffer changed in the meantime.
+type searchJump struct {
+	job     *searchJob
+	pattern string
+	forward bool
+	n       int
+	from    int    // Cursor when it was asked for
+	message string // Shown while it waits
+}
+
+// searchJob finds every match of a pattern in a snapshot of a buffer, off the event loop.
+type searchJob struct {
+	buffer   *Buffer
+	pattern  string
+	opts     search.Options
+	revision int
+	cancel   context.CancelFunc
+	done     chan struct{} // Closed once matches and err are set
+	matches  []Match
+	err      error
+}
+
+// StartSearch opens the "/" (or "?") prompt. Matches are previewed while typing.
+func (e *Editor) StartSearch(forward bool) {
+	count := e.GetCountAndClear()
+	prompt := '/'
+	if !forward {
+		prompt = '?'
+	}
+	e.StartCommandLine(prompt)
+	e.search.origin = e.cursor.GetPosition()
+	e.search.count = count
+	e.search.previewOK = false
+}
+
+func (e *Editor) isSearching() bool {
+	return e.GetMode() == ModeCommand && (e.cmdline.prompt == '/' || e.cmdline.prompt == '?')
+}
+
+// updateSearchPreview moves the cursor to the first match of what's typed so far, or back to where it started.
+// The search runs in the background, the next key cancels it when it changes the pattern.
+func (e *Editor) updateSearchPreview() {
+	if !e.isSearching() || !e.options.IncSearch {
+		return
+	}
+	e.search.previewOK, e.search.previewPending = false, false
+	_ = e.cursor.SetPosition(e.search.origin)
+
+	pattern := string(e.cmdline.text)
+	if pattern == "" {
+		e.stopSearchJob()
+		return
+	}
+	e.search.exactWord = false
+	if _, ready, err := e.matchesReady(pattern); err != nil || !ready {
+		e.search.previewPending = err == nil
+		return
+	}
+	m, _, err := e.findMatch(pattern, e.search.origin, e.cmdline.prompt == '/', e.search.count)
+	if err != nil {
+		return
+	}
+	e.search.preview, e.search.previewOK = m, true
+	_ = e.cursor.SetPosition(m.Start)
+}
+
+// GetSearchPreview returns the match shown while a search is being typed.
+func (e *Editor) GetSearchPreview() (Match, bool) {
+	if !e.isSearching() {
+		return Match{}, false
+	}
+	return e.search.preview, e.search.previewOK
+}
+
+func (e *Editor) cancelSearch() {
+	e.stopSearchJob()
+	e.search.previewOK, e.search.previewPending = false, false
+	_ = e.cursor.SetPosition(e.search.origin)
+}
+
+// submitSearch runs a typed "/" or "?" search. An empty pattern reuses the last one.
+func (e *Editor) submitSearch(pattern string, forward bool) error {
+	e.search.previewOK, e.search.previewPending = false, false
+	_ = e.cursor.SetPosition(e.search.origin)
+	if pattern == "" {
+		pattern = e.lastSearch
+	}
+	if pattern == "" {
+		return errors.New("E35: No previous regular expression")
+	}
+	e.lastSearch = pattern
+	e.search.forward = forward
+	e.search.exactWord = false
+	return e.jumpToMatch(pattern, forward, max(1, e.search.count))
+}
+
+// SearchNext repeats the last search, "n" in its direction and "N" (reverse) the other way.
+func (e *Editor) SearchNext(reverse bool) bool {
+	n := e.GetCountAndClear()
+	if e.lastSearch == "" {
+		e.SetMessage("E35: No previous regular expression")
+		return e.fail()
+	}
+	if err := e.jumpToMatch(e.lastSearch, e.search.forward != reverse, n); err != nil {
+		e.SetMessage(err.Error())
+		return e.fail()
+	}
+	return true
+}
+
+// SearchWord searches for the whole keyword under the cursor, forward for "*" and backward for "#".
+func (e *Editor) SearchWord(forward bool) bool {
+	n := e.GetCountAndClear()
+	start, end, ok := e.wordUnderCursor()
+	if !ok {
+		e.SetMessage("E348: No string under cursor")
+		return e.fail()
+	}
+
+	pattern := `\<` + e.buffer.Substring(start, end) + `\>` // Keywords have no special chars to escape
+	e.lastSearch = pattern
+	e.cmdHistory.Add(history.Search, pattern)
+	e.search.forward = forward
+	e.search.exactWord = true
+	// Start on the word itself so "#" skips it instead of landing on its own start
+	_ = e.cursor.SetPosition(start)
+	if err := e.jumpToMatch(pattern, forward, n); err != nil {
+		e.SetMessage(err.Error())
+		return e.fail()
+	}
+	return true
+}
+
+// wordUnderCursor finds the keyword at or after the cursor on the current line, like Vim's "*".
+func (e *Editor) wordUnderCursor() (int, int, bool) {
+	line, col := e.GetLineColumn()
+	lineStart := e.buffer.LineToChar(line)
+	runes := []rune(trimNewline(e.buffer.Line(line)))
+
+	for col < len(runes) && !isKeyword(runes[col]) {
+		col++
+	}
+	if col >= len(runes) {
+		return 0, 0, false
+	}
+	start, end := col, col
+	for start > 0 && isKeyword(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && isKeyword(runes[end]) {
+		end++
+	}
+	return lineStart + start, lineStart + end, true
+}
+
+func isKeyword(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// jumpToMatch moves the cursor n matches away, wrapping around the buffer with a message like Vim's. Typed keys
+// don't wait for a search that runs, the jump is left for Poll. Replayed keys do, what follows depends on it, and
+// so does everything without a Poll to leave it for.
+func (e *Editor) jumpToMatch(pattern string, forward bool, n int) error {
+	e.search.jump = nil
+	_, ready, err := e.matchesReady(pattern)
+	if err != nil {
+		return err
+	}
+	if !ready && e.keyReplay == 0 && e.redraw != nil {
+		jump := &searchJump{job: e.search.job, pattern: pattern, forward: forward, n: n, from: e.cursor.GetPosition()}
+		jump.message = fmt.Sprintf("Searching for %s...", pattern)
+		e.search.jump = jump
+		e.SetMessage(jump.message)
+		return nil
+	}
+
+	m, wrapped, err := e.findMatch(pattern, e.cursor.GetPosition(), forward, n)
+	if err != nil {
+		return err
+	}
+	e.search.highlight = true
+	e.pushJump()
+	_ = e.cursor.SetPosition(m.Start)
+
+	switch {
+	case wrapped && forward:
+		e.SetMessage("search hit BOTTOM, continuing at TOP")
+	case wrapped:
+		e.SetMessage("search hit TOP, continuing at BOTTOM")
+	}
+	return nil
+}
+
+// BeginKeyReplay marks the keys until EndKeyReplay as replayed by a macro or ":normal". Searches among them wait
+// for their matches.
+func (e *Editor) BeginKeyReplay() {
+	e.keyReplay++
+}
+
+func (e *Editor) EndKeyReplay() {
+	e.keyReplay--
+}
+
+// finishJump makes the jump that waited for job, when nothing moved on since it was asked for.
+func (e *Editor) finishJump(job *searchJob, err error) {
+	jump := e.search.jump
+	if jump == nil || jump.job != job {
+		return
+	}
+	e.dropSearchJump()
+	switch {
+	case err != nil:
+		e.SetMessage(err.Error())
+	case job.buffer == e.Buffer && job.revision == e.revision && e.cursor.GetPosition() == jump.from:
+		if err := e.jumpToMatch(jump.pattern, jump.forward, jump.n); err != nil {
+			e.SetMessage(err.Error())
+		}
+	}
+}
+
+// dropSearchJump forgets the jump waiting for a search, and its message.
+func (e *Editor) dropSearchJump() {
+	if jump := e.search.jump; jump != nil {
+		e.search.jump = nil
+		if e.message == jump.message {
+			e.SetMessage("")
+		}
+	}
+}
+
+// SearchPending reports whether a jump waits for its search. Keys typed meanwhile should wait for it too.
+func (e *Editor) SearchPending() bool {
+	return e.search.jump != nil
+}
+
+// StopSearch gives up on a jump waiting for its search, and the search. Returns false when there was none.
+func (e *Editor) StopSearch() bool {
+	if e.search.jump == nil {
+		return false
+	}
+	e.stopSearchJob()
+	return true
+}
+
+// findMatch finds the nth match starting after (or before) from. Matches at from itself are skipped.
+func (e *Editor) findMatch(pattern string, from int, forward bool, n int) (Match, bool, error) {
+	matches, err := e.searchMatches(pattern)
+	if err != nil {
+		return Match{}, false, err
+	}
+	if len(matches) == 0 {
+		return Match{}, false, fmt.Errorf("E486: Pattern not found: %s", pattern)
+	}
+
+	// i is the first match after from going forward, going backward it's the last one before
+	i := sort.Search(len(matches), func(i int) bool { return matches[i].Start > from })
+	if !forward {
+		i = sort.Search(len(matches), func(i int) bool { return matches[i].Start >= from }) - 1
+	}
+
+	wrapped := false
+	for step := 1; step < max(1, n); step++ {
+		if forward {
+			i++
+		} else {
+			i--
+		}
+	}
+	if i >= len(matches) || i < 0 {
+		switch {
+		case e.options.WrapScan:
+		case forward:
+			return Match{}, false, fmt.Errorf("E385: Search hit BOTTOM without match for: %s", pattern)
+		default:
+			return Match{}, false, fmt.Errorf("E384: Search hit TOP without match for: %s", pattern)
+		}
+		wrapped = true
+		i = ((i % len(matches)) + len(matches)) % len(matches)
+	}
+	return matches[i], wrapped, nil
+}
+
+// searchMatches returns every match of pattern in the buffer, sorted by position. It waits for the background
+// search at most searchTimeout.
+func (e *Editor) searchMatches(pattern string) ([]Match, error) {
+	matches, ready, err := e.matchesReady(pattern)
+	if err != nil || ready {
+		return matches, err
+	}
+	job := e.search.job
+	select {
+	case <-job.done:
+		return e.takeSearchJob(job)
+	case <-time.After(searchTimeout):
+		e.stopSearchJob()
+		return nil, fmt.Errorf("Search timed out: %s", pattern)
+	}
+}
+
+// matchesReady returns the matches of pattern when they're known. Otherwise it makes sure a background search
+// for them runs, cancelling one for anything else, and Poll picks them up.
+func (e *Editor) matchesReady(pattern string) ([]Match, bool, error) {
+	s := &e.search
+	opts := e.searchOptions()
+	if s.cache != nil && s.cacheBuffer == e.Buffer && s.cachePattern == pattern && s.cacheOptions == opts && s.cacheRevision == e.revision {
+		return s.cache, true, nil
+	}
+	if job := s.job; job != nil && job.buffer == e.Buffer && job.pattern == pattern && job.opts == opts && job.revision == e.revision {
+		return nil, false, nil
+	}
+
+	p, err := e.compileSearch(pattern, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	e.stopSearchJob()
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &searchJob{buffer: e.Buffer, pattern: pattern, opts: opts, revision: e.revision, cancel: cancel, done: make(chan struct{})}
+	s.job = job
+	text, redraw := e.buffer.Snapshot(), e.redraw
+	go func() {
+		ranges, err := p.All(ctx, text, 0, text.Length())
+		job.matches = make([]Match, len(ranges))
+		for i, r := range ranges {
+			job.matches[i] = Match{Start: r.Start, End: r.End}
+		}
+		job.err = err
+		close(job.done)
+		if ctx.Err() == nil && redraw != nil {
+			redraw()
+		}
+	}()
+	return nil, false, nil
+}
+
+// takeSearchJob ends a finished search and caches its matches.
+func (e *Editor) takeSearchJob(job *searchJob) ([]Match, error) {
+	if e.search.job == job {
+		e.search.job = nil
+	}
+	job.cancel()
+	if job.err != nil {
+		return nil, job.err
+	}
+	s := &e.search
+	s.cacheBuffer, s.cachePattern, s.cacheOptions, s.cacheRevision, s.cache = job.buffer, job.pattern, job.opts, job.revision, job.matches
+	return job.matches, nil
+}
+
+// stopSearchJob cancels the background search, if any, and the jump waiting for it.
+func (e *Editor) stopSearchJob() {
+	if job := e.search.job; job != nil {
+		job.cancel()
+		e.search.job = nil
+		if e.search.jump != nil && e.search.jump.job == job {
+			e.dropSearchJump()
+		}
+	}
+}
+
+// pollSearch picks up a finished background search, and shows the preview that waited for it.
+func (e *Editor) pollSearch() {
+	job := e.search.job
+	if job == nil {
+		return
+	}
+	select {
+	case <-job.done:
+	default:
+		return
+	}
+	_, err := e.takeSearchJob(job)
+	if err == nil && e.search.previewPending {
+		e.updateSearchPreview()
+	}
+	e.finishJump(job, err)
+}
+
+// compileSearch compiles a pattern, reusing the last one when nothing changed. Drawing asks for it on every line.
+func (e *Editor) compileSearch(pattern string, opts search.Options) (*search.Pattern, error) {
+	s := &e.search
+	if s.compiled != nil && s.compiledKey == indexKey(pattern, opts) {
+		return s.compiled, nil
+	}
+	p, err := search.NewPattern(pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.compiled, s.compiledKey = p, indexKey(pattern, opts)
+	return p, nil
+}
+
+func indexKey(pattern string, opts search.Options) string {
+	return fmt.Sprintf("%v %s", opts, pattern)
+}
+
+func (e *Editor) searchOptions() search.Options {
+	return search.Options{
+		IgnoreCase: e.options.IgnoreCase,
+		SmartCase:  e.options.SmartCase && !e.search.exactWord,
+	}
+}
+
+// ignoreCase applies ignorecase and smartcase to patterns outside "/", like ":s" and ":g".
+func (e *Editor) ignoreCase(pattern string) bool {
+	return search.IgnoreCase(pattern, search.Options{IgnoreCase: e.options.IgnoreCase, SmartCase: e.options.SmartCase})
+}
+
+// MatchesInLines returns the highlighted matches touching lines first..last. Single line patterns only search
+// those lines, through the index, so drawing after an edit doesn't go over the whole buffer.
+func (e *Editor) MatchesInLines(first, last int) []Match {
+	if !e.options.HLSearch || !e.search.highlight || e.lastSearch == "" {
+		return nil
+	}
+	opts := e.searchOptions()
+	p, err := e.compileSearch(e.lastSearch, opts)
+	if err != nil {
+		return nil
+	}
+	if p.Multiline() {
+		return e.multilineMatchesInLines(first, last)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), searchTimeout)
+	defer cancel()
+	e.matchIndex.Use(indexKey(e.lastSearch, opts), p)
+	var matches []Match
+	for line := max(first, 0); line <= last && line < e.buffer.LineCount(); line++ {
+		ranges, err := e.matchIndex.Line(ctx, e.buffer, line)
+		if err != nil {
+			return nil
+		}
+		for _, r := range ranges {
+			matches = append(matches, Match{Start: r.Start, End: r.End})
+		}
+	}
+	return matches
+}
+
+// multilineMatchesInLines picks the matches touching lines first..last out of all of them, a match can start
+// lines above the first one. Nothing is highlighted while they're being searched for.
+func (e *Editor) multilineMatchesInLines(first, last int) []Match {
+	matches, ready, err := e.matchesReady(e.lastSearch)
+	if err != nil || !ready {
+		return nil
+	}
+
+	start := e.buffer.LineToChar(first)
+	end := e.buffer.Length()
+	if last+1 < e.buffer.LineCount() {
+		end = e.buffer.LineToChar(last + 1)
+	}
+	i := sort.Search(len(matches), func(i int) bool { return matches[i].End > start || matches[i].Start >= start })
+	j := sort.Search(len(matches), func(i int) bool { return matches[i].Start >= end })
+	if i >= j {
+		return nil
+	}
+	return matches[i:j]
+}
+
+// SearchCount reports which match the cursor is on (or past) and how many there are, for "[3/17]". There's no
+// count until the search after an edit is done.
+func (e *Editor) SearchCount() (int, int, bool) {
+	if !e.search.highlight || e.lastSearch == "" {
+		return 0, 0, false
+	}
+	matches, ready, err := e.matchesReady(e.lastSearch)
+	if err != nil || !ready || len(matches) == 0 {
+		return 0, 0, false
+	}
+	pos := e.cursor.GetPosition()
+	index := sort.Search(len(matches), func(i int) bool { return matches[i].Start > pos })
+	// Before the first match the next one is the first
+	return max(index, 1), len(matches), true
+}
+
+// NoHighlight hides match highlighting until the next search.
+func (e *Editor) NoHighlight() {
+	e.search.highlight = false
+}
@@ -0,0 +1,131 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// withRedraw gives e a redraw that reports on the returned channel, like the screen's event queue. Redraws asked
+// for while one waits are folded into it.
+func withRedraw(e *Editor) chan struct{} {
+	redraws := make(chan struct{}, 1)
+	e.SetRedraw(func() {
+		select {
+		case redraws <- struct{}{}:
+		default:
+		}
+	})
+	return redraws
+}
+
+// waitPoll waits for background work to ask for a redraw, then polls like the screen does.
+func waitPoll(t *testing.T, e *Editor, redraws chan struct{}) {
+	t.Helper()
+	select {
+	case <-redraws:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no redraw")
+	}
+	e.Poll()
+}
+
+func TestSearchJumpWaitsForPoll(t *testing.T) {
+	e := newTestEditor(t, "a foo\nb\nfoo c")
+	redraws := withRedraw(e)
+
+	require.NoError(t, e.submitSearch("foo", true))
+	require.Equal(t, 0, e.cursor.GetPosition(), "no jump before the matches are in")
+	require.True(t, e.SearchPending())
+	require.Equal(t, "search", e.Running())
+	require.Equal(t, "Searching for foo...", e.GetMessage())
+
+	waitPoll(t, e, redraws)
+	require.Equal(t, 2, e.cursor.GetPosition())
+	require.False(t, e.SearchPending())
+	require.Empty(t, e.GetMessage())
+
+	// The matches are known now, "n" jumps at once
+	require.True(t, e.SearchNext(false))
+	require.Equal(t, 8, e.cursor.GetPosition())
+}
+
+func TestSearchJumpDropped(t *testing.T) {
+	t.Run("cursor moved", func(t *testing.T) {
+		e := newTestEditor(t, "a foo\nb\nfoo c")
+		redraws := withRedraw(e)
+		require.NoError(t, e.submitSearch("c", true))
+		e.MoveRight()
+		waitPoll(t, e, redraws)
+		require.Equal(t, 1, e.cursor.GetPosition())
+		require.False(t, e.SearchPending())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		e := newTestEditor(t, "a foo")
+		redraws := withRedraw(e)
+		require.NoError(t, e.submitSearch("zzz", true))
+		waitPoll(t, e, redraws)
+		require.Equal(t, "E486: Pattern not found: zzz", e.GetMessage())
+		require.False(t, e.SearchPending())
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		e := newTestEditor(t, "a foo")
+		withRedraw(e)
+		require.NoError(t, e.submitSearch("foo", true))
+		require.True(t, e.StopJobs())
+		require.False(t, e.SearchPending())
+		require.Empty(t, e.Running())
+		e.Poll()
+		require.Equal(t, 0, e.cursor.GetPosition())
+	})
+}
+
+func TestSearchReplayedKeysWait(t *testing.T) {
+	e := newTestEditor(t, "a foo")
+	withRedraw(e)
+	e.BeginKeyReplay()
+	require.NoError(t, e.submitSearch("foo", true))
+	e.EndKeyReplay()
+	require.Equal(t, 2, e.cursor.GetPosition())
+	require.False(t, e.SearchPending())
+}
+
+func TestSearchCount(t *testing.T) {
+	e := newTestEditor(t, "x foo foo")
+	require.NoError(t, e.submitSearch("foo", true))
+	count := func() []int {
+		index, total, ok := e.SearchCount()
+		require.True(t, ok)
+		return []int{index, total}
+	}
+	require.Equal(t, []int{1, 2}, count())
+
+	// Before the first match the next one counts
+	require.NoError(t, e.cursor.SetPosition(0))
+	require.Equal(t, []int{1, 2}, count())
+
+	require.True(t, e.SearchNext(false))
+	require.True(t, e.SearchNext(false))
+	require.Equal(t, 6, e.cursor.GetPosition())
+	require.Equal(t, []int{2, 2}, count())
+}
+
+func TestMatchesInLinesSearchesVisibleLines(t *testing.T) {
+	e := newTestEditor(t, strings.Repeat("a foo\n", 1000))
+	e.options.HLSearch = true
+	require.NoError(t, e.submitSearch("foo", true))
+	e.search.cache, e.search.job = nil, nil
+
+	require.Equal(t, []Match{{62, 65}, {68, 71}}, e.MatchesInLines(10, 11))
+	require.Nil(t, e.search.job, "no search of the whole buffer")
+	require.Nil(t, e.search.cache)
+
+	// An edit elsewhere doesn't either
+	require.NoError(t, e.ExecuteCommand("500d"))
+	require.Equal(t, []Match{{62, 65}, {68, 71}}, e.MatchesInLines(10, 11))
+	require.Nil(t, e.search.job)
+}
@@ -7,11 +7,16 @@ type Mode int
 const (
 	ModeNormal Mode = iota
 	ModeInsert
+	ModeCommand
+	ModeConfirm // Waiting for y/n/a/q/l during ":s///c"
 )
 
 type VimState struct {
 	mode         Mode
 	commandCount string
+	motionCount  string // Count typed after an operator, "3" of "d3w", multiplies commandCount
+	register     rune   // Register selected with '"x', consumed by the next yank/delete/paste
+	pending      string // Keys of an unfinished multi key command e.g. "d" of "dd", "dg" of "dgg" or `"` of `"a`
 }
 
 func NewVimState() *VimState {
@@ -35,15 +40,42 @@ func (v *VimState) HandleDigit(r rune) bool {
 	return false
 }
 
+// HandleMotionDigit takes a digit typed after an operator. Like HandleDigit, '0' only continues a count.
+func (v *VimState) HandleMotionDigit(r rune) bool {
+	if (r >= '1' && r <= '9') || (r == '0' && v.motionCount != "") {
+		v.motionCount += string(r)
+		return true
+	}
+	return false
+}
+
+// GetCountAndClear returns the command's count, 1 when none was typed. Counts before and after an operator
+// multiply, "2d3w" deletes 6 words.
 func (v *VimState) GetCountAndClear() int {
-	if v.commandCount == "" {
+	n := parseCount(v.commandCount) * parseCount(v.motionCount)
+	v.ClearCount()
+	return n
+}
+
+func parseCount(count string) int {
+	if count == "" {
 		return 1
 	}
-	n, _ := strconv.Atoi(v.commandCount)
-	v.commandCount = ""
+	n, _ := strconv.Atoi(count)
 	return n
 }
 
+func (v *VimState) HasCount() bool {
+	return v.commandCount != "" || v.motionCount != ""
+}
+
 func (v *VimState) ClearCount() {
 	v.commandCount = ""
+	v.motionCount = ""
+}
+
+func (v *VimState) GetRegisterAndClear() rune {
+	r := v.register
+	v.register = 0
+	return r
 }
@@ -0,0 +1,270 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ogzhanolguncu/go_editor/ex"
+	"github.com/ogzhanolguncu/go_editor/search"
+)
+
+// ### SUBSTITUTE
+
+// substituteSession walks the matches of one ":s" run. Without the c flag it runs to the end right away,
+// with it the session waits in ModeConfirm for y/n/a/q/l on each match.
+type substituteSession struct {
+	re          *search.Regexp
+	replacement string
+	global      bool
+	countOnly   bool
+
+	line     int // Line being searched, 0-based
+	lastLine int // Last line of the range, moves when replacements add or remove lines
+	col      int // Rune column in line to continue searching from
+
+	// Match waiting for an answer, absolute char positions
+	matchStart int
+	matchEnd   int
+	groups     []string
+
+	count       int
+	lines       int
+	lastChanged int // Last line that had a match, for counting lines and placing the cursor
+}
+
+func (e *Editor) substitute(args ExArgs) error {
+	sub, err := ex.ParseSubstitute(args.Args)
+	if err != nil {
+		return err
+	}
+
+	if sub.Repeat {
+		if e.lastSubstitute == nil {
+			return fmt.Errorf("E35: No previous regular expression")
+		}
+		prev := *e.lastSubstitute
+		prev.Global, prev.Confirm, prev.CountOnly = sub.Global, sub.Confirm, sub.CountOnly
+		prev.IgnoreCase, prev.MatchCase, prev.NoError, prev.Count = sub.IgnoreCase, sub.MatchCase, sub.NoError, sub.Count
+		sub = prev
+	}
+	if sub.Pattern == "" {
+		if e.lastSearch == "" {
+			return fmt.Errorf("E35: No previous regular expression")
+		}
+		sub.Pattern = e.lastSearch
+	}
+
+	re, err := search.Compile(sub.Pattern, (sub.IgnoreCase || e.ignoreCase(sub.Pattern)) && !sub.MatchCase)
+	if err != nil {
+		return err
+	}
+	e.lastSubstitute = &sub
+	e.lastSearch = sub.Pattern
+
+	first, last := args.Line1, args.Line2
+	if sub.Count > 0 {
+		first = last
+		last = min(last+sub.Count-1, e.buffer.LineCount()-1)
+	}
+
+	sess := &substituteSession{
+		re:          re,
+		replacement: sub.Replacement,
+		global:      sub.Global,
+		countOnly:   sub.CountOnly,
+		line:        first,
+		lastLine:    last,
+		lastChanged: -1,
+	}
+
+	if !sess.countOnly {
+		e.beginChange()
+	}
+	if !e.nextMatch(sess) {
+		e.finishSubstitute(sess)
+		if sub.NoError {
+			return nil
+		}
+		return fmt.Errorf("E486: Pattern not found: %s", sub.Pattern)
+	}
+
+	if sub.Confirm && !sub.CountOnly {
+		e.substitution = sess
+		_ = e.cursor.SetPosition(sess.matchStart)
+		e.SetMode(ModeConfirm)
+		return nil
+	}
+
+	for {
+		e.acceptMatch(sess, !sess.countOnly)
+		if !e.nextMatch(sess) {
+			break
+		}
+	}
+	e.finishSubstitute(sess)
+	return nil
+}
+
+// nextMatch finds the next match from the session position, it returns false when the range is done.
+func (e *Editor) nextMatch(sess *substituteSession) bool {
+	for sess.line <= sess.lastLine && sess.line < e.buffer.LineCount() {
+		lineStart := e.buffer.LineToChar(sess.line)
+		text := trimNewline(e.buffer.Line(sess.line))
+		byteCol := runeToByte(text, sess.col)
+		if sess.col > utf8.RuneCountInString(text) {
+			byteCol = len(text) + 1
+		}
+
+		// Search the whole line so "^" only matches at its real start
+		for _, loc := range sess.re.FindAllStringSubmatchIndex(text, -1) {
+			if loc[0] < byteCol {
+				continue
+			}
+			sess.matchStart = lineStart + utf8.RuneCountInString(text[:loc[0]])
+			sess.matchEnd = lineStart + utf8.RuneCountInString(text[:loc[1]])
+			sess.groups = sess.groups[:0]
+			for g := 0; g+1 < len(loc); g += 2 {
+				if loc[g] < 0 {
+					sess.groups = append(sess.groups, "")
+					continue
+				}
+				sess.groups = append(sess.groups, text[loc[g]:loc[g+1]])
+			}
+			return true
+		}
+
+		sess.line++
+		sess.col = 0
+	}
+	return false
+}
+
+// acceptMatch replaces the current match (or only counts it) and moves past it.
+func (e *Editor) acceptMatch(sess *substituteSession, replace bool) {
+	if sess.lastChanged != sess.line {
+		sess.lines++
+	}
+	sess.count++
+
+	end := sess.matchEnd
+	if replace {
+		matched := e.buffer.Substring(sess.matchStart, sess.matchEnd)
+		rep := search.ExpandReplacement(sess.replacement, sess.groups)
+		e.deleteText(sess.matchStart, sess.matchEnd)
+		e.insertText(sess.matchStart, rep)
+
+		added := strings.Count(rep, "\n") - strings.Count(matched, "\n")
+		sess.lastLine += added
+		sess.line += strings.Count(rep, "\n")
+		end = sess.matchStart + utf8.RuneCountInString(rep)
+	}
+	sess.lastChanged = sess.line
+	e.skipPast(sess, end)
+}
+
+// skipPast continues the search after end. Empty matches step one char so they can't repeat forever.
+func (e *Editor) skipPast(sess *substituteSession, end int) {
+	if !sess.global {
+		sess.line++
+		sess.col = 0
+		return
+	}
+	sess.col = end - e.buffer.LineToChar(sess.line)
+	if sess.matchStart == sess.matchEnd {
+		sess.col++
+	}
+}
+
+func (e *Editor) finishSubstitute(sess *substituteSession) {
+	e.substitution = nil
+	if !sess.countOnly {
+		e.endChange()
+	}
+	if sess.count == 0 {
+		return
+	}
+
+	what := "substitution"
+	if sess.countOnly {
+		what = "match"
+	} else {
+		e.moveToFirstNonBlank(min(sess.lastChanged, e.buffer.LineCount()-1))
+	}
+	e.SetMessage(fmt.Sprintf("%s on %s", plural(sess.count, what), plural(sess.lines, "line")))
+}
+
+// ConfirmSubstitute answers the "replace with ...?" prompt: y, n, a (all), q (quit) or l (this one then quit).
+func (e *Editor) ConfirmSubstitute(answer rune) {
+	sess := e.substitution
+	if sess == nil {
+		return
+	}
+
+	switch answer {
+	case 'y', 'l':
+		e.acceptMatch(sess, true)
+		if answer == 'l' {
+			e.endConfirm(sess)
+			return
+		}
+	case 'n':
+		e.skipPast(sess, sess.matchEnd)
+	case 'a':
+		for {
+			e.acceptMatch(sess, true)
+			if !e.nextMatch(sess) {
+				break
+			}
+		}
+		e.endConfirm(sess)
+		return
+	case 'q':
+		e.endConfirm(sess)
+		return
+	default:
+		return
+	}
+
+	if !e.nextMatch(sess) {
+		e.endConfirm(sess)
+		return
+	}
+	_ = e.cursor.SetPosition(sess.matchStart)
+}
+
+func (e *Editor) endConfirm(sess *substituteSession) {
+	e.SetMode(ModeNormal)
+	e.finishSubstitute(sess)
+}
+
+// GetConfirmPrompt returns the prompt and the match being asked about while a ":s///c" runs.
+func (e *Editor) GetConfirmPrompt() (string, int, int, bool) {
+	sess := e.substitution
+	if sess == nil {
+		return "", 0, 0, false
+	}
+	rep := search.ExpandReplacement(sess.replacement, sess.groups)
+	prompt := fmt.Sprintf("replace with %s (y/n/a/q/l)?", strings.ReplaceAll(rep, "\n", "^M"))
+	return prompt, sess.matchStart, sess.matchEnd, true
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", word)
+	}
+	if strings.HasSuffix(word, "ch") {
+		return fmt.Sprintf("%d %ses", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+func runeToByte(s string, runeCol int) int {
+	for i := range s {
+		if runeCol == 0 {
+			return i
+		}
+		runeCol--
+	}
+	return len(s)
+}
@@ -0,0 +1,30 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubstituteWordBoundaries(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		command string
+		want    string
+	}{
+		{"whole words only", "darüber über\nüber alles", `%s/\<über\>/X/g`, "darüber X\nX alles"},
+		{"word end", "café cafés", `s/é\>/e/g`, "cafe cafés"},
+		{"unicode groups", "jörg müller", `s/\<\(\S\+\) \(\S\+\)\>/\2 \1/`, "müller jörg"},
+		{"global", "über\ndarüber\nüber", `g/\<über\>/d`, "darüber"},
+		{"range address", "darüber\nx\nüber\ny", `/\<über\>/d`, "darüber\nx\ny"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEditor(t, tt.text)
+			require.NoError(t, e.ExecuteCommand(tt.command))
+			require.Equal(t, tt.want, e.buffer.String())
+		})
+	}
+}
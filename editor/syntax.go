@@ -0,0 +1,63 @@
+package editor
+
+import (
+	"github.com/ogzhanolguncu/go_editor/highlighter"
+	textbuffer "github.com/ogzhanolguncu/go_editor/text_buffer"
+)
+
+// ### SYNTAX HIGHLIGHTING
+
+// SetSyntaxPost gives the editor a way to hand highlighted lines from background lexing to the event loop, which
+// passes them back to ApplySyntax. It must be safe to call from any goroutine and reports whether the update was
+// taken. Without it lines are lexed on the spot.
+func (e *Editor) SetSyntaxPost(post func(highlighter.Update) bool) {
+	e.postSyntax = post
+}
+
+// ApplySyntax stores lines a background lex highlighted. Lines edited since it started are left for the next one.
+func (e *Editor) ApplySyntax(u highlighter.Update) {
+	u.Apply()
+}
+
+// LineTokens returns the syntax tokens of a line of the current buffer, for the screen to color. Lines of files
+// with no known language have none. A line that isn't highlighted yet starts a background lex from the first
+// such line, it's drawn with its tokens from before the last edit or plain until then.
+func (e *Editor) LineTokens(line int) []highlighter.Token {
+	h := e.syntax()
+	if e.postSyntax == nil {
+		return h.TokenizeLine(line, e.buffer)
+	}
+	tokens, current := h.Tokens(line)
+	if !current {
+		h.Background(line, syntaxSource{e.buffer}, e.postSyntax)
+	}
+	return tokens
+}
+
+// syntaxSource is the text buffer as the highlighter reads it, snapshots included.
+type syntaxSource struct {
+	*textbuffer.TextBuffer
+}
+
+func (s syntaxSource) Snapshot() highlighter.Text {
+	return s.TextBuffer.Snapshot()
+}
+
+// syntax is the buffer's highlighter, picked again when the buffer got another file name.
+func (b *Buffer) syntax() *highlighter.Highlighter {
+	if b.highlighter == nil || b.highlighterName != b.filename {
+		if b.highlighter != nil {
+			b.highlighter.Stop()
+		}
+		b.highlighter = highlighter.New(b.filename)
+		b.highlighterName = b.filename
+	}
+	return b.highlighter
+}
+
+// editSyntax records that lines line..line+removed were replaced by inserted+1 new lines.
+func (b *Buffer) editSyntax(line, removed, inserted int) {
+	if b.highlighter != nil {
+		b.highlighter.Edit(line, removed, inserted)
+	}
+}
@@ -0,0 +1,203 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/ogzhanolguncu/go_editor/layout"
+	"github.com/ogzhanolguncu/go_editor/register"
+)
+
+// ### TAB PAGES
+
+// tabPage is a window layout of its own. One is on screen at a time, the others keep their windows as they were left.
+type tabPage struct {
+	layout  *layout.Tree
+	windows map[int]*Window
+	current *Window // Window that was current when the tab was left
+}
+
+var errLastTab = errors.New("E784: Cannot close last tab page")
+
+func newTabPage(w *Window) *tabPage {
+	return &tabPage{
+		layout:  layout.New(w.winID),
+		windows: map[int]*Window{w.winID: w},
+		current: w,
+	}
+}
+
+// TabInfo is what the tabline shows for a tab: the name of its current window's buffer.
+type TabInfo struct {
+	Name     string
+	Modified bool
+	Current  bool
+}
+
+// Tabs describes the tab pages in order.
+func (e *Editor) Tabs() []TabInfo {
+	tabs := make([]TabInfo, 0, len(e.tabs))
+	for _, tab := range e.tabs {
+		w := tab.current
+		if tab == e.tab {
+			w = e.Window
+		}
+		name := w.Name()
+		if w.filename != "" {
+			name = filepath.Base(w.filename)
+		}
+		tabs = append(tabs, TabInfo{Name: name, Modified: w.modified, Current: tab == e.tab})
+	}
+	return tabs
+}
+
+// switchTab puts tab on screen with the window that was current in it.
+func (e *Editor) switchTab(tab *tabPage) bool {
+	if e.cmdWindow != nil {
+		e.SetMessage(errCmdWindow.Error())
+		return e.fail()
+	}
+	e.tab.current = e.Window
+	e.tab = tab
+	e.Window = tab.current
+	e.registers.SetReadOnly(register.FileName, e.filename)
+	return true
+}
+
+// NewTab opens a tab page after the current one (":tabnew"), editing path or an empty buffer.
+func (e *Editor) NewTab(path string) error {
+	if e.cmdWindow != nil {
+		return errCmdWindow
+	}
+	b, err := newBuffer("")
+	if err != nil {
+		return err
+	}
+	e.buffers.Add(b)
+	w := newWindow(e.nextWinID, b)
+	e.nextWinID++
+
+	tab := newTabPage(w)
+	i := slices.Index(e.tabs, e.tab)
+	e.tabs = slices.Insert(e.tabs, i+1, tab)
+	alternate := e.Buffer
+	e.switchTab(tab)
+	e.buffers.alternate = alternate
+
+	if path != "" {
+		return e.OpenFile(path)
+	}
+	return nil
+}
+
+// CloseTab closes the current tab page (":tabclose"), or tab {arg} when a number is given. Its buffers stay loaded.
+func (e *Editor) CloseTab(arg string) error {
+	if e.cmdWindow != nil {
+		return errCmdWindow
+	}
+	tab := e.tab
+	if arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > len(e.tabs) {
+			return fmt.Errorf("E475: Invalid argument: %s", arg)
+		}
+		tab = e.tabs[n-1]
+	}
+	return e.closeTab(tab)
+}
+
+func (e *Editor) closeTab(tab *tabPage) error {
+	if len(e.tabs) == 1 {
+		return errLastTab
+	}
+	i := slices.Index(e.tabs, tab)
+	if tab == e.tab {
+		// Like Vim, go to the tab on the right, or the left one when closing the last
+		next := i + 1
+		if next == len(e.tabs) {
+			next = i - 1
+		}
+		e.switchTab(e.tabs[next])
+	}
+	for _, w := range tab.windows {
+		w.Buffer.lastCursor = w.cursor.GetPosition()
+	}
+	e.tabs = slices.Delete(e.tabs, i, i+1)
+	return nil
+}
+
+// NextTabPage is "gt": the next tab, wrapping around, or tab {count} when one was typed.
+func (e *Editor) NextTabPage() bool {
+	i := slices.Index(e.tabs, e.tab)
+	if e.vimState.HasCount() {
+		n := e.GetCountAndClear()
+		if n > len(e.tabs) {
+			return e.fail()
+		}
+		return e.switchTab(e.tabs[n-1])
+	}
+	return e.switchTab(e.tabs[(i+1)%len(e.tabs)])
+}
+
+// PrevTabPage is "gT": {count} tabs back, wrapping around.
+func (e *Editor) PrevTabPage() bool {
+	return e.prevTab(e.GetCountAndClear())
+}
+
+func (e *Editor) prevTab(n int) bool {
+	i := slices.Index(e.tabs, e.tab)
+	n %= len(e.tabs)
+	return e.switchTab(e.tabs[(i-n+len(e.tabs))%len(e.tabs)])
+}
+
+// GoToTab switches to tab n, counted from 1. The tabline uses it for mouse clicks.
+func (e *Editor) GoToTab(n int) {
+	if n < 1 || n > len(e.tabs) {
+		return
+	}
+	e.switchTab(e.tabs[n-1])
+}
+
+// MoveTab moves the current tab (":tabmove"). No argument moves it last, N puts it after tab N (0 is first),
+// +N and -N move it relative to where it is.
+func (e *Editor) MoveTab(arg string) error {
+	i := slices.Index(e.tabs, e.tab)
+	to := len(e.tabs) - 1
+
+	if arg != "" {
+		// Only one sign is allowed, and a bare sign means one tab.
+		digits := arg
+		if arg[0] == '+' || arg[0] == '-' {
+			digits = arg[1:]
+		}
+		n := 1
+		if digits != "" {
+			var err error
+			if n, err = strconv.Atoi(digits); err != nil || digits[0] < '0' || digits[0] > '9' {
+				return fmt.Errorf("E475: Invalid argument: %s", arg)
+			}
+		}
+		switch arg[0] {
+		case '+':
+			to = i + n
+		case '-':
+			to = i - n
+		default:
+			to = min(n, len(e.tabs))
+			if to > i {
+				to--
+			}
+		}
+		if to < 0 || to >= len(e.tabs) {
+			return fmt.Errorf("E475: Invalid argument: %s", arg)
+		}
+	}
+
+	tab := e.tabs[i]
+	e.tabs = slices.Delete(e.tabs, i, i+1)
+	e.tabs = slices.Insert(e.tabs, to, tab)
+	return nil
+}
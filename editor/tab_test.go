@@ -0,0 +1,106 @@
+package editor
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTabs returns an editor with n tab pages, tab current (counted from 1) on screen.
+func newTabs(t *testing.T, n, current int) (*Editor, []*tabPage) {
+	t.Helper()
+	e := newTestEditor(t, "")
+	for range n - 1 {
+		require.NoError(t, e.NewTab(""))
+	}
+	e.GoToTab(current)
+	return e, slices.Clone(e.tabs)
+}
+
+// tabOrder lists the tabs by where they were when the test started, counted from 1.
+func tabOrder(e *Editor, start []*tabPage) []int {
+	var order []int
+	for _, tab := range e.tabs {
+		order = append(order, slices.Index(start, tab)+1)
+	}
+	return order
+}
+
+func TestMoveTab(t *testing.T) {
+	tests := []struct {
+		current int
+		arg     string
+		want    []int
+		err     string
+	}{
+		{current: 1, arg: "", want: []int{2, 3, 4, 1}},
+		{current: 4, arg: "", want: []int{1, 2, 3, 4}},
+		{current: 3, arg: "0", want: []int{3, 1, 2, 4}},
+		{current: 1, arg: "2", want: []int{2, 1, 3, 4}},
+		{current: 4, arg: "2", want: []int{1, 2, 4, 3}},
+		{current: 2, arg: "2", want: []int{1, 2, 3, 4}},
+		{current: 1, arg: "4", want: []int{2, 3, 4, 1}},
+		{current: 1, arg: "9", want: []int{2, 3, 4, 1}},
+		{current: 2, arg: "+", want: []int{1, 3, 2, 4}},
+		{current: 2, arg: "+2", want: []int{1, 3, 4, 2}},
+		{current: 3, arg: "-", want: []int{1, 3, 2, 4}},
+		{current: 3, arg: "-2", want: []int{3, 1, 2, 4}},
+		{current: 4, arg: "+1", err: "E475: Invalid argument: +1"},
+		{current: 2, arg: "+3", err: "E475: Invalid argument: +3"},
+		{current: 1, arg: "-1", err: "E475: Invalid argument: -1"},
+		{current: 3, arg: "-3", err: "E475: Invalid argument: -3"},
+		{current: 1, arg: "x", err: "E475: Invalid argument: x"},
+		{current: 1, arg: "++1", err: "E475: Invalid argument: ++1"},
+		{current: 3, arg: "+-1", err: "E475: Invalid argument: +-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			e, start := newTabs(t, 4, tt.current)
+			err := e.MoveTab(tt.arg)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				require.Equal(t, []int{1, 2, 3, 4}, tabOrder(e, start))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, tabOrder(e, start))
+			require.Same(t, start[tt.current-1], e.tab)
+		})
+	}
+}
+
+func TestTabPageCounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		current int
+		count   string
+		back    bool
+		want    int
+		ok      bool
+	}{
+		{name: "gt", current: 2, want: 3, ok: true},
+		{name: "gt wraps", current: 3, want: 1, ok: true},
+		{name: "2gt", current: 3, count: "2", want: 2, ok: true},
+		{name: "3gt is the last", current: 1, count: "3", want: 3, ok: true},
+		{name: "4gt is out of range", current: 2, count: "4", want: 2},
+		{name: "gT wraps", current: 1, back: true, want: 3, ok: true},
+		{name: "2gT", current: 3, count: "2", back: true, want: 1, ok: true},
+		{name: "5gT wraps around again", current: 1, count: "5", back: true, want: 2, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, start := newTabs(t, 3, tt.current)
+			typeCount(e, tt.count)
+			ok := e.NextTabPage
+			if tt.back {
+				ok = e.PrevTabPage
+			}
+			require.Equal(t, tt.ok, ok())
+			require.Same(t, start[tt.want-1], e.tab)
+			require.False(t, e.vimState.HasCount())
+		})
+	}
+}
@@ -1,14 +1,330 @@
 package editor
 
-// File Tree Sidebar
-//
-//  FileTree package: scan directory, build tree structure
-//  Toggle with Ctrl-n (or :NERDTreeToggle style)
-//  Tree rendering: indented lines, folder icons (▸/▾)
-//  Vim keys in tree: j/k navigate, Enter open file, o toggle folder
-//  Show in left panel (fixed width, e.g. 30 cols)
-//  Highlight current file in tree
-//  Basic operations: d delete, r rename, a create file
-//  File watching: refresh on external changes (use fsnotify)
-//
-//
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/filetree"
+)
+
+// ### FILE TREE
+
+// TreeWidth is the width of the file tree panel, not counting the separator next to it.
+const TreeWidth = 30
+
+// treePanel is the file tree sidebar. It stays open across tabs, keys go to it while it has focus.
+type treePanel struct {
+	tree    *filetree.Tree
+	row     int // Selected row
+	offset  int // First row drawn
+	focused bool
+	check   chan bool // Result of the look for outside changes in progress, nil when none runs
+}
+
+// TreeRow is one line of the file tree as the screen draws it.
+type TreeRow struct {
+	Name     string
+	Depth    int
+	IsDir    bool
+	Expanded bool
+	Current  bool // The file in the current window
+	Selected bool
+}
+
+// ToggleFileTree opens the file tree on the working directory and focuses it (Ctrl-N), or closes it.
+func (e *Editor) ToggleFileTree() error {
+	if e.fileTree != nil {
+		e.fileTree = nil
+		return nil
+	}
+	tree, err := filetree.New(".")
+	if err != nil {
+		return err
+	}
+	e.fileTree = &treePanel{tree: tree, focused: true}
+	if e.filename != "" {
+		if row, ok := tree.Reveal(absPath(e.filename)); ok {
+			e.fileTree.row = row
+		}
+	}
+	return nil
+}
+
+func (e *Editor) FileTreeOpen() bool {
+	return e.fileTree != nil
+}
+
+// TreeFocused reports whether keys go to the file tree rather than the current window.
+func (e *Editor) TreeFocused() bool {
+	return e.fileTree != nil && e.fileTree.focused
+}
+
+func (e *Editor) focusTree(focused bool) {
+	if e.fileTree != nil {
+		e.fileTree.focused = focused
+	}
+}
+
+// TreeView returns the rows that fit in height lines, scrolled to keep the selection visible.
+func (e *Editor) TreeView(height int) []TreeRow {
+	p := e.fileTree
+	if p == nil || height <= 0 {
+		return nil
+	}
+	nodes := p.tree.Rows()
+	p.row = max(0, min(p.row, len(nodes)-1))
+	if p.row < p.offset {
+		p.offset = p.row
+	}
+	if p.row >= p.offset+height {
+		p.offset = p.row - height + 1
+	}
+
+	current := ""
+	if e.filename != "" {
+		current = absPath(e.filename)
+	}
+	rows := make([]TreeRow, 0, height)
+	for i := p.offset; i < min(len(nodes), p.offset+height); i++ {
+		n := nodes[i]
+		rows = append(rows, TreeRow{
+			Name:     n.Name,
+			Depth:    n.Depth,
+			IsDir:    n.IsDir,
+			Expanded: n.Expanded,
+			Current:  n.Path == current,
+			Selected: i == p.row,
+		})
+	}
+	return rows
+}
+
+// TreeRoot is the name of the directory the tree shows.
+func (e *Editor) TreeRoot() string {
+	if e.fileTree == nil {
+		return ""
+	}
+	return filepath.Base(e.fileTree.tree.Root())
+}
+
+// TreeSelectedRow is the selection's row within what TreeView returned last.
+func (e *Editor) TreeSelectedRow() int {
+	if e.fileTree == nil {
+		return 0
+	}
+	return e.fileTree.row - e.fileTree.offset
+}
+
+// TreeMove moves the selection by count rows, j and k.
+func (e *Editor) TreeMove(down bool) {
+	if e.fileTree == nil {
+		return
+	}
+	n := e.GetCountAndClear()
+	if !down {
+		n = -n
+	}
+	e.fileTree.row = max(0, min(e.fileTree.row+n, len(e.fileTree.tree.Rows())-1))
+}
+
+func (e *Editor) selectedNode() (*filetree.Node, bool) {
+	if e.fileTree == nil {
+		return nil, false
+	}
+	rows := e.fileTree.tree.Rows()
+	if e.fileTree.row >= len(rows) {
+		return nil, false
+	}
+	return rows[e.fileTree.row], true
+}
+
+// TreeOpen opens or closes the selected folder, or edits the selected file in the current window (Enter, o).
+func (e *Editor) TreeOpen() {
+	n, ok := e.selectedNode()
+	if !ok {
+		return
+	}
+	if n.IsDir {
+		if err := e.fileTree.tree.Toggle(n); err != nil {
+			e.SetMessage(err.Error())
+		}
+		return
+	}
+	if e.cmdWindow != nil {
+		e.SetMessage(errCmdWindow.Error())
+		return
+	}
+	if err := e.OpenFile(relativePath(n.Path)); err != nil {
+		e.SetMessage(err.Error())
+		return
+	}
+	e.fileTree.focused = false
+}
+
+// TreeCreate asks for a name and creates it next to the selection, or inside it when a folder is selected.
+// A name ending in "/" makes a folder.
+func (e *Editor) TreeCreate() {
+	n, _ := e.selectedNode()
+	dir := e.fileTree.tree.Root()
+	if n != nil {
+		dir = n.Path
+		if !n.IsDir {
+			dir = filepath.Dir(n.Path)
+		}
+	}
+	e.Input(fmt.Sprintf("Create in %s/: ", relativePath(dir)), "", func(name string) error {
+		if name == "" {
+			return nil
+		}
+		path, err := e.fileTree.tree.Create(n, name)
+		if err != nil {
+			return err
+		}
+		e.selectTreePath(path)
+		return nil
+	})
+}
+
+// TreeRename asks for a new name for the selection. Buffers editing it follow the rename.
+func (e *Editor) TreeRename() {
+	n, ok := e.selectedNode()
+	if !ok {
+		return
+	}
+	e.Input("Rename to: ", n.Name, func(name string) error {
+		if name == "" || name == n.Name {
+			return nil
+		}
+		old := n.Path
+		path, err := e.fileTree.tree.Rename(n, name)
+		if err != nil {
+			return err
+		}
+		e.renamePaths(old, path)
+		e.selectTreePath(path)
+		return nil
+	})
+}
+
+// TreeMoveNode asks where to move the selection, relative to the tree's root. Buffers editing it follow the move.
+func (e *Editor) TreeMoveNode() {
+	n, ok := e.selectedNode()
+	if !ok {
+		return
+	}
+	rel, _ := filepath.Rel(e.fileTree.tree.Root(), n.Path)
+	e.Input("Move to: ", rel, func(dest string) error {
+		if dest == "" || dest == rel {
+			return nil
+		}
+		old := n.Path
+		path, err := e.fileTree.tree.Move(n, dest)
+		if err != nil {
+			return err
+		}
+		e.renamePaths(old, path)
+		e.selectTreePath(path)
+		return nil
+	})
+}
+
+// TreeDelete deletes the selection after a y/n confirmation. Open buffers are kept.
+func (e *Editor) TreeDelete() {
+	n, ok := e.selectedNode()
+	if !ok {
+		return
+	}
+	what := "file"
+	if n.IsDir {
+		what = "folder and everything in it"
+	}
+	e.Input(fmt.Sprintf("Delete %s %s? (y/n) ", what, relativePath(n.Path)), "", func(answer string) error {
+		if !strings.EqualFold(answer, "y") && !strings.EqualFold(answer, "yes") {
+			return nil
+		}
+		return e.fileTree.tree.Delete(n)
+	})
+}
+
+// CheckFileTree looks for files changed outside the editor. Stat-ing everything the tree has read would stall
+// the event loop, so it's done on another goroutine and Poll refreshes the tree when something changed.
+func (e *Editor) CheckFileTree() {
+	p := e.fileTree
+	if p == nil || p.check != nil {
+		return
+	}
+	stamps, redraw := p.tree.Stamps(), e.redraw
+	check := make(chan bool, 1)
+	p.check = check
+	go func() {
+		check <- stamps.Changed()
+		if redraw != nil {
+			redraw()
+		}
+	}()
+}
+
+// pollFileTree picks up a finished CheckFileTree.
+func (e *Editor) pollFileTree() {
+	p := e.fileTree
+	if p == nil || p.check == nil {
+		return
+	}
+	select {
+	case changed := <-p.check:
+		p.check = nil
+		if changed {
+			e.refreshFileTree()
+		}
+	default:
+	}
+}
+
+// refreshFileTree reads the tree again, keeping the selection.
+func (e *Editor) refreshFileTree() {
+	selected, ok := e.selectedNode()
+	if err := e.fileTree.tree.Refresh(); err != nil {
+		e.SetMessage(err.Error())
+		return
+	}
+	if ok {
+		e.selectTreePath(selected.Path)
+	}
+}
+
+func (e *Editor) selectTreePath(path string) {
+	if row, ok := e.fileTree.tree.Reveal(path); ok {
+		e.fileTree.row = row
+	}
+}
+
+// renamePaths points buffers editing old, or files under it, at their new place.
+func (e *Editor) renamePaths(old, path string) {
+	for _, b := range e.buffers.List() {
+		if b.filename == "" {
+			continue
+		}
+		abs := absPath(b.filename)
+		switch {
+		case abs == old:
+			b.filename = relativePath(path)
+		case strings.HasPrefix(abs, old+string(filepath.Separator)):
+			b.filename = relativePath(path + strings.TrimPrefix(abs, old))
+		}
+	}
+}
+
+// relativePath shortens an absolute path to one relative to the working directory when it's inside it.
+func relativePath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,49 @@
+package editor
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckFileTree(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("a.go", nil, 0o644))
+
+	e := newTestEditor(t, "")
+	checked := make(chan struct{}, 1)
+	e.SetRedraw(func() { checked <- struct{}{} })
+	require.NoError(t, e.ToggleFileTree())
+
+	names := func() []string {
+		var names []string
+		for _, row := range e.TreeView(10) {
+			names = append(names, row.Name)
+		}
+		return names
+	}
+	wait := func() {
+		select {
+		case <-checked:
+		case <-time.After(time.Second):
+			t.Fatal("no redraw after the check")
+		}
+		e.Poll()
+	}
+
+	e.CheckFileTree()
+	wait()
+	require.Equal(t, []string{"a.go"}, names())
+
+	later := time.Now().Add(2 * time.Second)
+	require.NoError(t, os.WriteFile("b.go", nil, 0o644))
+	require.NoError(t, os.Chtimes(".", later, later))
+	e.CheckFileTree()
+	// The tree only changes once the event loop polls
+	require.Equal(t, []string{"a.go"}, names())
+	wait()
+	require.Equal(t, []string{"a.go", "b.go"}, names())
+	require.Equal(t, 0, e.TreeSelectedRow(), "the selection stays on a.go")
+}
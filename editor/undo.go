@@ -0,0 +1,55 @@
+package editor
+
+const undoLimit = 1000
+
+// ### UNDO/REDO
+
+// BeginUndoGroup makes everything until EndUndoGroup a single undo step, e.g. a whole macro replay.
+func (e *Editor) BeginUndoGroup() {
+	e.beginChange()
+}
+
+func (e *Editor) EndUndoGroup() {
+	e.endChange()
+}
+
+func (e *Editor) beginChange() {
+	e.history.Begin(e.cursor.GetPosition())
+}
+
+func (e *Editor) endChange() {
+	e.history.End()
+}
+
+func (e *Editor) Undo() {
+	n := e.GetCountAndClear()
+	for range n {
+		change, ok := e.history.Undo()
+		if !ok {
+			e.SetMessage("Already at oldest change")
+			return
+		}
+		for i := len(change.Edits) - 1; i >= 0; i-- {
+			edit := change.Edits[i]
+			e.applyDelete(edit.Pos, edit.Pos+len([]rune(edit.Inserted)))
+			e.applyInsert(edit.Pos, edit.Deleted)
+		}
+		_ = e.cursor.SetPosition(min(change.Cursor, e.buffer.Length()))
+	}
+}
+
+func (e *Editor) Redo() {
+	n := e.GetCountAndClear()
+	for range n {
+		change, ok := e.history.Redo()
+		if !ok {
+			e.SetMessage("Already at newest change")
+			return
+		}
+		for _, edit := range change.Edits {
+			e.applyDelete(edit.Pos, edit.Pos+len([]rune(edit.Deleted)))
+			e.applyInsert(edit.Pos, edit.Inserted)
+		}
+		_ = e.cursor.SetPosition(min(change.Edits[0].Pos, e.buffer.Length()))
+	}
+}
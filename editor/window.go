@@ -0,0 +1,293 @@
+package editor
+
+import (
+	"errors"
+
+	cursor "github.com/ogzhanolguncu/go_editor/cursor_manager"
+	"github.com/ogzhanolguncu/go_editor/layout"
+)
+
+// ### WINDOWS
+
+// Window is a view on a buffer with its own cursor and scroll position, so two windows can show the same
+// buffer at different places. The editor embeds the current one, so e.cursor is the current window's.
+type Window struct {
+	*Buffer
+	winID    int
+	cursor   *cursor.CursorManager
+	yOffset  int       // First line drawn
+	xOffset  int       // First column drawn
+	loclist  *qfStack  // Location lists, nil until the window gets one
+	listView *listView // Set when the window is a quickfix or location list window
+}
+
+var errLastWindow = errors.New("E444: Cannot close last window")
+
+func newWindow(id int, b *Buffer) *Window {
+	w := &Window{winID: id}
+	w.show(b)
+	return w
+}
+
+// show puts b in the window, the cursor goes back to where it was when b was last left.
+func (w *Window) show(b *Buffer) {
+	if w.Buffer != nil {
+		w.Buffer.lastCursor = w.cursor.GetPosition()
+	}
+	w.Buffer = b
+	w.cursor = cursor.NewCursorManager(b.buffer)
+	_ = w.cursor.SetPosition(min(b.lastCursor, b.buffer.Length()))
+	w.yOffset, w.xOffset = 0, 0
+}
+
+// WindowView is where the screen draws a window.
+type WindowView struct {
+	ID      int
+	Rect    layout.Rect
+	Current bool
+}
+
+// Layout fits the current tab's windows into area and returns them top left to bottom right.
+func (e *Editor) Layout(area layout.Rect) []WindowView {
+	e.tab.layout.Arrange(area)
+	ids := e.tab.layout.Windows()
+	views := make([]WindowView, 0, len(ids))
+	for _, id := range ids {
+		r, _ := e.tab.layout.Rect(id)
+		views = append(views, WindowView{ID: id, Rect: r, Current: id == e.winID})
+	}
+	return views
+}
+
+// ViewWindow runs draw with window id standing in for the current one, so every getter reads that window.
+func (e *Editor) ViewWindow(id int, draw func()) {
+	w, ok := e.tab.windows[id]
+	if !ok {
+		return
+	}
+	current := e.Window
+	e.Window = w
+	defer func() { e.Window = current }()
+	draw()
+}
+
+// GetScroll and SetScroll are the current window's first drawn line and column.
+func (e *Editor) GetScroll() (int, int) {
+	return e.yOffset, e.xOffset
+}
+
+func (e *Editor) SetScroll(yOffset, xOffset int) {
+	e.yOffset, e.xOffset = yOffset, xOffset
+}
+
+// showBuffer puts b in the current window.
+func (e *Editor) showBuffer(b *Buffer) {
+	e.Window.show(b)
+}
+
+// windowsShowing returns the windows b is shown in, in every tab.
+func (e *Editor) windowsShowing(b *Buffer) []*Window {
+	var shown []*Window
+	for _, tab := range e.tabs {
+		for _, id := range tab.layout.Windows() {
+			if w := tab.windows[id]; w.Buffer == b {
+				shown = append(shown, w)
+			}
+		}
+	}
+	return shown
+}
+
+// replaceInWindows shows b wherever old was shown.
+func (e *Editor) replaceInWindows(old, b *Buffer) {
+	for _, w := range e.windowsShowing(old) {
+		w.show(b)
+	}
+}
+
+// cursorIn is the cursor position in b: the current window's, another window's showing it, or where it was left.
+func (e *Editor) cursorIn(b *Buffer) int {
+	if b == e.Buffer {
+		return e.cursor.GetPosition()
+	}
+	if shown := e.windowsShowing(b); len(shown) > 0 {
+		return shown[0].cursor.GetPosition()
+	}
+	return min(b.lastCursor, b.buffer.Length())
+}
+
+// applyToCursors shifts the cursors of every window showing the current buffer after an edit.
+func (e *Editor) applyToCursors(pos, delta int) {
+	for _, w := range e.windowsShowing(e.Buffer) {
+		w.cursor.ApplyTextChange(pos, delta)
+	}
+}
+
+// SplitWindow opens a new window above (Rows) or left of (Columns) the current one and moves into it.
+// It shows path when one is given, the current buffer otherwise.
+func (e *Editor) SplitWindow(split layout.Split, path string) error {
+	if e.cmdWindow != nil {
+		return errCmdWindow
+	}
+	w := newWindow(e.nextWinID, e.Buffer)
+	_ = w.cursor.SetPosition(e.cursor.GetPosition())
+	w.yOffset, w.xOffset = e.yOffset, e.xOffset
+	w.loclist = e.loclist.clone()
+	if err := e.tab.layout.Split(e.winID, w.winID, split); err != nil {
+		return err
+	}
+	e.nextWinID++
+	e.tab.windows[w.winID] = w
+	e.Window = w
+
+	if path != "" {
+		return e.OpenFile(path)
+	}
+	return nil
+}
+
+// CloseWindow closes the current window (":close", Ctrl-W c). The buffer stays loaded. Closing a tab's
+// last window closes the tab.
+func (e *Editor) CloseWindow() error {
+	if e.cmdWindow != nil {
+		return errCmdWindow
+	}
+	focus, ok := e.tab.layout.Close(e.winID)
+	if !ok {
+		if len(e.tabs) == 1 {
+			return errLastWindow
+		}
+		return e.closeTab(e.tab)
+	}
+	e.Buffer.lastCursor = e.cursor.GetPosition()
+	delete(e.tab.windows, e.winID)
+	e.Window = e.tab.windows[focus]
+	return nil
+}
+
+// OnlyWindow closes every other window (":only", Ctrl-W o).
+func (e *Editor) OnlyWindow() error {
+	if e.cmdWindow != nil {
+		return errCmdWindow
+	}
+	if e.tab.layout.Len() == 1 {
+		e.SetMessage("Already only one window")
+		return nil
+	}
+	for id, w := range e.tab.windows {
+		if id != e.winID {
+			w.Buffer.lastCursor = w.cursor.GetPosition()
+			delete(e.tab.windows, id)
+		}
+	}
+	e.tab.layout.Only(e.winID)
+	return nil
+}
+
+// lastWindow reports the only window of the only tab, ":q" there quits the editor instead of closing it.
+func (e *Editor) lastWindow() bool {
+	return len(e.tabs) == 1 && e.tab.layout.Len() == 1
+}
+
+// goToWindow makes window id the current one.
+func (e *Editor) goToWindow(id int) bool {
+	w, ok := e.tab.windows[id]
+	if !ok || e.cmdWindow != nil {
+		return e.fail()
+	}
+	e.Window = w
+	return true
+}
+
+// WindowCommand runs the key typed after Ctrl-W. A count moves or resizes that many times, or picks window {count} for w.
+func (e *Editor) WindowCommand(key rune) bool {
+	if e.TreeFocused() {
+		return e.treeWindowCommand(key)
+	}
+	hasCount := e.vimState.HasCount()
+	n := e.GetCountAndClear()
+
+	var err error
+	switch key {
+	case 'h', 'j', 'k', 'l':
+		dir := map[rune]layout.Direction{'h': layout.Left, 'j': layout.Down, 'k': layout.Up, 'l': layout.Right}[key]
+		id := e.winID
+		for range n {
+			next, ok := e.tab.layout.Neighbor(id, dir)
+			if !ok {
+				break
+			}
+			id = next
+		}
+		// The file tree is left of every window
+		if id == e.winID && key == 'h' && e.fileTree != nil {
+			e.focusTree(true)
+			return true
+		}
+		return e.goToWindow(id)
+	case 'w', 'W':
+		ids := e.tab.layout.Windows()
+		if hasCount {
+			return e.goToWindow(ids[min(n, len(ids))-1])
+		}
+		i := indexOfWindow(ids, e.winID)
+		if key == 'w' {
+			i++
+		} else {
+			i--
+		}
+		return e.goToWindow(ids[(i+len(ids))%len(ids)])
+	case 's', 'S':
+		err = e.SplitWindow(layout.Rows, "")
+	case 'v':
+		err = e.SplitWindow(layout.Columns, "")
+	case 'c':
+		err = e.CloseWindow()
+	case 'o':
+		err = e.OnlyWindow()
+	case 'q':
+		err = e.ExecuteCommand("quit")
+	case '=':
+		e.tab.layout.Equalize()
+	case '+', '-':
+		if key == '-' {
+			n = -n
+		}
+		e.tab.layout.Resize(e.winID, layout.Rows, n)
+	case '>', '<':
+		if key == '<' {
+			n = -n
+		}
+		e.tab.layout.Resize(e.winID, layout.Columns, n)
+	default:
+		return e.fail()
+	}
+	if err != nil {
+		e.SetMessage(err.Error())
+		return e.fail()
+	}
+	return true
+}
+
+// treeWindowCommand is Ctrl-W in the file tree: l, w and p go back to the window, c and q close the tree.
+func (e *Editor) treeWindowCommand(key rune) bool {
+	e.ClearCount()
+	switch key {
+	case 'l', 'w', 'W', 'p':
+		e.focusTree(false)
+	case 'c', 'q':
+		e.fileTree = nil
+	default:
+		return e.fail()
+	}
+	return true
+}
+
+func indexOfWindow(ids []int, id int) int {
+	for i, existing := range ids {
+		if existing == id {
+			return i
+		}
+	}
+	return 0
+}
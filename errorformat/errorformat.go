@@ -0,0 +1,204 @@
+// Package errorformat turns compiler and tool output into locations with Vim style 'errorformat' patterns,
+// like "%f:%l:%c: %m" for "main.go:12:5: undefined: foo".
+package errorformat
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Entry is one line of output. Lines no format matched keep only their Text, File is empty.
+type Entry struct {
+	File string
+	Line int // 1-based, 0 when unknown
+	Col  int // 1-based, 0 when unknown
+	Type rune
+	Text string
+}
+
+// Valid reports whether the entry points somewhere.
+func (e Entry) Valid() bool {
+	return e.File != ""
+}
+
+// Format is one compiled pattern.
+type Format struct {
+	re     *regexp.Regexp
+	fields []byte // Conversion of each capture group
+	ignore bool   // "%-G", matching lines are dropped
+}
+
+// Compile compiles one pattern. Supported items:
+//
+//	%f  file name       %l  line number      %c  column
+//	%m  message         %t  type char        %.  any char
+//	%#  previous repeated, like "*"          %%  a literal "%"
+//
+// A pattern starting with "%-G" drops the lines it matches instead of keeping them as text.
+func Compile(pattern string) (*Format, error) {
+	f := &Format{}
+	if rest, ok := strings.CutPrefix(pattern, "%-G"); ok {
+		f.ignore = true
+		pattern = rest
+	}
+
+	var re strings.Builder
+	re.WriteString("^")
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '%' {
+			re.WriteString(regexp.QuoteMeta(string(runes[i])))
+			continue
+		}
+		i++
+		if i == len(runes) {
+			return nil, fmt.Errorf("E372: Too many %%%c in format string", '%')
+		}
+		switch runes[i] {
+		case 'f':
+			re.WriteString(`(.+?)`)
+		case 'l', 'c':
+			re.WriteString(`(\d+)`)
+		case 'm':
+			re.WriteString(`(.*)`)
+		case 't':
+			re.WriteString(`(.)`)
+		case '.':
+			re.WriteString(`.`)
+		case '#':
+			re.WriteString(`*`)
+		case '%':
+			re.WriteString(`%`)
+		default:
+			return nil, fmt.Errorf("E373: Unexpected %%%c in format string", runes[i])
+		}
+		if strings.ContainsRune("flcmt", runes[i]) {
+			f.fields = append(f.fields, byte(runes[i]))
+		}
+	}
+	re.WriteString("$")
+
+	compiled, err := regexp.Compile(re.String())
+	if err != nil {
+		return nil, fmt.Errorf("E374: Invalid format string %q: %w", pattern, err)
+	}
+	f.re = compiled
+	return f, nil
+}
+
+// CompileList compiles a comma separated 'errorformat' value. "\," is a comma inside a pattern.
+func CompileList(list string) ([]*Format, error) {
+	var formats []*Format
+	for _, pattern := range splitList(list) {
+		if pattern == "" {
+			continue
+		}
+		f, err := Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, f)
+	}
+	return formats, nil
+}
+
+func splitList(list string) []string {
+	var parts []string
+	var cur strings.Builder
+	for i := 0; i < len(list); i++ {
+		switch {
+		case list[i] == '\\' && i+1 < len(list) && list[i+1] == ',':
+			cur.WriteByte(',')
+			i++
+		case list[i] == ',':
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(list[i])
+		}
+	}
+	return append(parts, cur.String())
+}
+
+// Ready made formats. Default is the generic "file:line:col: message" or "file:line: message" most tools print.
+const (
+	Default = `%f:%l:%c: %m,%f:%l: %m`
+	// Go skips the "# package" headers of go build and go vet.
+	Go = `%-G#%.%#,vet: %f:%l:%c: %m,%f:%l:%c: %m,%f:%l: %m`
+	// GCC reads the error, warning and note types of gcc and clang.
+	GCC = `%f:%l:%c: %trror: %m,%f:%l:%c: %tarning: %m,%f:%l:%c: %tote: %m,%f:%l:%c: %m,%f:%l: %m`
+)
+
+var presets = map[string]string{
+	"generic": Default,
+	"go":      Go,
+	"gcc":     GCC,
+}
+
+// Register adds a named format, or replaces one, for Preset to find.
+func Register(name, formats string) {
+	presets[name] = formats
+}
+
+// Preset returns the format registered as name.
+func Preset(name string) (string, bool) {
+	formats, ok := presets[name]
+	return formats, ok
+}
+
+// Presets returns the registered names, sorted.
+func Presets() []string {
+	return slices.Sorted(maps.Keys(presets))
+}
+
+// Parse matches every line against the formats, the first one matching wins. Empty lines are skipped and file
+// names lose the indentation tools like go test put before them.
+func Parse(output string, formats []*Format) []Entry {
+	var entries []Entry
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry, keep := parseLine(line, formats)
+		if keep {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func parseLine(line string, formats []*Format) (Entry, bool) {
+	for _, f := range formats {
+		m := f.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if f.ignore {
+			return Entry{}, false
+		}
+		entry := Entry{Text: line}
+		for i, field := range f.fields {
+			value := m[i+1]
+			switch field {
+			case 'f':
+				entry.File = strings.TrimSpace(value)
+			case 'l':
+				entry.Line, _ = strconv.Atoi(value)
+			case 'c':
+				entry.Col, _ = strconv.Atoi(value)
+			case 'm':
+				entry.Text = value
+			case 't':
+				entry.Type = unicode.ToUpper([]rune(value)[0])
+			}
+		}
+		return entry, true
+	}
+	return Entry{Text: line}, true
+}
@@ -0,0 +1,69 @@
+package errorformat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDefault(t *testing.T) {
+	formats, err := CompileList(Default)
+	require.NoError(t, err)
+
+	entries := Parse("main.go:12:5: undefined: foo\r\nlib/a.go:3: bad thing\n\nsomething else\n", formats)
+	require.Equal(t, []Entry{
+		{File: "main.go", Line: 12, Col: 5, Text: "undefined: foo"},
+		{File: "lib/a.go", Line: 3, Text: "bad thing"},
+		{Text: "something else"},
+	}, entries)
+	require.False(t, entries[2].Valid())
+}
+
+func TestParseTypeAndIgnore(t *testing.T) {
+	formats, err := CompileList(`%-G#%.%#,%f:%l:%c: %trror: %m,%f:%l:%c: %tarning: %m`)
+	require.NoError(t, err)
+
+	entries := Parse("# pkg\nx.c:1:2: error: boom\nx.c:3:4: warning: careful\n", formats)
+	require.Equal(t, []Entry{
+		{File: "x.c", Line: 1, Col: 2, Type: 'E', Text: "boom"},
+		{File: "x.c", Line: 3, Col: 4, Type: 'W', Text: "careful"},
+	}, entries)
+}
+
+func TestFileNameWithColon(t *testing.T) {
+	formats, err := CompileList(Default)
+	require.NoError(t, err)
+	entries := Parse(`C:\src\main.go:1:2: oops`, formats)
+	require.Equal(t, `C:\src\main.go`, entries[0].File)
+}
+
+func TestCompileList(t *testing.T) {
+	formats, err := CompileList(`%f\,%l: %m,%m`)
+	require.NoError(t, err)
+	require.Len(t, formats, 2)
+	require.Equal(t, []Entry{{File: "a.go", Line: 7, Text: "hi"}}, Parse("a.go,7: hi", formats))
+
+	_, err = Compile("%q")
+	require.Error(t, err)
+	_, err = Compile("trailing %")
+	require.Error(t, err)
+}
+
+func TestGoPreset(t *testing.T) {
+	formats, err := CompileList(Go)
+	require.NoError(t, err)
+
+	output := "# example.com/app\n./main.go:4:2: declared and not used: x\nvet: ./lib.go:9:1: missing return\n" +
+		"--- FAIL: TestA (0.00s)\n    a_test.go:12: got 1\n"
+	require.Equal(t, []Entry{
+		{File: "./main.go", Line: 4, Col: 2, Text: "declared and not used: x"},
+		{File: "./lib.go", Line: 9, Col: 1, Text: "missing return"},
+		{Text: "--- FAIL: TestA (0.00s)"},
+		{File: "a_test.go", Line: 12, Text: "got 1"},
+	}, Parse(output, formats))
+
+	preset, ok := Preset("go")
+	require.True(t, ok)
+	require.Equal(t, Go, preset)
+	require.Equal(t, []string{"gcc", "generic", "go"}, Presets())
+}
@@ -0,0 +1,292 @@
+// Package ex parses Ex command lines like ":'a,'b s/x/y/g" or ":10,$d" into a range, a command name,
+// a bang flag and an argument string. Resolving addresses needs the buffer, which is supplied through Resolver.
+package ex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type AddressKind int
+
+const (
+	AddrCurrent  AddressKind = iota // "." or only an offset like "+2"
+	AddrLast                        // "$"
+	AddrNumber                      // "42"
+	AddrMark                        // "'a"
+	AddrForward                     // "/pat/"
+	AddrBackward                    // "?pat?"
+)
+
+// Address is one side of a range. Line numbers are 1-based like Vim shows them.
+type Address struct {
+	Kind    AddressKind
+	Line    int
+	Mark    rune
+	Pattern string
+	Offset  int
+}
+
+// Range holds zero, one or two addresses. "%" is parsed into 1,$.
+type Range struct {
+	Addresses []Address
+	// Semicolon means the first address becomes the current line before the second one is resolved, e.g. "/a/;/b/"
+	Semicolon bool
+}
+
+func (r Range) IsEmpty() bool {
+	return len(r.Addresses) == 0
+}
+
+type Command struct {
+	Range Range
+	Name  string
+	Bang  bool
+	Args  string
+}
+
+// Resolver answers what addresses can't know on their own. All lines are 1-based.
+type Resolver interface {
+	CurrentLine() int
+	LastLine() int
+	MarkLine(mark rune) (int, error)
+	SearchLine(pattern string, from int, forward bool) (int, error)
+}
+
+// Parse splits a command line into its parts. It doesn't check that the command exists.
+func Parse(line string) (Command, error) {
+	p := &parser{src: []rune(strings.TrimLeft(line, ": \t"))}
+
+	rng, err := p.parseRange()
+	if err != nil {
+		return Command{}, err
+	}
+	p.skipSpace()
+
+	cmd := Command{Range: rng, Name: p.parseName()}
+	if p.peek() == '!' && cmd.Name != "" {
+		cmd.Bang = true
+		p.pos++
+	}
+	cmd.Args = strings.TrimLeft(string(p.src[p.pos:]), " \t")
+	return cmd, nil
+}
+
+type parser struct {
+	src []rune
+	pos int
+}
+
+func (p *parser) peek() rune {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.pos++
+	}
+}
+
+func (p *parser) parseRange() (Range, error) {
+	var rng Range
+
+	if p.peek() == '%' {
+		p.pos++
+		rng.Addresses = []Address{{Kind: AddrNumber, Line: 1}, {Kind: AddrLast}}
+		return rng, nil
+	}
+
+	first, ok, err := p.parseAddress()
+	if err != nil {
+		return rng, err
+	}
+	sep := p.peek()
+	isSep := sep == ',' || sep == ';'
+	if !ok && !isSep {
+		return rng, nil
+	}
+	if !ok {
+		// ",5" means ".,5"
+		first = Address{Kind: AddrCurrent}
+	}
+	rng.Addresses = append(rng.Addresses, first)
+	if !isSep {
+		return rng, nil
+	}
+
+	p.pos++
+	rng.Semicolon = sep == ';'
+	p.skipSpace()
+
+	second, ok, err := p.parseAddress()
+	if err != nil {
+		return rng, err
+	}
+	if !ok {
+		// "5," means "5,."
+		second = Address{Kind: AddrCurrent}
+	}
+	rng.Addresses = append(rng.Addresses, second)
+	return rng, nil
+}
+
+func (p *parser) parseAddress() (Address, bool, error) {
+	var addr Address
+	found := true
+
+	switch ch := p.peek(); {
+	case ch == '.':
+		p.pos++
+		addr.Kind = AddrCurrent
+	case ch == '$':
+		p.pos++
+		addr.Kind = AddrLast
+	case unicode.IsDigit(ch):
+		addr.Kind = AddrNumber
+		addr.Line = p.parseNumber()
+	case ch == '\'':
+		p.pos++
+		mark := p.peek()
+		if mark == 0 {
+			return addr, false, fmt.Errorf("E20: Mark not set")
+		}
+		p.pos++
+		addr.Kind = AddrMark
+		addr.Mark = mark
+	case ch == '/' || ch == '?':
+		p.pos++
+		addr.Kind = AddrForward
+		if ch == '?' {
+			addr.Kind = AddrBackward
+		}
+		addr.Pattern = p.parseDelimited(ch)
+	case ch == '+' || ch == '-':
+		addr.Kind = AddrCurrent
+	default:
+		found = false
+	}
+
+	// Offsets, "+", "-", "+3", "-2" and chains like "++"
+	for {
+		ch := p.peek()
+		if ch != '+' && ch != '-' {
+			break
+		}
+		found = true
+		p.pos++
+		n := 1
+		if unicode.IsDigit(p.peek()) {
+			n = p.parseNumber()
+		}
+		if ch == '-' {
+			n = -n
+		}
+		addr.Offset += n
+	}
+	return addr, found, nil
+}
+
+func (p *parser) parseNumber() int {
+	start := p.pos
+	for unicode.IsDigit(p.peek()) {
+		p.pos++
+	}
+	n, _ := strconv.Atoi(string(p.src[start:p.pos]))
+	return n
+}
+
+// parseDelimited reads up to an unescaped delim and consumes it. A missing closing delim ends at the line end.
+func (p *parser) parseDelimited(delim rune) string {
+	var out []rune
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+		if ch == '\\' && p.pos+1 < len(p.src) && p.src[p.pos+1] == delim {
+			out = append(out, delim)
+			p.pos += 2
+			continue
+		}
+		if ch == delim {
+			p.pos++
+			break
+		}
+		out = append(out, ch)
+		p.pos++
+	}
+	return string(out)
+}
+
+// parseName reads the command name. Names are letters, except a few single char commands like "&" and "<".
+func (p *parser) parseName() string {
+	start := p.pos
+	if ch := p.peek(); ch != 0 && strings.ContainsRune("&<>=!#", ch) {
+		p.pos++
+		return string(ch)
+	}
+	for unicode.IsLetter(p.peek()) {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+// Resolve turns the range into 1-based start and end lines. An empty range resolves to the current line.
+func (r Range) Resolve(res Resolver) (int, int, error) {
+	cur := res.CurrentLine()
+	if r.IsEmpty() {
+		return cur, cur, nil
+	}
+
+	start, err := r.Addresses[0].resolve(res, cur)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(r.Addresses) == 1 {
+		return start, start, nil
+	}
+
+	if r.Semicolon {
+		cur = start
+	}
+	end, err := r.Addresses[1].resolve(res, cur)
+	if err != nil {
+		return 0, 0, err
+	}
+	if start > end {
+		return 0, 0, fmt.Errorf("E493: Backwards range given")
+	}
+	return start, end, nil
+}
+
+func (a Address) resolve(res Resolver, cur int) (int, error) {
+	var line int
+	switch a.Kind {
+	case AddrCurrent:
+		line = cur
+	case AddrLast:
+		line = res.LastLine()
+	case AddrNumber:
+		line = a.Line
+	case AddrMark:
+		l, err := res.MarkLine(a.Mark)
+		if err != nil {
+			return 0, err
+		}
+		line = l
+	case AddrForward, AddrBackward:
+		l, err := res.SearchLine(a.Pattern, cur, a.Kind == AddrForward)
+		if err != nil {
+			return 0, err
+		}
+		line = l
+	}
+
+	line += a.Offset
+	if line < 0 || line > res.LastLine() {
+		return 0, fmt.Errorf("E16: Invalid range")
+	}
+	return line, nil
+}
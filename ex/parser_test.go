@@ -0,0 +1,116 @@
+package ex
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResolver struct {
+	cur   int
+	lines []string
+	marks map[rune]int
+}
+
+func (f *fakeResolver) CurrentLine() int { return f.cur }
+func (f *fakeResolver) LastLine() int    { return len(f.lines) }
+
+func (f *fakeResolver) MarkLine(mark rune) (int, error) {
+	if line, ok := f.marks[mark]; ok {
+		return line, nil
+	}
+	return 0, fmt.Errorf("E20: Mark not set")
+}
+
+func (f *fakeResolver) SearchLine(pattern string, from int, forward bool) (int, error) {
+	n := len(f.lines)
+	for i := 1; i <= n; i++ {
+		line := from + i
+		if !forward {
+			line = from - i
+		}
+		line = (line-1+n)%n + 1
+		if strings.Contains(f.lines[line-1], pattern) {
+			return line, nil
+		}
+	}
+	return 0, fmt.Errorf("E486: Pattern not found: %s", pattern)
+}
+
+func TestParseNameBangArgs(t *testing.T) {
+	cmd, err := Parse(":w! out.txt")
+	require.NoError(t, err)
+	require.True(t, cmd.Range.IsEmpty())
+	require.Equal(t, "w", cmd.Name)
+	require.True(t, cmd.Bang)
+	require.Equal(t, "out.txt", cmd.Args)
+
+	cmd, err = Parse("s/a/b/g")
+	require.NoError(t, err)
+	require.Equal(t, "s", cmd.Name)
+	require.Equal(t, "/a/b/g", cmd.Args)
+
+	cmd, err = Parse("  42")
+	require.NoError(t, err)
+	require.Equal(t, "", cmd.Name)
+	require.Len(t, cmd.Range.Addresses, 1)
+}
+
+func TestResolveRanges(t *testing.T) {
+	res := &fakeResolver{
+		cur:   3,
+		lines: []string{"alpha", "beta", "gamma", "delta", "epsilon", "zeta"},
+		marks: map[rune]int{'a': 2, 'b': 5},
+	}
+
+	tests := []struct {
+		line       string
+		start, end int
+	}{
+		{"d", 3, 3},
+		{"%d", 1, 6},
+		{".,$d", 3, 6},
+		{"'a,'bd", 2, 5},
+		{"2,+2d", 2, 5},
+		{"-,+d", 2, 4},
+		{"/eps/d", 5, 5},
+		{"?alp?,.d", 1, 3},
+		{"/beta/;+1d", 2, 3},
+		{"$-1", 5, 5},
+		{",4d", 3, 4},
+	}
+
+	for _, tt := range tests {
+		cmd, err := Parse(tt.line)
+		require.NoError(t, err, tt.line)
+		start, end, err := cmd.Range.Resolve(res)
+		require.NoError(t, err, tt.line)
+		require.Equal(t, tt.start, start, tt.line)
+		require.Equal(t, tt.end, end, tt.line)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	res := &fakeResolver{cur: 1, lines: []string{"a", "b"}}
+
+	for line, want := range map[string]string{
+		"'zd":   "E20",
+		"2,1d":  "E493",
+		"5d":    "E16",
+		"/nope": "E486",
+	} {
+		cmd, err := Parse(line)
+		require.NoError(t, err, line)
+		_, _, err = cmd.Range.Resolve(res)
+		require.ErrorContains(t, err, want, line)
+	}
+}
+
+func TestParseDelimitedPattern(t *testing.T) {
+	cmd, err := Parse(`/a\/b/d`)
+	require.NoError(t, err)
+	require.Equal(t, `a/b`, cmd.Range.Addresses[0].Pattern)
+	require.Equal(t, "d", cmd.Name)
+}
@@ -0,0 +1,123 @@
+package ex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Substitute is the argument part of ":s/pattern/replacement/flags count".
+type Substitute struct {
+	Pattern     string
+	Replacement string
+	Global      bool // g: every match in the line, not only the first
+	Confirm     bool // c: ask before each replacement
+	IgnoreCase  bool // i
+	MatchCase   bool // I
+	CountOnly   bool // n: report the number of matches, don't replace
+	NoError     bool // e: a missing pattern isn't an error
+	Count       int  // Trailing count, applies to that many lines from the end of the range
+	// Repeat is set for a bare ":s" or ":s g", which reuses the previous pattern and replacement.
+	Repeat bool
+}
+
+// ParseSubstitute splits ":s" arguments. The delimiter is whatever follows "s", so ":s#a/b#c#" works too.
+func ParseSubstitute(args string) (Substitute, error) {
+	var sub Substitute
+	runes := []rune(args)
+
+	if len(runes) == 0 || unicode.IsLetter(runes[0]) || unicode.IsSpace(runes[0]) || runes[0] == '&' {
+		sub.Repeat = true
+		rest := strings.TrimLeft(strings.TrimPrefix(args, "&"), " ")
+		return sub, sub.parseFlags([]rune(rest))
+	}
+
+	delim := runes[0]
+	if err := CheckDelimiter(delim); err != nil {
+		return sub, err
+	}
+
+	pattern, rest := splitDelimited(runes[1:], delim)
+	sub.Pattern = pattern
+	if rest == nil {
+		// ":s/pat" deletes the match
+		return sub, nil
+	}
+	replacement, rest := splitDelimited(rest, delim)
+	sub.Replacement = replacement
+	if rest == nil {
+		return sub, nil
+	}
+	return sub, sub.parseFlags(rest)
+}
+
+func (s *Substitute) parseFlags(rest []rune) error {
+	i := 0
+flags:
+	for ; i < len(rest); i++ {
+		switch rest[i] {
+		case 'g':
+			s.Global = !s.Global
+		case 'c':
+			s.Confirm = true
+		case 'i':
+			s.IgnoreCase = true
+		case 'I':
+			s.MatchCase = true
+		case 'n':
+			s.CountOnly = true
+		case 'e':
+			s.NoError = true
+		case '&':
+			// Keep flags from the previous substitute, handled by the caller through Repeat
+		default:
+			break flags
+		}
+	}
+
+	tail := strings.TrimSpace(string(rest[i:]))
+	if tail == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(tail)
+	if err != nil || n <= 0 {
+		return fmt.Errorf("E488: Trailing characters: %s", tail)
+	}
+	s.Count = n
+	return nil
+}
+
+// CheckDelimiter rejects what can't delimit a pattern in ":s" and ":g": letters, digits, "\\", '"' and "|".
+func CheckDelimiter(delim rune) error {
+	switch {
+	case unicode.IsLetter(delim):
+		return fmt.Errorf("E146: Regular expressions can't be delimited by letters")
+	case unicode.IsDigit(delim) || delim == '\\' || delim == '"' || delim == '|':
+		return fmt.Errorf("Regular expressions can't be delimited by %c", delim)
+	}
+	return nil
+}
+
+// splitDelimited reads up to an unescaped delim. Escaped delims lose their backslash, other escapes are kept
+// for the regex translation. rest is nil when the closing delim is missing.
+func splitDelimited(runes []rune, delim rune) (string, []rune) {
+	var out []rune
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		if ch == '\\' && i+1 < len(runes) {
+			if runes[i+1] == delim {
+				out = append(out, delim)
+			} else {
+				out = append(out, ch, runes[i+1])
+			}
+			i++
+			continue
+		}
+		if ch == delim {
+			return string(out), runes[i+1:]
+		}
+		out = append(out, ch)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,62 @@
+package ex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSubstitute(t *testing.T) {
+	sub, err := ParseSubstitute(`/foo\/bar/\1 &/gci 3`)
+	require.NoError(t, err)
+	require.Equal(t, `foo/bar`, sub.Pattern)
+	require.Equal(t, `\1 &`, sub.Replacement)
+	require.True(t, sub.Global)
+	require.True(t, sub.Confirm)
+	require.True(t, sub.IgnoreCase)
+	require.Equal(t, 3, sub.Count)
+
+	sub, err = ParseSubstitute(`#a/b#c`)
+	require.NoError(t, err)
+	require.Equal(t, "a/b", sub.Pattern)
+	require.Equal(t, "c", sub.Replacement)
+	require.False(t, sub.Global)
+
+	// Missing replacement deletes the match
+	sub, err = ParseSubstitute(`/x`)
+	require.NoError(t, err)
+	require.Equal(t, "x", sub.Pattern)
+	require.Equal(t, "", sub.Replacement)
+}
+
+func TestParseSubstituteRepeatAndErrors(t *testing.T) {
+	sub, err := ParseSubstitute("")
+	require.NoError(t, err)
+	require.True(t, sub.Repeat)
+
+	sub, err = ParseSubstitute(" g")
+	require.NoError(t, err)
+	require.True(t, sub.Repeat)
+	require.True(t, sub.Global)
+
+	_, err = ParseSubstitute(`/a/b/gx`)
+	require.ErrorContains(t, err, "E488")
+
+	_, err = ParseSubstitute(`1a1b1`)
+	require.EqualError(t, err, "Regular expressions can't be delimited by 1")
+
+	_, err = ParseSubstitute(`|a|b|`)
+	require.EqualError(t, err, "Regular expressions can't be delimited by |")
+}
+
+func TestCheckDelimiter(t *testing.T) {
+	require.NoError(t, CheckDelimiter('/'))
+	require.NoError(t, CheckDelimiter('#'))
+	require.ErrorContains(t, CheckDelimiter('x'), "E146")
+	require.ErrorContains(t, CheckDelimiter('é'), "E146")
+	for _, delim := range `7\"|` {
+		err := CheckDelimiter(delim)
+		require.Error(t, err)
+		require.NotContains(t, err.Error(), "letters")
+	}
+}
@@ -0,0 +1,385 @@
+package filetree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ogzhanolguncu/go_editor/gitignore"
+)
+
+// Node is a file or directory. Directories read their entries the first time they're expanded.
+type Node struct {
+	Name     string
+	Path     string // Absolute
+	IsDir    bool
+	Depth    int // 0 for the root's entries
+	Expanded bool
+
+	parent   *Node
+	children []*Node
+	loaded   bool
+	modTime  time.Time // When it was read. A directory's changes when entries come or go, a file's when it's written
+	size     int64
+}
+
+// Tree is a directory shown as an outline. Only expanded directories are read, ignored files are left out.
+type Tree struct {
+	root   *Node
+	ignore *gitignore.Matcher
+	rows   []*Node // Visible nodes in display order, rebuilt after every change
+}
+
+// New reads dir and its .gitignore.
+func New(dir string) (*Tree, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := gitignore.Load(abs)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tree{
+		root:   &Node{Name: filepath.Base(abs), Path: abs, IsDir: true, Depth: -1, Expanded: true},
+		ignore: ignore,
+	}
+	if err := t.load(t.root); err != nil {
+		return nil, err
+	}
+	t.flatten()
+	return t, nil
+}
+
+// Root is the directory the tree shows.
+func (t *Tree) Root() string {
+	return t.root.Path
+}
+
+// Rows returns the visible nodes top to bottom.
+func (t *Tree) Rows() []*Node {
+	return t.rows
+}
+
+// Toggle expands a collapsed directory, reading it if needed, or collapses an expanded one.
+func (t *Tree) Toggle(n *Node) error {
+	if !n.IsDir {
+		return nil
+	}
+	if !n.Expanded && !n.loaded {
+		if err := t.load(n); err != nil {
+			return err
+		}
+	}
+	n.Expanded = !n.Expanded
+	t.flatten()
+	return nil
+}
+
+// Reveal expands the directories leading to path and returns its row. False when path isn't in the tree.
+func (t *Tree) Reveal(path string) (int, bool) {
+	rel, err := filepath.Rel(t.root.Path, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return 0, false
+	}
+
+	n := t.root
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		if !n.loaded {
+			if err := t.load(n); err != nil {
+				return 0, false
+			}
+		}
+		n.Expanded = true
+		i := slices.IndexFunc(n.children, func(c *Node) bool { return c.Name == name })
+		if i < 0 {
+			t.flatten()
+			return 0, false
+		}
+		n = n.children[i]
+	}
+	t.flatten()
+	return slices.Index(t.rows, n), true
+}
+
+// Stamps records what the loaded directories and the files in them looked like when they were read. It's a
+// copy, so Changed can stat everything on another goroutine while the tree goes on being used.
+type Stamps []stamp
+
+type stamp struct {
+	path    string
+	isDir   bool
+	modTime time.Time
+	size    int64
+}
+
+// Stamps returns the stamps of everything read so far.
+func (t *Tree) Stamps() Stamps {
+	var stamps Stamps
+	t.walkLoaded(t.root, func(n *Node) {
+		stamps = append(stamps, stamp{path: n.Path, isDir: true, modTime: n.modTime})
+		for _, c := range n.children {
+			if !c.IsDir {
+				stamps = append(stamps, stamp{path: c.Path, modTime: c.modTime, size: c.size})
+			}
+		}
+	})
+	return stamps
+}
+
+// Changed reports whether entries were added or removed in a directory, or a file was written, since the stamps
+// were taken.
+func (s Stamps) Changed() bool {
+	for _, st := range s {
+		stat := os.Lstat
+		if st.isDir {
+			stat = os.Stat
+		}
+		info, err := stat(st.path)
+		if err != nil || !info.ModTime().Equal(st.modTime) || !st.isDir && info.Size() != st.size {
+			return true
+		}
+	}
+	return false
+}
+
+// Refresh reads every loaded directory again, keeping what was expanded. The .gitignore files are read again
+// too, the nested ones as their directories load.
+func (t *Tree) Refresh() error {
+	ignore, err := gitignore.Load(t.root.Path)
+	if err != nil {
+		return err
+	}
+	t.ignore = ignore
+
+	expanded := map[string]bool{}
+	t.walkLoaded(t.root, func(n *Node) {
+		if n.Expanded {
+			expanded[n.Path] = true
+		}
+	})
+
+	var reload func(n *Node) error
+	reload = func(n *Node) error {
+		if err := t.load(n); err != nil {
+			return err
+		}
+		for _, c := range n.children {
+			if c.IsDir && expanded[c.Path] {
+				c.Expanded = true
+				if err := reload(c); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	err = reload(t.root)
+	t.flatten()
+	return err
+}
+
+// Create makes a file in dir, or a directory when name ends with "/". Returns the new path.
+func (t *Tree) Create(dir *Node, name string) (string, error) {
+	if dir == nil {
+		dir = t.root
+	}
+	if !dir.IsDir {
+		dir = dir.parent
+	}
+	isDir := strings.HasSuffix(name, "/")
+	path, err := t.target(dir.Path, strings.TrimSuffix(name, "/"))
+	if err != nil {
+		return "", err
+	}
+
+	if isDir {
+		err = os.MkdirAll(path, 0o755)
+	} else {
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+			var f *os.File
+			f, err = os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+			if err == nil {
+				err = f.Close()
+			}
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return path, t.afterChange(path)
+}
+
+// Rename gives n a new name in the same directory. Unlike Move, a name taken by a directory is refused rather
+// than moved into. Returns the new path.
+func (t *Tree) Rename(n *Node, name string) (string, error) {
+	if n == t.root {
+		return "", errors.New("can't rename the root")
+	}
+	if strings.ContainsRune(name, filepath.Separator) {
+		return "", fmt.Errorf("invalid name: %s", name)
+	}
+	dest, err := t.target(filepath.Dir(n.Path), name)
+	if err != nil {
+		return "", err
+	}
+	return t.rename(n, dest)
+}
+
+// Move moves n to dest. A dest that is an existing directory receives n under its own name. Relative
+// destinations are taken from the tree's root. Returns the new path.
+func (t *Tree) Move(n *Node, dest string) (string, error) {
+	if n == t.root {
+		return "", errors.New("can't move the root")
+	}
+	if !filepath.IsAbs(dest) {
+		dest = filepath.Join(t.root.Path, dest)
+	}
+	if info, err := os.Stat(dest); err == nil && info.IsDir() {
+		dest = filepath.Join(dest, n.Name)
+	}
+	if _, err := os.Lstat(dest); err == nil {
+		return "", fmt.Errorf("already exists: %s", dest)
+	}
+	return t.rename(n, dest)
+}
+
+// rename moves n to dest, making the directories it needs.
+func (t *Tree) rename(n *Node, dest string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(n.Path, dest); err != nil {
+		return "", err
+	}
+	return dest, t.afterChange(dest)
+}
+
+// Delete removes n, directories with everything in them.
+func (t *Tree) Delete(n *Node) error {
+	if n == t.root {
+		return errors.New("can't delete the root")
+	}
+	if err := os.RemoveAll(n.Path); err != nil {
+		return err
+	}
+	return t.Refresh()
+}
+
+// target joins name to dir, refusing names that already exist.
+func (t *Tree) target(dir, name string) (string, error) {
+	if name == "" {
+		return "", errors.New("empty name")
+	}
+	path := filepath.Join(dir, name)
+	if _, err := os.Lstat(path); err == nil {
+		return "", fmt.Errorf("already exists: %s", path)
+	}
+	return path, nil
+}
+
+// afterChange reads the tree again and shows path.
+func (t *Tree) afterChange(path string) error {
+	if err := t.Refresh(); err != nil {
+		return err
+	}
+	t.Reveal(path)
+	return nil
+}
+
+// load reads the entries of directory n, leaving out .git and what .gitignore excludes. Directories come first.
+func (t *Tree) load(n *Node) error {
+	info, err := os.Stat(n.Path)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(n.Path)
+	if err != nil {
+		return err
+	}
+	rel, err := gitignore.Rel(t.root.Path, n.Path)
+	if err != nil {
+		return err
+	}
+	if rel == "." {
+		rel = ""
+	} else if err := t.ignore.AddFile(t.root.Path, rel); err != nil {
+		return err
+	}
+
+	children := make([]*Node, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			if target, err := os.Stat(filepath.Join(n.Path, name)); err == nil {
+				isDir = target.IsDir()
+			}
+		}
+		if name == ".git" || t.ignore.Match(joinRel(rel, name), isDir) {
+			continue
+		}
+		child := &Node{
+			Name:   name,
+			Path:   filepath.Join(n.Path, name),
+			IsDir:  isDir,
+			Depth:  n.Depth + 1,
+			parent: n,
+		}
+		if info, err := entry.Info(); err == nil && !isDir {
+			child.modTime, child.size = info.ModTime(), info.Size()
+		}
+		children = append(children, child)
+	}
+	slices.SortFunc(children, func(a, b *Node) int {
+		if a.IsDir != b.IsDir {
+			if a.IsDir {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	n.children = children
+	n.loaded = true
+	n.modTime = info.ModTime()
+	return nil
+}
+
+func (t *Tree) flatten() {
+	t.rows = t.rows[:0]
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		for _, c := range n.children {
+			t.rows = append(t.rows, c)
+			if c.IsDir && c.Expanded {
+				walk(c)
+			}
+		}
+	}
+	walk(t.root)
+}
+
+func (t *Tree) walkLoaded(n *Node, visit func(n *Node)) {
+	if !n.loaded {
+		return
+	}
+	visit(n)
+	for _, c := range n.children {
+		if c.IsDir {
+			t.walkLoaded(c, visit)
+		}
+	}
+}
+
+func joinRel(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
@@ -0,0 +1,185 @@
+package filetree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// makeDir creates files under a temp dir, names ending in "/" are directories.
+func makeDir(t *testing.T, names ...string) string {
+	root := t.TempDir()
+	for _, name := range names {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if name[len(name)-1] == '/' {
+			require.NoError(t, os.MkdirAll(path, 0o755))
+			continue
+		}
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+	}
+	return root
+}
+
+func names(t *Tree) []string {
+	var out []string
+	for _, n := range t.Rows() {
+		out = append(out, n.Name)
+	}
+	return out
+}
+
+func TestNewListsDirsFirst(t *testing.T) {
+	root := makeDir(t, "b.go", "A.go", "src/main.go", "docs/", ".git/HEAD")
+	tree, err := New(root)
+	require.NoError(t, err)
+	require.Equal(t, []string{"docs", "src", "A.go", "b.go"}, names(tree))
+}
+
+func TestToggleLoadsLazily(t *testing.T) {
+	root := makeDir(t, "src/main.go", "src/util/x.go")
+	tree, err := New(root)
+	require.NoError(t, err)
+
+	src := tree.Rows()[0]
+	require.False(t, src.loaded)
+	require.NoError(t, tree.Toggle(src))
+	require.Equal(t, []string{"src", "util", "main.go"}, names(tree))
+	require.Equal(t, 1, tree.Rows()[1].Depth)
+
+	require.NoError(t, tree.Toggle(src))
+	require.Equal(t, []string{"src"}, names(tree))
+}
+
+func TestGitignore(t *testing.T) {
+	root := makeDir(t, "main.go", "debug.log", "build/out", "lib/gen/x.go", "lib/keep.go")
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.log\nbuild/\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "lib", ".gitignore"), []byte("gen/\n"), 0o644))
+
+	tree, err := New(root)
+	require.NoError(t, err)
+	require.Equal(t, []string{"lib", ".gitignore", "main.go"}, names(tree))
+
+	require.NoError(t, tree.Toggle(tree.Rows()[0]))
+	require.Equal(t, []string{"lib", ".gitignore", "keep.go", ".gitignore", "main.go"}, names(tree))
+}
+
+func TestRefreshRereadsGitignore(t *testing.T) {
+	root := makeDir(t, "main.go", "debug.log", "lib/gen/x.go", "lib/keep.go")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "lib", ".gitignore"), []byte("gen/\n"), 0o644))
+	tree, err := New(root)
+	require.NoError(t, err)
+	require.NoError(t, tree.Toggle(tree.Rows()[0]))
+	require.Equal(t, []string{"lib", ".gitignore", "keep.go", "debug.log", "main.go"}, names(tree))
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.log\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "lib", ".gitignore"), nil, 0o644))
+	require.NoError(t, tree.Refresh())
+	require.NoError(t, tree.Refresh())
+	require.Equal(t, []string{"lib", "gen", ".gitignore", "keep.go", ".gitignore", "main.go"}, names(tree),
+		"a new root rule applies, a dropped nested one is gone")
+}
+
+func TestReveal(t *testing.T) {
+	root := makeDir(t, "a/b/c.go", "z.go")
+	tree, err := New(root)
+	require.NoError(t, err)
+
+	row, ok := tree.Reveal(filepath.Join(root, "a", "b", "c.go"))
+	require.True(t, ok)
+	require.Equal(t, 2, row)
+	require.Equal(t, []string{"a", "b", "c.go", "z.go"}, names(tree))
+
+	_, ok = tree.Reveal(filepath.Join(root, "missing.go"))
+	require.False(t, ok)
+	_, ok = tree.Reveal("/elsewhere/file.go")
+	require.False(t, ok)
+}
+
+func TestChangedAndRefresh(t *testing.T) {
+	root := makeDir(t, "src/a.go")
+	tree, err := New(root)
+	require.NoError(t, err)
+	require.NoError(t, tree.Toggle(tree.Rows()[0]))
+	require.False(t, tree.Stamps().Changed())
+
+	// Make sure the directory's mtime moves even on coarse filesystems
+	later := time.Now().Add(2 * time.Second)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "src", "b.go"), nil, 0o644))
+	require.NoError(t, os.Chtimes(filepath.Join(root, "src"), later, later))
+	require.True(t, tree.Stamps().Changed())
+
+	require.NoError(t, tree.Refresh())
+	require.False(t, tree.Stamps().Changed())
+	require.Equal(t, []string{"src", "a.go", "b.go"}, names(tree), "expanded directories stay expanded")
+}
+
+func TestChangedFileWrite(t *testing.T) {
+	root := makeDir(t, "closed/b.go")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.go"), []byte("a"), 0o644))
+	tree, err := New(root)
+	require.NoError(t, err)
+	stamps := tree.Stamps()
+
+	// Files in directories that weren't read don't count
+	require.NoError(t, os.WriteFile(filepath.Join(root, "closed", "b.go"), []byte("b"), 0o644))
+	require.False(t, stamps.Changed())
+
+	// Written in place with the same size, the directory's mtime stays put
+	later := time.Now().Add(2 * time.Second)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.go"), []byte("x"), 0o644))
+	require.NoError(t, os.Chtimes(filepath.Join(root, "a.go"), later, later))
+	require.True(t, stamps.Changed())
+}
+
+func TestCreate(t *testing.T) {
+	root := makeDir(t, "src/")
+	tree, err := New(root)
+	require.NoError(t, err)
+
+	path, err := tree.Create(tree.Rows()[0], "new.go")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, "src", "new.go"), path)
+	require.FileExists(t, path)
+	require.Equal(t, []string{"src", "new.go"}, names(tree))
+
+	path, err = tree.Create(nil, "pkg/")
+	require.NoError(t, err)
+	require.DirExists(t, path)
+
+	_, err = tree.Create(nil, "pkg/")
+	require.Error(t, err)
+}
+
+func TestRenameMoveDelete(t *testing.T) {
+	root := makeDir(t, "a.go", "dir/")
+	tree, err := New(root)
+	require.NoError(t, err)
+
+	file := tree.Rows()[1]
+	path, err := tree.Rename(file, "b.go")
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(root, "b.go"))
+	require.Equal(t, filepath.Join(root, "b.go"), path)
+
+	file = tree.Rows()[1]
+	path, err = tree.Move(file, "dir")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, "dir", "b.go"), path)
+	require.Equal(t, []string{"dir", "b.go"}, names(tree))
+
+	// A name taken by a directory is refused, not moved into
+	require.NoError(t, os.Mkdir(filepath.Join(root, "dir", "sub"), 0o755))
+	_, err = tree.Rename(tree.Rows()[1], "sub")
+	require.EqualError(t, err, "already exists: "+filepath.Join(root, "dir", "sub"))
+	require.FileExists(t, filepath.Join(root, "dir", "b.go"))
+	_, err = tree.Rename(tree.Rows()[1], "")
+	require.EqualError(t, err, "empty name")
+
+	require.NoError(t, tree.Delete(tree.Rows()[0]))
+	require.NoDirExists(t, filepath.Join(root, "dir"))
+	require.Empty(t, tree.Rows())
+}
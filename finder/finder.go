@@ -0,0 +1,126 @@
+// Package finder lists the files of a project in the background, for the fuzzy file finder to filter while
+// the walk is still running.
+package finder
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ogzhanolguncu/go_editor/gitignore"
+)
+
+// MaxFiles stops the walk in huge trees, the finder is for projects, not the whole disk.
+const MaxFiles = 200_000
+
+// notifyEvery is how often a running walk reports new files.
+const notifyEvery = 50 * time.Millisecond
+
+// Collector walks a directory in its own goroutine. Files are slash separated and relative to the root.
+type Collector struct {
+	mu     sync.Mutex
+	files  []string
+	done   bool
+	err    error
+	cancel context.CancelFunc
+}
+
+// Start begins walking root, skipping .git and whatever .gitignore files exclude. notify is called from the
+// walking goroutine when files were added and once more when the walk ends, it must be safe to call from there.
+func Start(root string, notify func()) *Collector {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Collector{cancel: cancel}
+	go c.walk(ctx, root, notify)
+	return c
+}
+
+// Files returns what was found so far and whether the walk is over. The slice must not be modified.
+func (c *Collector) Files() ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.files, c.done
+}
+
+// Err is why the walk stopped early, if it did.
+func (c *Collector) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Stop ends a running walk.
+func (c *Collector) Stop() {
+	c.cancel()
+}
+
+func (c *Collector) walk(ctx context.Context, root string, notify func()) {
+	var batch []string
+	last := time.Now()
+	flush := func(done bool, err error) {
+		c.mu.Lock()
+		c.files = append(c.files, batch...)
+		c.done = done
+		c.err = err
+		c.mu.Unlock()
+		batch = batch[:0]
+		last = time.Now()
+		if notify != nil {
+			notify()
+		}
+	}
+
+	count := 0
+	err := Walk(ctx, root, func(rel string) error {
+		batch = append(batch, rel)
+		count++
+		if count >= MaxFiles {
+			return fs.SkipAll
+		}
+		if time.Since(last) >= notifyEvery {
+			flush(false, nil)
+		}
+		return nil
+	})
+	if ctx.Err() != nil {
+		err = nil
+	}
+	flush(true, err)
+}
+
+// Walk calls fn with the path of every file under root that .gitignore files don't exclude, slash separated and
+// relative to root. .git is skipped and so are entries that can't be read. The walk stops when ctx is done or fn
+// returns an error, fs.SkipAll stops it without one.
+func Walk(ctx context.Context, root string, fn func(rel string) error) error {
+	ignore, err := gitignore.Load(root)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		rel, err := gitignore.Rel(root, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+
+		if d.IsDir() {
+			if d.Name() == ".git" || ignore.Match(rel, true) {
+				return fs.SkipDir
+			}
+			return ignore.AddFile(root, rel)
+		}
+		if ignore.Match(rel, false) {
+			return nil
+		}
+		return fn(rel)
+	})
+}
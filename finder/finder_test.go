@@ -0,0 +1,53 @@
+package finder
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func wait(t *testing.T, c *Collector) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if files, done := c.Files(); done {
+			return slices.Sorted(slices.Values(files))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("walk didn't finish")
+	return nil
+}
+
+func TestCollectorRespectsGitignore(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"main.go", "debug.log", "src/a.go", "build/out.bin", ".git/HEAD", "lib/gen/x.go", "lib/y.go"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.log\nbuild/\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "lib", ".gitignore"), []byte("gen/\n"), 0o644))
+
+	notified := make(chan struct{}, 100)
+	c := Start(root, func() { notified <- struct{}{} })
+	files := wait(t, c)
+
+	require.Equal(t, []string{".gitignore", "lib/.gitignore", "lib/y.go", "main.go", "src/a.go"}, files)
+	require.NoError(t, c.Err())
+	require.NotEmpty(t, notified)
+}
+
+func TestCollectorStop(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.go"), nil, 0o644))
+
+	c := Start(root, nil)
+	c.Stop()
+	wait(t, c)
+	require.NoError(t, c.Err(), "stopping isn't an error")
+}
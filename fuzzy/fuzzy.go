@@ -0,0 +1,161 @@
+// Package fuzzy matches short typed patterns against candidates like file paths and command names.
+// A pattern matches when its chars appear in order, and matches are ranked so that consecutive chars,
+// chars starting a word and an early start score higher.
+package fuzzy
+
+import (
+	"sort"
+	"unicode"
+)
+
+// Scoring weights. They only matter relative to each other.
+const (
+	scoreMatch       = 16
+	bonusConsecutive = 24
+	bonusBoundary    = 20 // Char starts a word: after "/", "_", "-", ".", a space, or a lower to upper case change
+	bonusFirstChar   = 12 // Match starts at the very beginning
+	penaltyGap       = 1  // Per skipped char between two matched ones
+	penaltyLeading   = 1  // Per char before the first match, capped
+	maxLeadingCost   = 12
+)
+
+// Match is a candidate that matched a pattern.
+type Match struct {
+	Str       string
+	Index     int   // Position in the candidate list
+	Score     int   // Higher is better
+	Positions []int // Rune indexes of the matched chars, for highlighting
+}
+
+// Score matches pattern against s. Matching ignores case unless the pattern has an uppercase letter.
+func Score(pattern, s string) (int, []int, bool) {
+	p, text := []rune(pattern), []rune(s)
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+	ignoreCase := !hasUpper(p)
+
+	// Forward pass finds where the earliest match ends
+	pi, end := 0, -1
+	for i, r := range text {
+		if equal(r, p[pi], ignoreCase) {
+			pi++
+			if pi == len(p) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	// Backward pass from there finds the tightest match ending at that spot
+	positions := make([]int, len(p))
+	pi = len(p) - 1
+	for i := end; i >= 0 && pi >= 0; i-- {
+		if equal(text[i], p[pi], ignoreCase) {
+			positions[pi] = i
+			pi--
+		}
+	}
+
+	// Prefer boundary positions where one is available further right, "fb" in "foo_bar" should pick the "b" of bar
+	positions = preferBoundaries(text, p, positions, ignoreCase)
+	return score(text, positions), positions, true
+}
+
+// preferBoundaries moves each matched char, last one first, to a later word start when one fits before the next match.
+func preferBoundaries(text, p []rune, positions []int, ignoreCase bool) []int {
+	for k := len(positions) - 1; k >= 0; k-- {
+		if isBoundary(text, positions[k]) {
+			continue
+		}
+		limit := len(text)
+		if k+1 < len(positions) {
+			limit = positions[k+1]
+		}
+		for i := positions[k] + 1; i < limit; i++ {
+			if equal(text[i], p[k], ignoreCase) && isBoundary(text, i) {
+				positions[k] = i
+				break
+			}
+		}
+	}
+	return positions
+}
+
+func score(text []rune, positions []int) int {
+	total := 0
+	for k, pos := range positions {
+		total += scoreMatch
+		if isBoundary(text, pos) {
+			total += bonusBoundary
+		}
+		if k > 0 {
+			if gap := pos - positions[k-1] - 1; gap == 0 {
+				total += bonusConsecutive
+			} else {
+				total -= gap * penaltyGap
+			}
+		}
+	}
+	if positions[0] == 0 {
+		total += bonusFirstChar
+	}
+	total -= min(positions[0]*penaltyLeading, maxLeadingCost)
+	return total
+}
+
+func isBoundary(text []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := text[i-1], text[i]
+	switch prev {
+	case '/', '\\', '_', '-', '.', ' ', ':':
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
+
+func equal(a, b rune, ignoreCase bool) bool {
+	if ignoreCase {
+		return unicode.ToLower(a) == unicode.ToLower(b)
+	}
+	return a == b
+}
+
+func hasUpper(p []rune) bool {
+	for _, r := range p {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the candidates matching pattern, best first. Ties go to the shorter candidate,
+// then to the earlier one. An empty pattern keeps every candidate in order.
+func Filter(pattern string, candidates []string) []Match {
+	matches := make([]Match, 0, len(candidates))
+	for i, c := range candidates {
+		if s, positions, ok := Score(pattern, c); ok {
+			matches = append(matches, Match{Str: c, Index: i, Score: s, Positions: positions})
+		}
+	}
+	if pattern == "" {
+		return matches
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.Str) != len(b.Str) {
+			return len(a.Str) < len(b.Str)
+		}
+		return a.Index < b.Index
+	})
+	return matches
+}
@@ -0,0 +1,41 @@
+package fuzzy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreMatches(t *testing.T) {
+	_, positions, ok := Score("fb", "foo_bar")
+	require.True(t, ok)
+	require.Equal(t, []int{0, 4}, positions, "Prefers the start of a word")
+
+	_, _, ok = Score("xyz", "foo_bar")
+	require.False(t, ok)
+
+	_, _, ok = Score("FB", "foo_bar")
+	require.False(t, ok, "Uppercase pattern matches case")
+
+	_, positions, ok = Score("çy", "çay")
+	require.True(t, ok)
+	require.Equal(t, []int{0, 2}, positions, "Positions are runes")
+}
+
+func TestFilterRanking(t *testing.T) {
+	candidates := []string{"src/screen/palette.go", "editor/editor.go", "editor/marks.go", "main.go"}
+	matches := Filter("ed/ed", candidates)
+	require.NotEmpty(t, matches)
+	require.Equal(t, "editor/editor.go", matches[0].Str)
+
+	matches = Filter("main", candidates)
+	require.Equal(t, "main.go", matches[0].Str)
+	require.Len(t, matches, 1)
+
+	require.Len(t, Filter("", candidates), len(candidates))
+}
+
+func TestFilterPrefersConsecutive(t *testing.T) {
+	matches := Filter("set", []string{"sxexxt", "set"})
+	require.Equal(t, "set", matches[0].Str)
+}
@@ -0,0 +1,100 @@
+package gapbuffer
+
+// Find returns the start of every occurrence of needle, overlapping ones included.
+func (gb *GapBuffer) Find(needle string) []int {
+	return gb.FindIn(needle, 0, gb.Length())
+}
+
+// FindIn returns the start of every occurrence of needle lying entirely inside start..end (end exclusive).
+// The text on each side of the gap is searched in place with Boyer-Moore-Horspool, and only the few
+// chars around the gap are copied to catch matches that span it.
+func (gb *GapBuffer) FindIn(needle string, start, end int) []int {
+	positions := make([]int, 0)
+	pattern := []rune(needle)
+	m := len(pattern)
+
+	start = max(0, start)
+	end = min(gb.Length(), end)
+	if m == 0 || end-start < m {
+		return positions
+	}
+
+	h := newHorspool(pattern)
+
+	// Left of the gap: logical positions are buffer indexes
+	var left []rune
+	if start < gb.gapStart {
+		left = gb.buffer[start:min(end, gb.gapStart)]
+		positions = h.search(left, start, positions)
+	}
+
+	// Seam: the last m-1 chars before the gap and the first m-1 after it. Any match in there spans the gap
+	var right []rune
+	rightStart := max(start, gb.gapStart)
+	if end > gb.gapStart {
+		right = gb.buffer[rightStart+gb.GapSize() : end+gb.GapSize()]
+	}
+	if len(left) > 0 && len(right) > 0 && m > 1 {
+		before := left[len(left)-min(m-1, len(left)):]
+		after := right[:min(m-1, len(right))]
+		seam := make([]rune, 0, len(before)+len(after))
+		seam = append(append(seam, before...), after...)
+		positions = h.search(seam, rightStart-len(before), positions)
+	}
+
+	// Right of the gap
+	if len(right) > 0 {
+		positions = h.search(right, rightStart, positions)
+	}
+	return positions
+}
+
+// horspool holds the bad character shifts for one needle. ASCII gets a table, anything else a map.
+type horspool struct {
+	needle []rune
+	ascii  [128]int
+	other  map[rune]int
+}
+
+func newHorspool(needle []rune) *horspool {
+	m := len(needle)
+	h := &horspool{needle: needle, other: make(map[rune]int)}
+	for i := range h.ascii {
+		h.ascii[i] = m
+	}
+	// How far the window may jump when its last char is r: distance from r's last spot to the needle end
+	for i, r := range needle[:m-1] {
+		if r >= 0 && r < 128 {
+			h.ascii[r] = m - 1 - i
+		} else {
+			h.other[r] = m - 1 - i
+		}
+	}
+	return h
+}
+
+func (h *horspool) shift(r rune) int {
+	if r >= 0 && r < 128 {
+		return h.ascii[r]
+	}
+	if n, ok := h.other[r]; ok {
+		return n
+	}
+	return len(h.needle)
+}
+
+// search appends offset+i for every match at text[i:].
+func (h *horspool) search(text []rune, offset int, positions []int) []int {
+	m := len(h.needle)
+	for i := 0; i <= len(text)-m; {
+		j := m - 1
+		for j >= 0 && text[i+j] == h.needle[j] {
+			j--
+		}
+		if j < 0 {
+			positions = append(positions, offset+i)
+		}
+		i += h.shift(text[i+m-1])
+	}
+	return positions
+}
@@ -8,6 +8,11 @@ type GapBuffer struct {
 	buffer   []rune
 	gapStart int
 	gapEnd   int
+
+	// shared is set while snapshots may read buffer, only writes in writableStart..writableEnd leave it as is
+	shared        bool
+	writableStart int
+	writableEnd   int
 }
 
 func NewGapBuffer(initialSize int) (*GapBuffer, error) {
@@ -42,6 +47,7 @@ func (gb *GapBuffer) Insert(ch rune) {
 	if gb.GapSize() == 0 {
 		gb.expandBuffer()
 	}
+	gb.beforeWrite(gb.gapStart, gb.gapStart+1)
 	gb.buffer[gb.gapStart] = ch
 	gb.gapStart++
 }
@@ -65,6 +71,7 @@ func (gb *GapBuffer) InsertString(text string) {
 		gb.resizeBuffer(newSize)
 	}
 
+	gb.beforeWrite(gb.gapStart, gb.gapStart+len(textRunes))
 	for _, r := range textRunes {
 		gb.buffer[gb.gapStart] = r
 		gb.gapStart++
@@ -114,6 +121,7 @@ func (gb *GapBuffer) resizeBuffer(newSize int) {
 	copy(newBuffer[newSize-len(rightPart):], rightPart) // Copy right part to end
 
 	gb.buffer = newBuffer
+	gb.shared = false
 	gb.gapStart = len(leftPart)          // Gap starts after left part
 	gb.gapEnd = newSize - len(rightPart) // Gap ends before right part
 }
@@ -145,6 +153,7 @@ func (gb *GapBuffer) MoveGapTo(pos int) {
 		if gb.gapEnd+diff > len(gb.buffer) {
 			return
 		}
+		gb.beforeWrite(gb.gapStart, gb.gapStart+diff)
 		for i := range diff {
 			gb.buffer[gb.gapStart+i] = gb.buffer[gb.gapEnd+i]
 		}
@@ -156,6 +165,7 @@ func (gb *GapBuffer) MoveGapTo(pos int) {
 
 	if direction == "left" {
 		diff := gb.gapStart - pos
+		gb.beforeWrite(gb.gapEnd-diff, gb.gapEnd)
 		for i := range diff {
 			gb.buffer[gb.gapEnd-1-i] = gb.buffer[gb.gapStart-1-i]
 		}
@@ -252,39 +262,3 @@ func (gb *GapBuffer) Substring(start, end int) string {
 
 	return string(result)
 }
-
-// Find moves through text char by char then tries to do full text check at every stop.
-// E.g
-// Text: "Hello Hello"
-// Search: "Hello"
-// Lands on "H" then start moving as much as needle length if every char matches it means starting position is a match
-func (gb *GapBuffer) Find(needle string) []int {
-	// If search is empty, bail
-	if len(needle) == 0 {
-		return []int{}
-	}
-
-	positions := make([]int, 0)
-	needleLen := len(needle)
-	textLen := gb.Length()
-
-	// If search is bigger than the actual text, bail
-	if needleLen > textLen {
-		return positions
-	}
-
-	for start := 0; start <= textLen-needleLen; start++ {
-		match := true
-		for i := range needleLen {
-			if gb.CharAt(start+i) != rune(needle[i]) {
-				match = false
-				break
-			}
-		}
-		if match {
-			positions = append(positions, start)
-		}
-	}
-
-	return positions
-}
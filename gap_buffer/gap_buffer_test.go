@@ -317,4 +317,77 @@ func TestFind(t *testing.T) {
 
 	// Edge case
 	require.Equal(t, []int{}, gb.Find(""), "Empty needle")
+
+	// Multibyte needles compare runes, not bytes
+	gb, _ = NewGapBuffer(30)
+	gb.InsertString("çay ve çay")
+	require.Equal(t, []int{0, 7}, gb.Find("çay"), "Non-ASCII needle")
+}
+
+func TestFindIn(t *testing.T) {
+	gb, _ := NewGapBuffer(10)
+	gb.InsertString("abcabcabc")
+
+	require.Equal(t, []int{3}, gb.FindIn("abc", 1, 8), "Only matches inside the range")
+	require.Equal(t, []int{0, 1, 2}, func() []int {
+		gb, _ := NewGapBuffer(10)
+		gb.InsertString("aaaa")
+		return gb.Find("aa")
+	}(), "Overlapping matches")
+
+	// Every gap position gives the same answer as a plain scan
+	text := "xaxaxaa çaça xaa"
+	for gap := 0; gap <= len([]rune(text)); gap++ {
+		gb, _ := NewGapBuffer(32)
+		gb.InsertString(text)
+		gb.MoveGapTo(gap)
+		for _, needle := range []string{"xa", "aa", "xax", "ça", "a xa", "x"} {
+			require.Equal(t, naiveFind(text, needle), gb.Find(needle), "gap %d needle %q", gap, needle)
+		}
+	}
+}
+
+func naiveFind(text, needle string) []int {
+	runes, pattern := []rune(text), []rune(needle)
+	positions := []int{}
+	for i := 0; i+len(pattern) <= len(runes); i++ {
+		if string(runes[i:i+len(pattern)]) == needle {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+func TestSnapshot(t *testing.T) {
+	gb, _ := NewGapBuffer(32)
+	gb.InsertString("hello world")
+	gb.MoveGapTo(5)
+	snap := gb.Snapshot()
+	storage := &gb.buffer[0]
+
+	// Typing into the gap leaves the storage shared
+	gb.InsertString(",")
+	require.Same(t, storage, &gb.buffer[0])
+	require.Equal(t, "hello, world", gb.String())
+	require.Equal(t, "hello world", snap.String())
+
+	// Writing over text the snapshot reads copies first
+	gb.DeleteRange(0, 6)
+	gb.InsertString("bye,")
+	require.NotSame(t, storage, &gb.buffer[0])
+	require.Equal(t, "bye, world", gb.String())
+	require.Equal(t, "hello world", snap.String())
+	require.Equal(t, 'w', snap.CharAt(6))
+	require.Equal(t, []int{6}, snap.FindIn("wor", 0, snap.Length()))
+
+	// Two snapshots, the gap writable for both is what's gap in each
+	gb.MoveGapTo(4)
+	first := gb.Snapshot()
+	gb.InsertString("!")
+	second := gb.Snapshot()
+	gb.MoveGapTo(0)
+	gb.InsertString(">")
+	require.Equal(t, ">bye,! world", gb.String())
+	require.Equal(t, "bye, world", first.String())
+	require.Equal(t, "bye,! world", second.String())
 }
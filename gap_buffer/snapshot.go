@@ -0,0 +1,54 @@
+package gapbuffer
+
+import "slices"
+
+// Snapshot is the text of a gap buffer at one moment. It never changes, so another goroutine can read it while
+// the buffer goes on being edited.
+type Snapshot struct {
+	gb GapBuffer
+}
+
+// Snapshot freezes the current text. The storage is shared rather than copied: writes into the gap go on in
+// place since no snapshot reads there, anything else copies the storage first.
+func (gb *GapBuffer) Snapshot() *Snapshot {
+	if gb.shared {
+		// Older snapshots may still be read, only what's gap for all of them stays writable
+		gb.writableStart = max(gb.writableStart, gb.gapStart)
+		gb.writableEnd = min(gb.writableEnd, gb.gapEnd)
+	} else {
+		gb.shared = true
+		gb.writableStart, gb.writableEnd = gb.gapStart, gb.gapEnd
+	}
+	return &Snapshot{gb: GapBuffer{buffer: gb.buffer, gapStart: gb.gapStart, gapEnd: gb.gapEnd}}
+}
+
+// beforeWrite is called before storage start..end is written. When a snapshot reads any of it, the buffer gets
+// its own copy.
+func (gb *GapBuffer) beforeWrite(start, end int) {
+	if !gb.shared || start >= end || (start >= gb.writableStart && end <= gb.writableEnd) {
+		return
+	}
+	gb.buffer = slices.Clone(gb.buffer)
+	gb.shared = false
+}
+
+func (s *Snapshot) String() string {
+	return s.gb.String()
+}
+
+func (s *Snapshot) Length() int {
+	return s.gb.Length()
+}
+
+func (s *Snapshot) CharAt(pos int) rune {
+	return s.gb.CharAt(pos)
+}
+
+func (s *Snapshot) Substring(start, end int) string {
+	return s.gb.Substring(start, end)
+}
+
+// FindIn is GapBuffer.FindIn on the snapshot.
+func (s *Snapshot) FindIn(needle string, start, end int) []int {
+	return s.gb.FindIn(needle, start, end)
+}
@@ -0,0 +1,195 @@
+package gitignore
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Matcher holds the rules of every .gitignore added so far. Paths are slash separated and relative to the
+// directory the matcher was made for.
+type Matcher struct {
+	rules []rule
+}
+
+type rule struct {
+	base    string // Directory of the .gitignore the rule came from, "" for the root
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+func New() *Matcher {
+	return &Matcher{}
+}
+
+// Load returns a matcher with the root's .gitignore and .git/info/exclude. Nested .gitignore files are added
+// with AddFile as their directories are read.
+func Load(root string) (*Matcher, error) {
+	m := New()
+	if err := m.AddFile(root, ""); err != nil {
+		return nil, err
+	}
+	if err := m.addPath(filepath.Join(root, ".git", "info", "exclude"), ""); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AddFile reads the .gitignore in dir (relative to root) if there is one.
+func (m *Matcher) AddFile(root, dir string) error {
+	return m.addPath(filepath.Join(root, filepath.FromSlash(dir), ".gitignore"), dir)
+}
+
+func (m *Matcher) addPath(file, base string) error {
+	f, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m.Add(base, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// Add parses one .gitignore line from the file in directory base. Blank lines and comments are skipped.
+func (m *Matcher) Add(base, line string) {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	r := rule{base: base}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return
+	}
+
+	// A slash at the start or in the middle ties the pattern to base, otherwise it matches at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if anchored || strings.HasPrefix(line, "**/") {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "(?:^|/)" + expr + "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return
+	}
+	r.re = re
+	m.rules = append(m.rules, r)
+}
+
+// Match reports whether path is ignored. The last rule that matches decides, like in git. Callers walk down from
+// the root and skip ignored directories, so a path inside an ignored directory isn't checked on its own.
+func (m *Matcher) Match(p string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		rel := p
+		if r.base != "" {
+			var ok bool
+			rel, ok = strings.CutPrefix(p, r.base+"/")
+			if !ok {
+				continue
+			}
+		}
+		if r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp turns a gitignore glob into a regexp body. "*" and "?" stop at slashes, "**" spans directories.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case r == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			atStart := i == 0 || runes[i-1] == '/'
+			atEnd := i+2 == len(runes)
+			switch {
+			case atStart && i+2 < len(runes) && runes[i+2] == '/':
+				b.WriteString("(?:.*/)?") // "**/" is zero or more directories
+				i += 2
+			case atStart && atEnd:
+				b.WriteString(".*") // "dir/**" is everything inside
+				i++
+			default:
+				b.WriteString("[^/]*")
+				i++
+			}
+		case r == '*':
+			b.WriteString("[^/]*")
+		case r == '?':
+			b.WriteString("[^/]")
+		case r == '[':
+			end := strings.IndexRune(string(runes[i+1:]), ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := string(runes[i+1 : i+1+end])
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// trimTrailingSpaces drops trailing spaces unless the last one is escaped with a backslash.
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-2] + " "
+	}
+	return line
+}
+
+// Rel returns target relative to root with forward slashes, the form Match expects.
+func Rel(root, target string) (string, error) {
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return "", err
+	}
+	return path.Clean(filepath.ToSlash(rel)), nil
+}
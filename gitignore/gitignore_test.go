@@ -0,0 +1,97 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func matcher(lines ...string) *Matcher {
+	m := New()
+	for _, line := range lines {
+		m.Add("", line)
+	}
+	return m
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"name at any depth", []string{"*.log"}, "a/b/debug.log", false, true},
+		{"name at root", []string{"*.log"}, "debug.log", false, true},
+		{"no match", []string{"*.log"}, "debug.txt", false, false},
+		{"star stops at slash", []string{"a*c"}, "ab/c", false, false},
+		{"question mark", []string{"file?.go"}, "file1.go", false, true},
+		{"class", []string{"file[0-9].go"}, "file7.go", false, true},
+		{"negated class", []string{"file[!0-9].go"}, "file7.go", false, false},
+		{"anchored with leading slash", []string{"/build"}, "build", true, true},
+		{"anchored doesn't match deeper", []string{"/build"}, "src/build", true, false},
+		{"middle slash anchors", []string{"doc/*.txt"}, "doc/notes.txt", false, true},
+		{"middle slash anchors deeper", []string{"doc/*.txt"}, "a/doc/notes.txt", false, false},
+		{"dir only matches dir", []string{"out/"}, "out", true, true},
+		{"dir only skips file", []string{"out/"}, "out", false, false},
+		{"leading double star", []string{"**/logs"}, "a/b/logs", true, true},
+		{"leading double star at root", []string{"**/logs"}, "logs", true, true},
+		{"trailing double star", []string{"vendor/**"}, "vendor/x/y.go", false, true},
+		{"middle double star", []string{"a/**/b"}, "a/x/y/b", false, true},
+		{"middle double star none", []string{"a/**/b"}, "a/b", false, true},
+		{"negation", []string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{"last rule wins", []string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"comment", []string{"# *.go"}, "main.go", false, false},
+		{"escaped hash", []string{`\#notes`}, "#notes", false, true},
+		{"trailing spaces", []string{"tmp   "}, "tmp", false, true},
+		{"escaped trailing space", []string{`tmp\ `}, "tmp ", false, true},
+		{"dot is literal", []string{"a.b"}, "axb", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.ignored, matcher(tt.lines...).Match(tt.path, tt.isDir))
+		})
+	}
+}
+
+func TestNestedBase(t *testing.T) {
+	m := New()
+	m.Add("sub", "*.tmp")
+	m.Add("sub", "/only")
+
+	require.True(t, m.Match("sub/a.tmp", false))
+	require.True(t, m.Match("sub/deep/a.tmp", false))
+	require.False(t, m.Match("a.tmp", false), "rules only apply below their directory")
+	require.True(t, m.Match("sub/only", false))
+	require.False(t, m.Match("sub/deep/only", false))
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.o\n!main.o\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git", "info"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".git", "info", "exclude"), []byte("secret\n"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(root, "lib"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "lib", ".gitignore"), []byte("gen/\n"), 0o644))
+
+	m, err := Load(root)
+	require.NoError(t, err)
+	require.True(t, m.Match("x.o", false))
+	require.False(t, m.Match("main.o", false))
+	require.True(t, m.Match("secret", false))
+	require.False(t, m.Match("lib/gen", true), "nested files are added separately")
+
+	require.NoError(t, m.AddFile(root, "lib"))
+	require.True(t, m.Match("lib/gen", true))
+
+	require.NoError(t, m.AddFile(root, "missing"))
+}
+
+func TestRel(t *testing.T) {
+	rel, err := Rel("/a/b", "/a/b/c/d.go")
+	require.NoError(t, err)
+	require.Equal(t, "c/d.go", rel)
+}
@@ -2,14 +2,16 @@ module github.com/ogzhanolguncu/go_editor
 
 go 1.24.2
 
-require github.com/stretchr/testify v1.10.0
+require (
+	github.com/alecthomas/chroma/v2 v2.20.0
+	github.com/gdamore/tcell/v2 v2.9.0
+	github.com/stretchr/testify v1.10.0
+)
 
 require (
-	github.com/alecthomas/chroma/v2 v2.20.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/dlclark/regexp2 v1.11.5 // indirect
 	github.com/gdamore/encoding v1.0.1 // indirect
-	github.com/gdamore/tcell/v2 v2.9.0 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-runewidth v0.0.16 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
@@ -0,0 +1,159 @@
+// Package gotest reads the output of "go test -json" into per-test results, and finds the test functions in Go
+// source so the results can be shown next to them.
+package gotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Status is where a test is at.
+type Status int
+
+const (
+	Running Status = iota + 1
+	Passed
+	Failed
+	Skipped
+)
+
+// Event is a line of "go test -json", see "go doc test2json".
+type Event struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// Test is one test's result so far. Subtests are tests of their own, named "TestParent/sub".
+type Test struct {
+	Package string
+	Name    string
+	Status  Status
+	Elapsed float64  // Seconds
+	Output  []string // Lines it printed, "--- FAIL" and friends included
+}
+
+// Report collects the events of a run. Output is written to it as it comes, in pieces of any size.
+type Report struct {
+	Tests   []*Test  // In the order they started
+	Output  []string // Lines that belong to no test: package results, build errors, anything that wasn't JSON
+	Failed  bool     // A package failed, which is also how build errors show up
+	partial []byte   // Start of a line that didn't end yet
+	index   map[string]*Test
+}
+
+func NewReport() *Report {
+	return &Report{index: make(map[string]*Test)}
+}
+
+// Write adds the complete lines in p, keeping a partial last line for the next call.
+func (r *Report) Write(p []byte) (int, error) {
+	data := append(r.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		r.addLine(data[:i])
+		data = data[i+1:]
+	}
+	r.partial = bytes.Clone(data)
+	return len(p), nil
+}
+
+// Close adds a last line that had no newline.
+func (r *Report) Close() {
+	if len(r.partial) > 0 {
+		r.addLine(r.partial)
+		r.partial = nil
+	}
+}
+
+// Test looks a test up by package and name.
+func (r *Report) Test(pkg, name string) (*Test, bool) {
+	t, ok := r.index[pkg+" "+name]
+	return t, ok
+}
+
+// FailedTests are the tests that failed, in the order they started.
+func (r *Report) FailedTests() []*Test {
+	var failed []*Test
+	for _, t := range r.Tests {
+		if t.Status == Failed {
+			failed = append(failed, t)
+		}
+	}
+	return failed
+}
+
+func (r *Report) addLine(line []byte) {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	var ev Event
+	if len(line) == 0 || line[0] != '{' || json.Unmarshal(line, &ev) != nil {
+		if len(bytes.TrimSpace(line)) > 0 {
+			r.Output = append(r.Output, string(line))
+		}
+		return
+	}
+	r.add(ev)
+}
+
+func (r *Report) add(ev Event) {
+	if ev.Test == "" {
+		switch ev.Action {
+		case "output", "build-output":
+			r.Output = append(r.Output, strings.TrimRight(ev.Output, "\n"))
+		case "fail", "build-fail":
+			r.Failed = true
+		}
+		return
+	}
+
+	t, ok := r.Test(ev.Package, ev.Test)
+	if !ok {
+		t = &Test{Package: ev.Package, Name: ev.Test, Status: Running}
+		r.Tests = append(r.Tests, t)
+		r.index[ev.Package+" "+ev.Test] = t
+	}
+	switch ev.Action {
+	case "output":
+		t.Output = append(t.Output, strings.TrimRight(ev.Output, "\n"))
+	case "pass":
+		t.Status, t.Elapsed = Passed, ev.Elapsed
+	case "fail":
+		t.Status, t.Elapsed = Failed, ev.Elapsed
+	case "skip":
+		t.Status, t.Elapsed = Skipped, ev.Elapsed
+	}
+}
+
+// testFunc matches what go test runs: the prefix alone, or followed by anything but a lowercase letter
+var testFunc = regexp.MustCompile(`^func ((?:Test|Example|Fuzz)(?:[^\p{Ll}\W]\w*)?)\(`)
+
+// FuncName returns the name of the test function a line declares, "TestFoo" for "func TestFoo(t *testing.T) {".
+// Examples and fuzz tests count, go test runs them the same way.
+func FuncName(line string) (string, bool) {
+	m := testFunc.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// FuncAt returns the test function line i is in. lines is the file, gofmt style, so functions start and end
+// at the first column.
+func FuncAt(lines []string, i int) (string, bool) {
+	for j := min(i, len(lines)-1); j >= 0; j-- {
+		if strings.HasPrefix(lines[j], "func ") {
+			return FuncName(lines[j])
+		}
+		if j != i && strings.HasPrefix(lines[j], "}") {
+			return "", false
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,101 @@
+package gotest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sample = `{"Action":"start","Package":"example.com/app"}
+{"Action":"run","Package":"example.com/app","Test":"TestAdd"}
+{"Action":"output","Package":"example.com/app","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"output","Package":"example.com/app","Test":"TestAdd","Output":"    add_test.go:12: got 3, want 4\n"}
+{"Action":"output","Package":"example.com/app","Test":"TestAdd","Output":"--- FAIL: TestAdd (0.00s)\n"}
+{"Action":"fail","Package":"example.com/app","Test":"TestAdd","Elapsed":0.01}
+{"Action":"run","Package":"example.com/app","Test":"TestSub"}
+{"Action":"pass","Package":"example.com/app","Test":"TestSub","Elapsed":0}
+{"Action":"run","Package":"example.com/app","Test":"TestSlow"}
+{"Action":"output","Package":"example.com/app","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/app","Elapsed":0.02}
+`
+
+func TestReport(t *testing.T) {
+	r := NewReport()
+	// Pieces that split lines anywhere, the way a pipe hands them over
+	for chunk := range chunks(sample, 7) {
+		_, _ = r.Write([]byte(chunk))
+	}
+	r.Close()
+
+	require.Len(t, r.Tests, 3)
+	add, ok := r.Test("example.com/app", "TestAdd")
+	require.True(t, ok)
+	require.Equal(t, Failed, add.Status)
+	require.Equal(t, []string{"=== RUN   TestAdd", "    add_test.go:12: got 3, want 4", "--- FAIL: TestAdd (0.00s)"}, add.Output)
+
+	sub, _ := r.Test("example.com/app", "TestSub")
+	require.Equal(t, Passed, sub.Status)
+	slow, _ := r.Test("example.com/app", "TestSlow")
+	require.Equal(t, Running, slow.Status)
+
+	require.Equal(t, []*Test{add}, r.FailedTests())
+	require.True(t, r.Failed)
+	require.Equal(t, []string{"FAIL"}, r.Output)
+}
+
+func TestReportBuildError(t *testing.T) {
+	r := NewReport()
+	_, _ = r.Write([]byte("# example.com/app\n./add_test.go:5:2: undefined: x\nFAIL\texample.com/app [build failed]"))
+	r.Close()
+	require.Empty(t, r.Tests)
+	require.Equal(t, []string{"# example.com/app", "./add_test.go:5:2: undefined: x", "FAIL\texample.com/app [build failed]"}, r.Output)
+}
+
+func TestFuncAt(t *testing.T) {
+	lines := strings.Split(`package app
+
+func helper() {}
+
+func TestAdd(t *testing.T) {
+	if add(1, 2) != 4 {
+		t.Fatal("no")
+	}
+}
+
+var x = 1
+
+func ExampleAdd() {
+}`, "\n")
+
+	for line, want := range map[int]string{4: "TestAdd", 6: "TestAdd", 8: "TestAdd", 12: "ExampleAdd", 13: "ExampleAdd"} {
+		name, ok := FuncAt(lines, line)
+		require.True(t, ok, "line %d", line)
+		require.Equal(t, want, name, "line %d", line)
+	}
+	for _, line := range []int{0, 2, 9, 10, 11} {
+		_, ok := FuncAt(lines, line)
+		require.False(t, ok, "line %d", line)
+	}
+
+	_, ok := FuncName("func Testing() {")
+	require.False(t, ok, "go test doesn't run it")
+	name, ok := FuncName("func Test_add(t *testing.T) {")
+	require.True(t, ok)
+	require.Equal(t, "Test_add", name)
+	_, ok = FuncName("func (s *suite) TestAdd() {")
+	require.False(t, ok)
+}
+
+// chunks cuts s into pieces of n bytes.
+func chunks(s string, n int) func(func(string) bool) {
+	return func(yield func(string) bool) {
+		for len(s) > 0 {
+			k := min(n, len(s))
+			if !yield(s[:k]) {
+				return
+			}
+			s = s[k:]
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Package grep searches the files of a project for a pattern in the background. A pool of workers reads the
+// files the walk finds, so results stream in while the search runs and it can be stopped at any time.
+package grep
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ogzhanolguncu/go_editor/finder"
+)
+
+const (
+	notifyEvery = 50 * time.Millisecond
+	maxFileSize = 16 << 20 // Bigger files are skipped, they're data rather than code
+	binarySniff = 8000     // Bytes looked at for a NUL, like git does
+)
+
+// Options changes how the pattern is read.
+type Options struct {
+	Literal    bool // The pattern is plain text, not a regexp
+	IgnoreCase bool
+	Workers    int // Files read at once, 0 means one per CPU
+}
+
+// Match is the first match on a line.
+type Match struct {
+	Path string // Slash separated, relative to the root
+	Line int    // 1-based
+	Col  int    // 1-based, in runes
+	Text string // The whole line
+}
+
+// Compile turns a pattern into the regexp Start searches with.
+func Compile(pattern string, opts Options) (*regexp.Regexp, error) {
+	if opts.Literal {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// Search is a running or finished search.
+type Search struct {
+	mu      sync.Mutex
+	matches []Match
+	files   int // Files with at least one match
+	done    bool
+	err     error
+	cancel  context.CancelFunc
+
+	notify     func()
+	lastNotify time.Time
+}
+
+// Start searches the files under root that .gitignore files don't exclude, skipping binary files. notify is
+// called from the search's goroutines when matches were added and once more when the search ends, it must be
+// safe to call from there. A pattern that doesn't compile is returned as an error before anything starts.
+func Start(root, pattern string, opts Options, notify func()) (*Search, error) {
+	re, err := Compile(pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	// The same pattern with ^ and $ at every line, for the pass over the whole file
+	whole, err := regexp.Compile("(?m)" + re.String())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Search{cancel: cancel, notify: notify}
+	go s.run(ctx, root, matcher{line: re, whole: whole}, workers)
+	return s, nil
+}
+
+// Matches returns what was found so far and whether the search is over. Matches of one file are always next to
+// each other, files come in the order workers finish them. The slice must not be modified.
+func (s *Search) Matches() ([]Match, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.matches, s.done
+}
+
+// Files is how many files matched so far.
+func (s *Search) Files() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.files
+}
+
+// Err is why the search stopped early, if it did. Stopping it isn't an error.
+func (s *Search) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Stop ends a running search. What was found stays.
+func (s *Search) Stop() {
+	s.cancel()
+}
+
+// matcher is the pattern compiled for single lines and for whole files.
+type matcher struct {
+	line  *regexp.Regexp
+	whole *regexp.Regexp
+}
+
+func (s *Search) run(ctx context.Context, root string, re matcher, workers int) {
+	paths := make(chan string, workers*4)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rel := range paths {
+				if ctx.Err() != nil {
+					continue
+				}
+				if matches := searchFile(filepath.Join(root, filepath.FromSlash(rel)), rel, re); len(matches) > 0 {
+					s.add(matches)
+				}
+			}
+		}()
+	}
+
+	err := finder.Walk(ctx, root, func(rel string) error {
+		select {
+		case paths <- rel:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(paths)
+	wg.Wait()
+	if ctx.Err() != nil {
+		err = nil
+	}
+
+	s.mu.Lock()
+	s.done = true
+	s.err = err
+	s.mu.Unlock()
+	if s.notify != nil {
+		s.notify()
+	}
+}
+
+func (s *Search) add(matches []Match) {
+	s.mu.Lock()
+	s.matches = append(s.matches, matches...)
+	s.files++
+	notify := s.notify != nil && time.Since(s.lastNotify) >= notifyEvery
+	if notify {
+		s.lastNotify = time.Now()
+	}
+	s.mu.Unlock()
+	if notify {
+		s.notify()
+	}
+}
+
+// searchFile returns the first match of every matching line. Unreadable, huge and binary files have none.
+func searchFile(path, rel string, re matcher) []Match {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxFileSize {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data[:min(len(data), binarySniff)], 0) >= 0 {
+		return nil
+	}
+	// Most files don't match at all, one pass over the whole file finds out fastest. With CRLF line ends a $ would
+	// stop at the \r, which lines lose before they're matched, so those files are matched line by line only
+	if bytes.IndexByte(data, '\r') < 0 && !re.whole.Match(data) {
+		return nil
+	}
+
+	var matches []Match
+	for n := 1; len(data) > 0; n++ {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if loc := re.line.FindIndex(line); loc != nil {
+			matches = append(matches, Match{
+				Path: rel,
+				Line: n,
+				Col:  utf8.RuneCount(line[:loc[0]]) + 1,
+				Text: string(line),
+			})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,120 @@
+package grep
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func makeFiles(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return root
+}
+
+func wait(t *testing.T, s *Search) []Match {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if matches, done := s.Matches(); done {
+			matches = slices.Clone(matches)
+			slices.SortFunc(matches, func(a, b Match) int {
+				if c := strings.Compare(a.Path, b.Path); c != 0 {
+					return c
+				}
+				return a.Line - b.Line
+			})
+			return matches
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("search didn't finish")
+	return nil
+}
+
+func TestSearchRegexp(t *testing.T) {
+	root := makeFiles(t, map[string]string{
+		"main.go":     "package main\n\nfunc main() {\n\tfoo(1)\n\tfoo3()\r\n}\n",
+		"lib/util.go": "// héllo foo2\nbar\n",
+		"data.bin":    "foo1\x00foo1",
+		"out.log":     "foo1\n",
+		".gitignore":  "*.log\n",
+	})
+
+	notified := make(chan struct{}, 100)
+	s, err := Start(root, `foo\d`, Options{Workers: 2}, func() { notified <- struct{}{} })
+	require.NoError(t, err)
+	matches := wait(t, s)
+
+	require.Equal(t, []Match{
+		{Path: "lib/util.go", Line: 1, Col: 10, Text: "// héllo foo2"},
+		{Path: "main.go", Line: 5, Col: 2, Text: "\tfoo3()"},
+	}, matches, "binary and ignored files are skipped")
+	require.Equal(t, 2, s.Files())
+	require.NoError(t, s.Err())
+	require.NotEmpty(t, notified)
+}
+
+func TestSearchLiteralIgnoreCase(t *testing.T) {
+	root := makeFiles(t, map[string]string{
+		"a.txt": "x := a.b(c)\nA.B(C) twice A.B(C)\nabc\n",
+	})
+
+	s, err := Start(root, "a.b(c)", Options{Literal: true, IgnoreCase: true}, nil)
+	require.NoError(t, err)
+	matches := wait(t, s)
+
+	require.Len(t, matches, 2, "one match per line")
+	require.Equal(t, 1, matches[0].Line)
+	require.Equal(t, 6, matches[0].Col)
+	require.Equal(t, 2, matches[1].Line)
+	require.Equal(t, 1, matches[1].Col)
+}
+
+func TestSearchAnchored(t *testing.T) {
+	root := makeFiles(t, map[string]string{
+		"a.go":  "package a\n\nfunc A() {}\n\tfunc inner() {}\n",
+		"b.go":  "package b\r\n\r\nfunc B() {}\r\n",
+		"c.txt": "no func here\n",
+		"d.txt": "ends with {}",
+	})
+
+	s, err := Start(root, "^func", Options{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []Match{
+		{Path: "a.go", Line: 3, Col: 1, Text: "func A() {}"},
+		{Path: "b.go", Line: 3, Col: 1, Text: "func B() {}"},
+	}, wait(t, s), "^ matches at every line start, not only the file's")
+
+	s, err = Start(root, `\{\}$`, Options{}, nil)
+	require.NoError(t, err)
+	matches := wait(t, s)
+	require.Len(t, matches, 4, "$ matches at every line end, CRLF ones too")
+	require.Equal(t, 3, matches[0].Line)
+	require.Equal(t, 4, matches[1].Line)
+	require.Equal(t, "b.go", matches[2].Path)
+	require.Equal(t, "d.txt", matches[3].Path)
+}
+
+func TestBadPattern(t *testing.T) {
+	_, err := Start(t.TempDir(), "foo(", Options{}, nil)
+	require.Error(t, err)
+}
+
+func TestStop(t *testing.T) {
+	root := makeFiles(t, map[string]string{"a.txt": "foo\n"})
+	s, err := Start(root, "foo", Options{}, nil)
+	require.NoError(t, err)
+	s.Stop()
+	wait(t, s)
+	require.NoError(t, s.Err(), "stopping isn't an error")
+}
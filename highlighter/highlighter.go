@@ -1,45 +1,432 @@
+// Package highlighter splits lines of source into chroma tokens for syntax highlighting. Lexing a line right
+// needs to know what came before it, a line inside a block comment or a raw string is all comment or string, so
+// the tokens of every line down to the last one asked for are cached and an edit drops them from its line down.
+// The lexer sees a window of lines at a time rather than the rest of the text, so a keystroke costs a window's
+// worth of lexing however long the file is.
+// Lexing can run in a goroutine over a snapshot of the text, its results are handed back to the goroutine that
+// owns the highlighter as updates. Lines far down the text get a guess first, lexed from a line near them, so what
+// is on screen isn't plain until everything above it is lexed.
 package highlighter
 
-// # Chroma Integration Checklist
-//
-// ## **Setup**
-// - [ ] `go get github.com/alecthomas/chroma/v2`
-// - [ ] Create `highlighter/` package
-//
-// ## **1. highlighter/highlighter.go**
-// - [ ] `Token` struct: Type, Value, Start, End
-// - [ ] `Highlighter` struct: lexer, style, filename
-// - [ ] `New(filename)` - auto-detect language
-// - [ ] `TokenizeLine(lineNum, content)` - returns []Token
-// - [ ] Internal `tokenize(content)` - chroma tokenization
-// - [ ] `SetTheme(name)`, `GetTheme()`, `ListThemes()`
-//
-// ## **2. editor/editor.go**
-// - [ ] Add `highlighter` field to Editor
-// - [ ] Initialize in `New()`
-// - [ ] `GetHighlighter()`, `SetTheme()`, `GetTheme()`, `ListThemes()` passthroughs
-// - [ ] Invalidate on edits: `InvalidateLine()` in InsertChar/Delete, `InvalidateAll()` on newlines
-//
-// ## **3. screen/palette.go**
-// - [ ] Add syntax style fields: keyword, string, comment, number, function, type, operator, identifier
-// - [ ] Define colors in `NewPalette()`
-// - [ ] `StyleForToken(chroma.TokenType)` - map tokens to styles
-//
-// ## **4. screen/screen.go**
-// - [ ] Update `renderLines()`: get highlighter, call `TokenizeLine()` per line
-// - [ ] For each char, find token, apply style + preserve current line background
-//
-// ## **5. screen/input.go**
-// - [ ] Add `Ctrl-T` to cycle themes (temp testing)
-//
-// ## **6. Testing**
-// - [ ] Test .go, .py, .txt files
-// - [ ] Test typing, newlines, deletes
-// - [ ] Test theme switching
-// - [ ] Test large files for lag
-//
-// ## **Cache (Only if slow)**
-// - [ ] `TokenCache` struct: map[int][]Token, maxSize
-// - [ ] `Get()`, `Set()`, `InvalidateLine()`, `InvalidateFrom()`, `InvalidateAll()`
-// - [ ] Add to Highlighter, check cache in `TokenizeLine()`
-//
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+const (
+	chunkLines      = 100  // How many lines past the asked one a lex goes, for the lines drawn below it
+	backgroundChunk = 2000 // Lines a background lex delivers at a time once the asked ones are done
+	retryDelay      = 10 * time.Millisecond
+
+	// lookahead is how many lines past the last one it needs a lex shows the lexer. Chroma copies all the text it's
+	// given, so it doesn't get the rest of the file. A construct open for longer, a comment closing further down,
+	// lexes as if it didn't open
+	lookahead   = 1000
+	windowBlock = 4096 // Chars a window is read from a snapshot in at a time
+)
+
+// Token is a run of chars of one type on a line, Start..End in rune columns, End exclusive.
+type Token struct {
+	Type  chroma.TokenType
+	Start int
+	End   int
+}
+
+// Source is the text to highlight, a line at a time with its newline. The editor's text buffer is one.
+type Source interface {
+	LineCount() int
+	Line(lineNum int) string
+}
+
+// Snapshotter is a Source that hands a background lex a frozen copy of its text, the editor's buffer through a
+// copy-on-write snapshot.
+type Snapshotter interface {
+	Source
+	LineToChar(lineNum int) int
+	Snapshot() Text
+}
+
+// Text is a Source's text at one moment in char positions. It never changes, so a goroutine can read it.
+type Text interface {
+	Length() int
+	Substring(start, end int) string
+}
+
+// line is a highlighted line.
+type line struct {
+	tokens []Token
+	// endsClean is set when the line's newline lexed as plain whitespace, nothing is left open after it and the
+	// next line can be lexed on its own
+	endsClean bool
+}
+
+type Highlighter struct {
+	lexer chroma.Lexer
+	// Highlighted lines from the first one down. Past valid they're from before an edit, moved to where their
+	// lines went, to draw until the lines are lexed again
+	lines []line
+	valid int
+	gen   int  // Bumped by every edit, updates lexed before it are dropped
+	job   *job // Background lex of the current generation, nil when none runs
+}
+
+// job is a background lex.
+type job struct {
+	gen     int
+	stopped atomic.Bool
+}
+
+// Update is a run of lines a background lex highlighted, for Apply.
+type Update struct {
+	h     *Highlighter
+	job   *job
+	gen   int
+	start int
+	lines []line
+	done  bool // The lex got to the end of the text
+	guess bool // Lexed from a line that may not start clean, the lines aren't current
+}
+
+// guessWindow is the stretch of text a guess lexes: lines line..line+lines from char from to char to.
+type guessWindow struct {
+	line, lines int
+	from, to    int
+}
+
+// New picks the lexer from the filename. Files it knows no language for get no tokens.
+func New(filename string) *Highlighter {
+	h := &Highlighter{}
+	if lexer := lexers.Match(filename); lexer != nil {
+		h.lexer = chroma.Coalesce(lexer)
+	}
+	return h
+}
+
+// Language is the lexer's name, "Go", empty when there's none.
+func (h *Highlighter) Language() string {
+	if h.lexer == nil {
+		return ""
+	}
+	return h.lexer.Config().Name
+}
+
+// TokenizeLine returns the tokens of line lineNum of src, text without a token is plain. Lines above it that
+// aren't cached yet are lexed on the way.
+func (h *Highlighter) TokenizeLine(lineNum int, src Source) []Token {
+	if h.lexer == nil || lineNum < 0 || lineNum >= src.LineCount() {
+		return nil
+	}
+	h.extend(lineNum, src)
+	return h.lines[lineNum].tokens
+}
+
+// Tokens returns the cached tokens of a line without lexing anything. They may be from before an edit, or
+// missing when the line was never highlighted; current is false then.
+func (h *Highlighter) Tokens(lineNum int) (tokens []Token, current bool) {
+	if h.lexer == nil {
+		return nil, true
+	}
+	if lineNum < 0 || lineNum >= len(h.lines) {
+		return nil, false
+	}
+	return h.lines[lineNum].tokens, lineNum < h.valid
+}
+
+// Background starts lexing a snapshot of src in a goroutine, from the first line that isn't current to the end.
+// lineNum and the lines below it, what's on screen, are delivered first. When they're far down they get a guess
+// before that, lexed from a line near them, which the lex from the top corrects. The rest follows in chunks.
+// Nothing starts when lineNum is current or a lex already runs. deliver is called from the goroutine and reports
+// whether it took the update, it's offered again until it does.
+func (h *Highlighter) Background(lineNum int, src Snapshotter, deliver func(Update) bool) {
+	if h.lexer == nil || lineNum < h.valid || lineNum >= src.LineCount() || h.job != nil {
+		return
+	}
+	start := h.restart()
+	var guess *guessWindow
+	if first, ok := h.guessStart(lineNum, start, src); ok {
+		last := min(lineNum+chunkLines, src.LineCount()-1)
+		guess = &guessWindow{line: first, lines: last - first + 1, from: src.LineToChar(first), to: lineEnd(src, last)}
+	}
+	j := &job{gen: h.gen}
+	h.job = j
+	go j.run(h, h.lexer, src.Snapshot(), start, src.LineToChar(start), lineNum+chunkLines, guess, deliver)
+}
+
+// guessStart picks where to lex lineNum from when the lex from start would go through many lines above it
+// first: below the nearest line that ended clean before the last edit, or else below a blank line. Lines lexed
+// from there may still be wrong, inside a comment that opened further up.
+func (h *Highlighter) guessStart(lineNum, start int, src Source) (int, bool) {
+	if lineNum-start <= chunkLines {
+		return 0, false
+	}
+	top := lineNum - chunkLines
+	for i := lineNum; i > top; i-- {
+		if i-1 < len(h.lines) && h.lines[i-1].endsClean || strings.TrimSpace(src.Line(i-1)) == "" {
+			return i, true
+		}
+	}
+	return top, true
+}
+
+// lineEnd is the char position right after line lineNum and its newline.
+func lineEnd(src Snapshotter, lineNum int) int {
+	return src.LineToChar(lineNum) + utf8.RuneCountInString(src.Line(lineNum))
+}
+
+// Apply stores the lines of an update, on the goroutine that owns the highlighter. Updates lexed before an edit
+// are dropped, false then.
+func (u Update) Apply() bool {
+	h := u.h
+	if h == nil || u.gen != h.gen {
+		return false
+	}
+	if u.guess {
+		h.storeGuess(u.start, u.lines)
+		return true
+	}
+	if u.start > h.valid {
+		// Lines above it weren't stored, the lex has to start over from the first line that isn't current
+		if u.job == h.job {
+			h.Stop()
+		}
+		return false
+	}
+	h.store(u.start, u.lines)
+	// A lex stopped by extend still delivers good lines, but its end isn't the end of the one running now
+	if u.done && u.job == h.job {
+		h.job = nil
+	}
+	return true
+}
+
+// Edit records that lines lineNum..lineNum+removed were replaced by inserted+1 new lines. Tokens from lineNum down
+// are no longer current and a running background lex is stopped.
+func (h *Highlighter) Edit(lineNum, removed, inserted int) {
+	h.Stop()
+	h.gen++
+	lineNum = max(lineNum, 0)
+	h.valid = min(h.valid, lineNum)
+	if lineNum+removed >= len(h.lines) {
+		h.lines = h.lines[:min(lineNum, len(h.lines))]
+		return
+	}
+	// The edited line keeps its colors until it's lexed again rather than flash plain
+	fresh := make([]line, inserted+1)
+	fresh[0].tokens = h.lines[lineNum].tokens
+	h.lines = append(h.lines[:lineNum], append(fresh, h.lines[lineNum+removed+1:]...)...)
+}
+
+// Stop stops a running background lex, its updates are dropped.
+func (h *Highlighter) Stop() {
+	if h.job != nil {
+		h.job.stopped.Store(true)
+		h.job = nil
+	}
+}
+
+// restart is the line a lex of what isn't current starts from, the last one that starts clean.
+func (h *Highlighter) restart() int {
+	start := h.valid
+	for start > 0 && !h.lines[start-1].endsClean {
+		start--
+	}
+	return start
+}
+
+// store puts lexed lines in place from line start, they're current up to their end.
+func (h *Highlighter) store(start int, lexed []line) {
+	end := start + len(lexed)
+	if end > len(h.lines) {
+		h.lines = append(h.lines[:start], lexed...)
+	} else {
+		copy(h.lines[start:], lexed)
+	}
+	h.valid = end
+}
+
+// storeGuess puts guessed lines in place from line start, past the current ones. They stay not current.
+func (h *Highlighter) storeGuess(start int, guessed []line) {
+	for len(h.lines) < start+len(guessed) {
+		h.lines = append(h.lines, line{})
+	}
+	for i, l := range guessed {
+		if start+i >= h.valid {
+			h.lines[start+i] = l
+		}
+	}
+}
+
+// extend lexes lines until target is current, and on for a while past it for the lines that are asked next.
+func (h *Highlighter) extend(target int, src Source) {
+	if target < h.valid {
+		return
+	}
+	h.Stop()
+	start := h.restart()
+	stop := target + chunkLines
+	end := min(src.LineCount(), stop+lookahead)
+	var lexed []line
+	tokenize(h.lexer, snapshot(src, start, end), end-start, func(l line) bool {
+		lexed = append(lexed, l)
+		return start+len(lexed) <= stop || !l.endsClean
+	})
+	h.store(start, lexed)
+}
+
+func (j *job) run(h *Highlighter, lexer chroma.Lexer, text Text, start, from, priority int, guess *guessWindow, deliver func(Update) bool) {
+	if guess != nil {
+		g := Update{h: h, job: j, gen: j.gen, start: guess.line, guess: true}
+		tokenize(lexer, text.Substring(guess.from, guess.to), guess.lines, func(l line) bool {
+			g.lines = append(g.lines, l)
+			return !j.stopped.Load()
+		})
+		if j.stopped.Load() || !j.send(g, deliver) {
+			return
+		}
+	}
+
+	// Updates but the last end on a clean line, the window for the next one is lexed from there
+	u := Update{h: h, job: j, gen: j.gen, start: start}
+	next, flush := start, priority
+	for {
+		w, lines, last := window(text, from, flush-next+lookahead)
+		taken, sent := 0, true
+		tokenize(lexer, w, lines, func(l line) bool {
+			if j.stopped.Load() {
+				return false
+			}
+			u.lines = append(u.lines, l)
+			next++
+			taken++
+			if next > flush && l.endsClean {
+				if sent = j.send(u, deliver); sent {
+					u = Update{h: h, job: j, gen: j.gen, start: next}
+					flush = next + backgroundChunk
+				}
+				return false
+			}
+			return true
+		})
+		if j.stopped.Load() || !sent {
+			return
+		}
+		if last && taken == lines {
+			break
+		}
+		from += windowChars(w, taken)
+	}
+	u.done = true
+	j.send(u, deliver)
+}
+
+// send offers an update until deliver takes it, the event queue it goes through may be full for a while.
+func (j *job) send(u Update, deliver func(Update) bool) bool {
+	for !deliver(u) {
+		if j.stopped.Load() {
+			return false
+		}
+		time.Sleep(retryDelay)
+	}
+	return true
+}
+
+// snapshot copies lines start..end of src, for a lex on the spot.
+func snapshot(src Source, start, end int) string {
+	var text strings.Builder
+	for i := start; i < end; i++ {
+		text.WriteString(src.Line(i))
+	}
+	return text.String()
+}
+
+// window reads lines lines of text from char from on, newlines included. last is set when the text ends first,
+// lines is how many it holds then, counting the one after the last newline.
+func window(text Text, from, lines int) (string, int, bool) {
+	var w strings.Builder
+	count := 0
+	for pos := from; pos < text.Length(); pos += windowBlock {
+		block := text.Substring(pos, min(pos+windowBlock, text.Length()))
+		for i := 0; ; {
+			n := strings.IndexByte(block[i:], '\n')
+			if n < 0 {
+				break
+			}
+			i += n + 1
+			if count++; count == lines {
+				w.WriteString(block[:i])
+				return w.String(), count, false
+			}
+		}
+		w.WriteString(block)
+	}
+	return w.String(), count + 1, true
+}
+
+// windowChars is how many chars the first lines lines of text take.
+func windowChars(text string, lines int) int {
+	chars := 0
+	for range lines {
+		line, rest, _ := strings.Cut(text, "\n")
+		chars += utf8.RuneCountInString(line) + 1
+		text = rest
+	}
+	return chars
+}
+
+// tokenize lexes text, which starts clean, and hands its first lines lines to yield in order until yield returns
+// false. Constructs open on a line see their end when it's in the text.
+func tokenize(lexer chroma.Lexer, text string, lines int, yield func(line) bool) {
+	count := min(strings.Count(text, "\n")+1, lines)
+	it, err := lexer.Tokenise(nil, text)
+	if err != nil {
+		for range count {
+			if !yield(line{endsClean: true}) {
+				return
+			}
+		}
+		return
+	}
+
+	var cur line
+	col := 0
+	for t := it(); t != chroma.EOF; t = it() {
+		for value := t.Value; value != ""; {
+			part, rest, newline := strings.Cut(value, "\n")
+			if n := utf8.RuneCountInString(part); n > 0 {
+				cur.add(t.Type, col, col+n)
+				col += n
+			}
+			if !newline {
+				break
+			}
+			cur.endsClean = t.Type == chroma.Text || t.Type == chroma.TextWhitespace
+			if count--; !yield(cur) || count == 0 {
+				return
+			}
+			cur, col = line{}, 0
+			value = rest
+		}
+	}
+	// The last line has no newline
+	if count > 0 {
+		cur.endsClean = true
+		yield(cur)
+	}
+}
+
+// add appends a token, growing the last one when it's the same type and they touch. Plain text gets none.
+func (l *line) add(typ chroma.TokenType, start, end int) {
+	if typ == chroma.Text || typ == chroma.TextWhitespace {
+		return
+	}
+	if n := len(l.tokens); n > 0 && l.tokens[n-1].Type == typ && l.tokens[n-1].End == start {
+		l.tokens[n-1].End = end
+		return
+	}
+	l.tokens = append(l.tokens, Token{Type: typ, Start: start, End: end})
+}
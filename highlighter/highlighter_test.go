@@ -0,0 +1,376 @@
+package highlighter
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// lines is a Source over a string.
+type lines []string
+
+func source(text string) lines {
+	return strings.SplitAfter(text, "\n")
+}
+
+func (l lines) LineCount() int          { return len(l) }
+func (l lines) Line(lineNum int) string { return l[lineNum] }
+func (l lines) Snapshot() Text          { return runes(strings.Join(l, "")) }
+
+func (l lines) LineToChar(lineNum int) int {
+	pos := 0
+	for _, text := range l[:lineNum] {
+		pos += utf8.RuneCountInString(text)
+	}
+	return pos
+}
+
+// runes is a Text over a string.
+type runes []rune
+
+func (r runes) Length() int { return len(r) }
+
+func (r runes) Substring(start, end int) string {
+	end = min(end, len(r))
+	if start >= end {
+		return ""
+	}
+	return string(r[start:end])
+}
+
+// typeAt is the token type at column col, Text when no token covers it.
+func typeAt(tokens []Token, col int) chroma.TokenType {
+	for _, t := range tokens {
+		if col >= t.Start && col < t.End {
+			return t.Type
+		}
+	}
+	return chroma.Text
+}
+
+func TestTokenizeLine(t *testing.T) {
+	h := New("main.go")
+	require.Equal(t, "Go", h.Language())
+	src := source("package main\n\nfunc main() { x := \"héllo\" }\n")
+
+	tokens := h.TokenizeLine(2, src)
+	require.Equal(t, chroma.KeywordDeclaration, typeAt(tokens, 0))
+	require.Equal(t, chroma.NameFunction, typeAt(tokens, 5))
+	require.Equal(t, chroma.Text, typeAt(tokens, 4), "whitespace gets no token")
+	// Columns count runes: the string ends at its closing quote
+	require.Equal(t, chroma.LiteralString, typeAt(tokens, 19))
+	require.Equal(t, chroma.LiteralString, typeAt(tokens, 25))
+	require.NotEqual(t, chroma.LiteralString, typeAt(tokens, 26))
+	require.Nil(t, h.TokenizeLine(5, src))
+}
+
+func TestMultiLine(t *testing.T) {
+	h := New("main.go")
+	src := source("package main\n/* a\nfunc b\n*/\nvar s = `raw\nfunc c`\nfunc d() {}\n")
+
+	require.True(t, typeAt(h.TokenizeLine(2, src), 0).InCategory(chroma.Comment), "inside a block comment")
+	require.True(t, typeAt(h.TokenizeLine(3, src), 0).InCategory(chroma.Comment))
+	require.True(t, typeAt(h.TokenizeLine(5, src), 0).InCategory(chroma.LiteralString), "inside a raw string")
+	require.Equal(t, chroma.KeywordDeclaration, typeAt(h.TokenizeLine(6, src), 0))
+
+	// Python strings are a lexer state, not one long match
+	h = New("x.py")
+	src = source("x = \"\"\"\nimport os\n\"\"\"\nimport os\n")
+	require.True(t, typeAt(h.TokenizeLine(1, src), 0).InCategory(chroma.LiteralString))
+	require.Equal(t, chroma.KeywordNamespace, typeAt(h.TokenizeLine(3, src), 0))
+}
+
+func TestInvalidate(t *testing.T) {
+	h := New("main.go")
+	src := source("package main\nvar a = 1\nfunc b() {}\nvar c = 2\n")
+	require.Equal(t, chroma.KeywordDeclaration, typeAt(h.TokenizeLine(3, src), 0))
+
+	// Opening a comment on line 1 turns what's below into comment once the lines are dropped
+	src[1] = "/* var a = 1\n"
+	src[3] = "*/ var c = 2\n"
+	require.Equal(t, chroma.KeywordDeclaration, typeAt(h.TokenizeLine(2, src), 0), "cached")
+	h.Edit(1, 0, 0)
+	require.True(t, typeAt(h.TokenizeLine(2, src), 0).InCategory(chroma.Comment))
+	require.Equal(t, chroma.KeywordDeclaration, typeAt(h.TokenizeLine(3, src), 3))
+
+	// Dropping lines inside the comment lexes it again from where it opened
+	h.Edit(3, 0, 0)
+	require.True(t, typeAt(h.TokenizeLine(3, src), 0).InCategory(chroma.Comment))
+}
+
+func TestLongComment(t *testing.T) {
+	// A comment longer than a chunk still closes
+	text := "package main\n/*\n" + strings.Repeat("var x\n", 3*chunkLines) + "*/\nvar y\n"
+	src := source(text)
+	h := New("main.go")
+	require.True(t, typeAt(h.TokenizeLine(2, src), 0).InCategory(chroma.Comment))
+	require.Equal(t, chroma.KeywordDeclaration, typeAt(h.TokenizeLine(len(src)-2, src), 0))
+}
+
+func TestPlain(t *testing.T) {
+	h := New("notes.unknownext")
+	require.Equal(t, "", h.Language())
+	require.Nil(t, h.TokenizeLine(0, source("func main() {}\n")))
+}
+
+// collect runs updates through a channel the way the editor's event queue does.
+func collect() (chan Update, func(Update) bool) {
+	updates := make(chan Update, 100)
+	return updates, func(u Update) bool {
+		updates <- u
+		return true
+	}
+}
+
+func next(t *testing.T, updates chan Update) Update {
+	t.Helper()
+	select {
+	case u := <-updates:
+		return u
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update")
+		return Update{}
+	}
+}
+
+func TestBackground(t *testing.T) {
+	var text strings.Builder
+	text.WriteString("package main\n/*\n*/\n")
+	for i := range 3 * backgroundChunk {
+		fmt.Fprintf(&text, "var x%d = %d\n", i, i)
+	}
+	src := source(text.String())
+	h := New("main.go")
+	updates, deliver := collect()
+
+	_, current := h.Tokens(1000)
+	require.False(t, current)
+	h.Background(1000, src, deliver)
+	h.Background(1000, src, deliver) // Already running
+
+	// What's on screen comes first, a guess and then lexed from the top, the rest later
+	u := next(t, updates)
+	require.True(t, u.guess)
+	require.True(t, u.Apply())
+	tokens, current := h.Tokens(1000)
+	require.False(t, current)
+	require.Equal(t, chroma.KeywordDeclaration, typeAt(tokens, 0))
+	u = next(t, updates)
+	require.True(t, u.Apply())
+	tokens, current = h.Tokens(1000)
+	require.True(t, current)
+	require.Equal(t, chroma.KeywordDeclaration, typeAt(tokens, 0))
+	_, current = h.Tokens(len(src) - 2)
+	require.False(t, current)
+	for !u.done {
+		u = next(t, updates)
+		require.True(t, u.Apply())
+	}
+	tokens, current = h.Tokens(len(src) - 2)
+	require.True(t, current)
+	require.Equal(t, chroma.KeywordDeclaration, typeAt(tokens, 0))
+}
+
+func TestBackgroundGuess(t *testing.T) {
+	// A comment opened at the top runs past the guess, the lex from the top corrects it
+	var text strings.Builder
+	text.WriteString("package main\n/*\n")
+	for i := range 3 * chunkLines {
+		fmt.Fprintf(&text, "var x%d = %d\n\n", i, i)
+	}
+	text.WriteString("*/\n")
+	src := source(text.String())
+	h := New("main.go")
+	updates, deliver := collect()
+	h.Background(4*chunkLines, src, deliver)
+
+	u := next(t, updates)
+	require.True(t, u.guess)
+	require.LessOrEqual(t, u.start, 4*chunkLines)
+	require.Greater(t, u.start, 3*chunkLines, "starts near the line asked for")
+	require.True(t, u.Apply())
+	tokens, _ := h.Tokens(4 * chunkLines)
+	require.Equal(t, chroma.KeywordDeclaration, typeAt(tokens, 0))
+
+	for !u.done {
+		u = next(t, updates)
+		require.True(t, u.Apply())
+	}
+	tokens, current := h.Tokens(4 * chunkLines)
+	require.True(t, current)
+	require.True(t, typeAt(tokens, 0).InCategory(chroma.Comment))
+}
+
+func TestBackgroundEdit(t *testing.T) {
+	src := source("package main\nvar a = 1\nvar b = 2\nvar c = 3\n")
+	h := New("main.go")
+	updates, deliver := collect()
+	h.Background(0, src, deliver)
+	require.True(t, next(t, updates).Apply())
+
+	// Old tokens stay, moved down with their lines, until the new ones come
+	src = source("package main\n/* x\n\n*/\nvar b = 2\nvar c = 3\n")
+	h.Edit(1, 0, 2)
+	tokens, current := h.Tokens(4)
+	require.False(t, current)
+	require.Equal(t, chroma.KeywordDeclaration, typeAt(tokens, 0))
+	_, current = h.Tokens(0)
+	require.True(t, current)
+
+	h.Background(2, src, deliver)
+	stale := Update{h: h, gen: h.gen - 1, start: 1, lines: make([]line, 5)}
+	require.False(t, stale.Apply(), "lexed before the edit")
+	require.True(t, next(t, updates).Apply())
+	tokens, current = h.Tokens(2)
+	require.True(t, current)
+	require.Empty(t, tokens, "blank line inside the comment")
+	tokens, _ = h.Tokens(3)
+	require.True(t, typeAt(tokens, 0).InCategory(chroma.Comment))
+}
+
+func TestBackgroundQueueFull(t *testing.T) {
+	src := source("package main\nvar a = 1\n")
+	h := New("main.go")
+	updates := make(chan Update, 1)
+	refused := 0
+	h.Background(0, src, func(u Update) bool {
+		if refused < 3 {
+			refused++
+			return false
+		}
+		updates <- u
+		return true
+	})
+	u := next(t, updates)
+	require.True(t, u.done)
+	require.True(t, u.Apply())
+	_, current := h.Tokens(1)
+	require.True(t, current)
+}
+
+func TestWindow(t *testing.T) {
+	text := runes("a\nbé\nc\n")
+	tests := []struct {
+		from, lines int
+		want        string
+		count       int
+		last        bool
+	}{
+		{from: 0, lines: 2, want: "a\nbé\n", count: 2},
+		{from: 2, lines: 1, want: "bé\n", count: 1},
+		{from: 2, lines: 2, want: "bé\nc\n", count: 2},
+		// The line after the last newline is empty
+		{from: 2, lines: 5, want: "bé\nc\n", count: 3, last: true},
+		{from: 8, lines: 5, want: "", count: 1, last: true},
+	}
+
+	for _, tt := range tests {
+		w, count, last := window(text, tt.from, tt.lines)
+		require.Equal(t, tt.want, w)
+		require.Equal(t, tt.count, count)
+		require.Equal(t, tt.last, last)
+	}
+	require.Equal(t, 5, windowChars("a\nbé\nc\n", 2))
+}
+
+// countingLines is a Source that counts the lines read from it.
+type countingLines struct {
+	lines
+	read *int
+}
+
+func (c countingLines) Line(lineNum int) string {
+	*c.read++
+	return c.lines[lineNum]
+}
+
+// countingRunes is a Text that counts the chars read from it.
+type countingRunes struct {
+	runes
+	read *atomic.Int64
+}
+
+func (c countingRunes) Substring(start, end int) string {
+	text := c.runes.Substring(start, end)
+	c.read.Add(int64(utf8.RuneCountInString(text)))
+	return text
+}
+
+func longSource(count int) lines {
+	var text strings.Builder
+	text.WriteString("package main\n")
+	for i := range count {
+		fmt.Fprintf(&text, "var x%d = %d\n", i, i)
+	}
+	return source(text.String())
+}
+
+func TestLexReadsAWindow(t *testing.T) {
+	read := 0
+	src := countingLines{lines: longSource(5 * lookahead), read: &read}
+	h := New("main.go")
+	require.Equal(t, chroma.KeywordDeclaration, typeAt(h.TokenizeLine(5, src), 0))
+	require.LessOrEqual(t, read, 5+chunkLines+lookahead, "lines past the lookahead aren't read")
+}
+
+func TestBackgroundReadsAWindow(t *testing.T) {
+	src := longSource(5 * lookahead)
+	text := countingRunes{runes: runes(strings.Join(src, "")), read: new(atomic.Int64)}
+	h := New("main.go")
+	updates := make(chan Update, 100)
+	var readFirst int64
+	h.Background(0, snapshotLines{src, text}, func(u Update) bool {
+		if readFirst == 0 {
+			readFirst = text.read.Load()
+		}
+		updates <- u
+		return true
+	})
+
+	u := next(t, updates)
+	require.True(t, u.Apply())
+	_, current := h.Tokens(chunkLines)
+	require.True(t, current)
+	perLine := int64(len([]rune(src[len(src)-2])))
+	require.LessOrEqual(t, readFirst, (chunkLines+lookahead+windowBlock)*perLine, "the first update reads a window")
+
+	for !u.done {
+		u = next(t, updates)
+		require.True(t, u.Apply())
+	}
+	tokens, current := h.Tokens(len(src) - 2)
+	require.True(t, current)
+	require.Equal(t, chroma.KeywordDeclaration, typeAt(tokens, 0))
+}
+
+// snapshotLines is a Source whose snapshot is a given Text.
+type snapshotLines struct {
+	lines
+	text Text
+}
+
+func (s snapshotLines) Snapshot() Text { return s.text }
+
+func TestApplyDroppedEndsJob(t *testing.T) {
+	src := source("package main\nvar a = 1\n")
+	h := New("main.go")
+	h.Background(0, src, func(Update) bool { return false })
+	require.NotNil(t, h.job)
+
+	// An update past what's current can't be stored, the lex has to start over
+	u := Update{h: h, job: h.job, gen: h.gen, start: h.valid + 1, done: true}
+	require.False(t, u.Apply())
+	require.Nil(t, h.job)
+
+	updates, deliver := collect()
+	h.Background(0, src, deliver)
+	require.True(t, next(t, updates).Apply())
+	_, current := h.Tokens(1)
+	require.True(t, current)
+}
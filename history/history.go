@@ -0,0 +1,221 @@
+// Package history keeps what was typed on the command line, one list per kind of prompt, and saves it
+// between sessions. Browsing with Up/Down only offers entries starting with what's already typed.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Kind picks a history list. "/" and "?" share Search like they do in Vim.
+type Kind int
+
+const (
+	Command    Kind = iota // ":"
+	Search                 // "/" and "?"
+	Expression             // "="
+	Input                  // Answers typed at input prompts
+	File                   // Files that were edited, for the recent files picker. Not a prompt
+)
+
+var kindNames = map[Kind]string{
+	Command:    "cmd",
+	Search:     "search",
+	Expression: "expr",
+	Input:      "input",
+	File:       "file",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// KindFor maps a command line prompt to its history.
+func KindFor(prompt rune) Kind {
+	switch prompt {
+	case '/', '?':
+		return Search
+	case '=':
+		return Expression
+	case ':':
+		return Command
+	default:
+		return Input
+	}
+}
+
+// DefaultLimit is how many entries each list keeps.
+const DefaultLimit = 200
+
+// List holds entries oldest first. An entry is only kept once, adding it again moves it to the end.
+type List struct {
+	entries []string
+	limit   int
+}
+
+func (l *List) Add(entry string) {
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+	if i := slices.Index(l.entries, entry); i >= 0 {
+		l.entries = slices.Delete(l.entries, i, i+1)
+	}
+	l.entries = append(l.entries, entry)
+	if over := len(l.entries) - l.limit; over > 0 {
+		l.entries = slices.Delete(l.entries, 0, over)
+	}
+}
+
+// Entries returns the entries oldest first.
+func (l *List) Entries() []string {
+	return slices.Clone(l.entries)
+}
+
+func (l *List) Len() int {
+	return len(l.entries)
+}
+
+// Store is every history list.
+type Store struct {
+	lists map[Kind]*List
+	limit int
+}
+
+func NewStore(limit int) *Store {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return &Store{lists: make(map[Kind]*List), limit: limit}
+}
+
+func (s *Store) List(kind Kind) *List {
+	l, ok := s.lists[kind]
+	if !ok {
+		l = &List{limit: s.limit}
+		s.lists[kind] = l
+	}
+	return l
+}
+
+func (s *Store) Add(kind Kind, entry string) {
+	s.List(kind).Add(entry)
+}
+
+// Browser walks one list from the newest entry back, skipping entries that don't start with the
+// text typed before browsing started. Going past the newest entry gives the typed text back.
+type Browser struct {
+	entries []string
+	typed   string
+	index   int // len(entries) means "back at what was typed"
+}
+
+func NewBrowser(l *List, typed string) *Browser {
+	return &Browser{entries: l.Entries(), typed: typed, index: len(l.entries)}
+}
+
+// Older returns the previous matching entry, false when there is none.
+func (b *Browser) Older() (string, bool) {
+	for i := b.index - 1; i >= 0; i-- {
+		if strings.HasPrefix(b.entries[i], b.typed) {
+			b.index = i
+			return b.entries[i], true
+		}
+	}
+	return "", false
+}
+
+// Newer returns the next matching entry, or the typed text after the newest one.
+func (b *Browser) Newer() (string, bool) {
+	if b.index >= len(b.entries) {
+		return "", false
+	}
+	for i := b.index + 1; i < len(b.entries); i++ {
+		if strings.HasPrefix(b.entries[i], b.typed) {
+			b.index = i
+			return b.entries[i], true
+		}
+	}
+	b.index = len(b.entries)
+	return b.typed, true
+}
+
+// ### PERSISTENCE
+
+// DefaultPath is where history is saved: $XDG_STATE_HOME/go_editor/history.json, falling back to ~/.local/state.
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("history: no state directory: %w", err)
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "go_editor", "history.json"), nil
+}
+
+// Load reads a saved file. Saved entries count as older than anything added this session.
+// A missing file isn't an error, there just is no history yet.
+func (s *Store) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+
+	var saved map[string][]string
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("history: %s: %w", path, err)
+	}
+	for kind, name := range kindNames {
+		current := s.List(kind).Entries()
+		merged := &List{limit: s.limit}
+		for _, entry := range append(saved[name], current...) {
+			merged.Add(entry)
+		}
+		s.lists[kind] = merged
+	}
+	return nil
+}
+
+// Save writes every list to path, replacing the file in one step so a crash never leaves half of it.
+func (s *Store) Save(path string) error {
+	saved := make(map[string][]string)
+	for kind, l := range s.lists {
+		if l.Len() > 0 {
+			saved[kind.String()] = l.Entries()
+		}
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".history-*")
+	if err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	return nil
+}
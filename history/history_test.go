@@ -0,0 +1,65 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListDedupeAndLimit(t *testing.T) {
+	s := NewStore(3)
+	for _, entry := range []string{"w", "q", "w", " ", "e foo", "set ic"} {
+		s.Add(Command, entry)
+	}
+	require.Equal(t, []string{"w", "e foo", "set ic"}, s.List(Command).Entries())
+	require.Equal(t, 0, s.List(Search).Len(), "Lists are separate")
+}
+
+func TestBrowserPrefix(t *testing.T) {
+	s := NewStore(10)
+	for _, entry := range []string{"set ic", "w", "set scs", "q"} {
+		s.Add(Command, entry)
+	}
+
+	b := NewBrowser(s.List(Command), "se")
+	entry, ok := b.Older()
+	require.True(t, ok)
+	require.Equal(t, "set scs", entry)
+	entry, _ = b.Older()
+	require.Equal(t, "set ic", entry)
+	_, ok = b.Older()
+	require.False(t, ok)
+
+	entry, _ = b.Newer()
+	require.Equal(t, "set scs", entry)
+	entry, ok = b.Newer()
+	require.True(t, ok)
+	require.Equal(t, "se", entry, "Past the newest gives the typed text back")
+	_, ok = b.Newer()
+	require.False(t, ok)
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "history.json")
+
+	s := NewStore(10)
+	s.Add(Command, "w")
+	s.Add(Search, "foo")
+	require.NoError(t, s.Save(path))
+
+	loaded := NewStore(10)
+	loaded.Add(Command, "q")
+	loaded.Add(Command, "w")
+	require.NoError(t, loaded.Load(path))
+	require.Equal(t, []string{"q", "w"}, loaded.List(Command).Entries(), "Session entries stay newest")
+	require.Equal(t, []string{"foo"}, loaded.List(Search).Entries())
+
+	require.NoError(t, NewStore(10).Load(filepath.Join(t.TempDir(), "missing.json")))
+}
+
+func TestKindFor(t *testing.T) {
+	require.Equal(t, Search, KindFor('?'))
+	require.Equal(t, Command, KindFor(':'))
+	require.Equal(t, Input, KindFor('>'))
+}
@@ -0,0 +1,486 @@
+package layout
+
+import (
+	"errors"
+)
+
+// Split says how a container lays out its children.
+type Split int
+
+const (
+	Rows    Split = iota // Stacked top to bottom, ":split"
+	Columns              // Side by side with a one column separator between them, ":vsplit"
+)
+
+// Direction is where Neighbor looks from a window.
+type Direction int
+
+const (
+	Left Direction = iota
+	Down
+	Up
+	Right
+)
+
+// Smallest size a window is squeezed to, a text line plus its status line.
+const (
+	MinHeight = 2
+	MinWidth  = 1
+)
+
+var ErrNoRoom = errors.New("E36: Not enough room")
+
+type Rect struct {
+	X, Y          int
+	Width, Height int
+}
+
+// Tree is a window layout: leaves are windows, inner nodes split their area into rows or columns.
+// Windows are known by the IDs the caller gives them.
+type Tree struct {
+	root   *node
+	leaves map[int]*node
+	rects  map[int]Rect
+	area   Rect
+}
+
+type node struct {
+	parent   *node
+	window   int // Leaves only
+	split    Split
+	children []*node
+	size     int // Cells along the parent's axis, scaled to fit on every Arrange
+}
+
+func (n *node) isLeaf() bool {
+	return n.children == nil
+}
+
+// New returns a layout holding a single window.
+func New(window int) *Tree {
+	leaf := &node{window: window}
+	return &Tree{
+		root:   leaf,
+		leaves: map[int]*node{window: leaf},
+		rects:  make(map[int]Rect),
+	}
+}
+
+// Arrange fits the layout into area and remembers it, so later changes are laid out in the same area.
+func (t *Tree) Arrange(area Rect) {
+	t.area = area
+	clear(t.rects)
+	t.arrange(t.root, area)
+}
+
+// Rect is where a window went on the last Arrange.
+func (t *Tree) Rect(window int) (Rect, bool) {
+	r, ok := t.rects[window]
+	return r, ok
+}
+
+// Windows lists the windows top left to bottom right, the order Ctrl-W w cycles through.
+func (t *Tree) Windows() []int {
+	var windows []int
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n.isLeaf() {
+			windows = append(windows, n.window)
+			return
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(t.root)
+	return windows
+}
+
+func (t *Tree) Len() int {
+	return len(t.leaves)
+}
+
+// Split divides window in two, newWindow takes the top (Rows) or left (Columns) half like Vim does.
+func (t *Tree) Split(window, newWindow int, split Split) error {
+	leaf, ok := t.leaves[window]
+	if !ok {
+		return errors.New("layout: no such window")
+	}
+	// Until the first Arrange there's no size to check against
+	if r, ok := t.rects[window]; ok && t.area.Width > 0 && t.area.Height > 0 {
+		if split == Rows && r.Height < 2*MinHeight || split == Columns && r.Width < 2*MinWidth+1 {
+			return ErrNoRoom
+		}
+	}
+
+	added := &node{window: newWindow}
+	t.leaves[newWindow] = added
+
+	parent := leaf.parent
+	if parent == nil || parent.split != split {
+		// Turn the leaf into a container holding the old window and the new one
+		container := &node{parent: parent, split: split, size: leaf.size}
+		t.replace(leaf, container)
+		leaf.parent = container
+		container.children = []*node{leaf}
+		parent = container
+		leaf.size = 0
+		if r, ok := t.rects[window]; ok {
+			leaf.size = r.Height
+			if split == Columns {
+				leaf.size = r.Width
+			}
+		}
+	}
+
+	if split == Columns {
+		leaf.size-- // Room for the separator
+	}
+	half := leaf.size / 2
+	added.parent = parent
+	added.size = half
+	leaf.size -= half
+	i := indexOf(parent, leaf)
+	parent.children = append(parent.children[:i], append([]*node{added}, parent.children[i:]...)...)
+
+	t.Arrange(t.area)
+	return nil
+}
+
+// SplitBelow opens newWindow under window, height rows tall, like Vim's ":belowright split".
+func (t *Tree) SplitBelow(window, newWindow, height int) error {
+	leaf, ok := t.leaves[window]
+	if !ok {
+		return errors.New("layout: no such window")
+	}
+	return t.splitAfter(leaf, newWindow, height)
+}
+
+// SplitBottom opens newWindow across the whole width at the bottom, height rows tall, like ":botright split".
+func (t *Tree) SplitBottom(newWindow, height int) error {
+	return t.splitAfter(t.root, newWindow, height)
+}
+
+// splitAfter puts newWindow under n, a window or a whole container, taking its rows from n. The height shrinks
+// when n can't spare it.
+func (t *Tree) splitAfter(n *node, newWindow, height int) error {
+	total := t.height(n)
+	if total > 0 {
+		if total < 2*MinHeight {
+			return ErrNoRoom
+		}
+		height = min(height, total-MinHeight)
+	}
+
+	added := &node{window: newWindow, size: height}
+	t.leaves[newWindow] = added
+
+	parent := n.parent
+	if parent == nil || parent.split != Rows {
+		container := &node{parent: parent, split: Rows, size: n.size}
+		t.replace(n, container)
+		n.parent = container
+		container.children = []*node{n}
+		parent = container
+	}
+	n.size = max(0, total-height)
+	added.parent = parent
+	i := indexOf(parent, n)
+	parent.children = append(parent.children[:i+1], append([]*node{added}, parent.children[i+1:]...)...)
+
+	t.Arrange(t.area)
+	return nil
+}
+
+// height is how many rows n took on the last Arrange, 0 before the first one.
+func (t *Tree) height(n *node) int {
+	first, ok := t.rects[firstLeaf(n).window]
+	last, ok2 := t.rects[lastLeaf(n).window]
+	if !ok || !ok2 {
+		return 0
+	}
+	return last.Y + last.Height - first.Y
+}
+
+// Close removes a window, its space goes to the window before it, or after it when it's the first one.
+// Returns the window that got the space. The last window can't be closed.
+func (t *Tree) Close(window int) (int, bool) {
+	leaf, ok := t.leaves[window]
+	if !ok || leaf.parent == nil {
+		return 0, false
+	}
+	parent := leaf.parent
+	i := indexOf(parent, leaf)
+	parent.children = append(parent.children[:i], parent.children[i+1:]...)
+	delete(t.leaves, window)
+
+	var heir *node
+	var focus int
+	if i > 0 {
+		heir = parent.children[i-1]
+		focus = lastLeaf(heir).window
+	} else {
+		heir = parent.children[0]
+		focus = firstLeaf(heir).window
+	}
+	heir.size += leaf.size
+	if parent.split == Columns {
+		heir.size++ // The separator
+	}
+
+	if len(parent.children) == 1 {
+		t.collapse(parent)
+	}
+	t.Arrange(t.area)
+	return focus, true
+}
+
+// collapse replaces a container left with one child by that child. A child container splitting the same
+// way as the grandparent is merged into it.
+func (t *Tree) collapse(container *node) {
+	child := container.children[0]
+	child.size = container.size
+	t.replace(container, child)
+
+	grandparent := child.parent
+	if grandparent == nil || child.isLeaf() || child.split != grandparent.split {
+		return
+	}
+	i := indexOf(grandparent, child)
+	for _, c := range child.children {
+		c.parent = grandparent
+	}
+	merged := append(append(append([]*node{}, grandparent.children[:i]...), child.children...), grandparent.children[i+1:]...)
+	grandparent.children = merged
+}
+
+// Only closes every window but this one.
+func (t *Tree) Only(window int) {
+	leaf, ok := t.leaves[window]
+	if !ok {
+		return
+	}
+	leaf.parent = nil
+	t.root = leaf
+	t.leaves = map[int]*node{window: leaf}
+	t.Arrange(t.area)
+}
+
+// Equalize makes all windows (almost) the same size, Ctrl-W =.
+func (t *Tree) Equalize() {
+	var walk func(n *node)
+	walk = func(n *node) {
+		n.size = 0
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(t.root)
+	t.Arrange(t.area)
+}
+
+// Resize grows the window by delta rows (Rows) or columns (Columns), shrinking when negative. The space
+// comes from the windows after it, then the ones before it, none of them going below the minimum.
+func (t *Tree) Resize(window int, split Split, delta int) {
+	n, ok := t.leaves[window]
+	if !ok {
+		return
+	}
+	for n.parent != nil && n.parent.split != split {
+		n = n.parent
+	}
+	parent := n.parent
+	if parent == nil || delta == 0 {
+		return
+	}
+	minimum := t.minimum(split)
+	i := indexOf(parent, n)
+	others := append(append([]*node{}, parent.children[i+1:]...), reversed(parent.children[:i])...)
+
+	if delta < 0 {
+		give := min(-delta, max(0, n.size-minimum))
+		n.size -= give
+		if len(others) > 0 {
+			others[0].size += give
+		}
+		t.Arrange(t.area)
+		return
+	}
+	for _, o := range others {
+		take := min(delta, max(0, o.size-minimum))
+		o.size -= take
+		n.size += take
+		delta -= take
+	}
+	t.Arrange(t.area)
+}
+
+// Neighbor finds the window next to this one in a direction, the one beside its top left corner when several are.
+func (t *Tree) Neighbor(window int, dir Direction) (int, bool) {
+	cur, ok := t.rects[window]
+	if !ok {
+		return 0, false
+	}
+	best, found := 0, false
+	bestDist := 0
+	for _, id := range t.Windows() {
+		r := t.rects[id]
+		var touches bool
+		var dist int
+		switch dir {
+		case Left:
+			touches = r.X+r.Width+1 == cur.X && overlaps(r.Y, r.Height, cur.Y, cur.Height)
+			dist = distance(cur.Y, r.Y, r.Height)
+		case Right:
+			touches = cur.X+cur.Width+1 == r.X && overlaps(r.Y, r.Height, cur.Y, cur.Height)
+			dist = distance(cur.Y, r.Y, r.Height)
+		case Up:
+			touches = r.Y+r.Height == cur.Y && overlaps(r.X, r.Width, cur.X, cur.Width)
+			dist = distance(cur.X, r.X, r.Width)
+		case Down:
+			touches = cur.Y+cur.Height == r.Y && overlaps(r.X, r.Width, cur.X, cur.Width)
+			dist = distance(cur.X, r.X, r.Width)
+		}
+		if touches && (!found || dist < bestDist) {
+			best, bestDist, found = id, dist, true
+		}
+	}
+	return best, found
+}
+
+func (t *Tree) arrange(n *node, r Rect) {
+	if n.isLeaf() {
+		t.rects[n.window] = r
+		return
+	}
+
+	total, pos := r.Height, r.Y
+	if n.split == Columns {
+		total, pos = r.Width-(len(n.children)-1), r.X
+	}
+	sizes := distribute(n.children, max(0, total), t.minimum(n.split))
+
+	for i, c := range n.children {
+		c.size = sizes[i]
+		child := Rect{X: r.X, Y: pos, Width: r.Width, Height: c.size}
+		if n.split == Columns {
+			child = Rect{X: pos, Y: r.Y, Width: c.size, Height: r.Height}
+			pos++
+		}
+		pos += c.size
+		t.arrange(c, child)
+	}
+}
+
+// distribute scales the children's sizes to add up to total. Children without a size yet get an even share.
+func distribute(children []*node, total, minimum int) []int {
+	weights := make([]int, len(children))
+	sum, known := 0, 0
+	for i, c := range children {
+		weights[i] = c.size
+		if c.size > 0 {
+			sum += c.size
+			known++
+		}
+	}
+	even := total / len(children)
+	if known > 0 {
+		even = max(1, sum/known)
+	}
+	sum = 0
+	for i := range weights {
+		if weights[i] <= 0 {
+			weights[i] = even
+		}
+		sum += weights[i]
+	}
+
+	sizes := make([]int, len(children))
+	used := 0
+	for i, w := range weights {
+		sizes[i] = w * total / max(1, sum)
+		used += sizes[i]
+	}
+	sizes[len(sizes)-1] += total - used
+
+	// Lift windows below the minimum by taking from the largest ones
+	for i := range sizes {
+		for sizes[i] < minimum {
+			largest := 0
+			for j := range sizes {
+				if sizes[j] > sizes[largest] {
+					largest = j
+				}
+			}
+			if sizes[largest] <= minimum {
+				break
+			}
+			sizes[largest]--
+			sizes[i]++
+		}
+	}
+	return sizes
+}
+
+func (t *Tree) minimum(split Split) int {
+	if split == Columns {
+		return MinWidth
+	}
+	return MinHeight
+}
+
+// replace puts n where old was in old's parent, or makes it the root.
+func (t *Tree) replace(old, n *node) {
+	n.parent = old.parent
+	if old.parent == nil {
+		t.root = n
+		return
+	}
+	old.parent.children[indexOf(old.parent, old)] = n
+}
+
+func indexOf(parent, child *node) int {
+	for i, c := range parent.children {
+		if c == child {
+			return i
+		}
+	}
+	return -1
+}
+
+func firstLeaf(n *node) *node {
+	for !n.isLeaf() {
+		n = n.children[0]
+	}
+	return n
+}
+
+func lastLeaf(n *node) *node {
+	for !n.isLeaf() {
+		n = n.children[len(n.children)-1]
+	}
+	return n
+}
+
+func reversed(nodes []*node) []*node {
+	out := make([]*node, len(nodes))
+	for i, n := range nodes {
+		out[len(nodes)-1-i] = n
+	}
+	return out
+}
+
+func overlaps(start, length, otherStart, otherLength int) bool {
+	return start < otherStart+otherLength && otherStart < start+length
+}
+
+// distance from point to the span start..start+length, 0 when it's inside.
+func distance(point, start, length int) int {
+	switch {
+	case point < start:
+		return start - point
+	case point >= start+length:
+		return point - (start + length - 1)
+	}
+	return 0
+}
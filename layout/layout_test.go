@@ -0,0 +1,199 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func arranged(width, height int) *Tree {
+	t := New(1)
+	t.Arrange(Rect{Width: width, Height: height})
+	return t
+}
+
+func TestSplitRows(t *testing.T) {
+	tree := arranged(80, 24)
+	require.NoError(t, tree.Split(1, 2, Rows))
+
+	require.Equal(t, []int{2, 1}, tree.Windows())
+	top, _ := tree.Rect(2)
+	bottom, _ := tree.Rect(1)
+	require.Equal(t, Rect{X: 0, Y: 0, Width: 80, Height: 12}, top)
+	require.Equal(t, Rect{X: 0, Y: 12, Width: 80, Height: 12}, bottom)
+}
+
+func TestSplitColumnsLeavesSeparator(t *testing.T) {
+	tree := arranged(81, 24)
+	require.NoError(t, tree.Split(1, 2, Columns))
+
+	left, _ := tree.Rect(2)
+	right, _ := tree.Rect(1)
+	require.Equal(t, Rect{X: 0, Y: 0, Width: 40, Height: 24}, left)
+	require.Equal(t, Rect{X: 41, Y: 0, Width: 40, Height: 24}, right)
+}
+
+func TestSplitNested(t *testing.T) {
+	tree := arranged(81, 24)
+	require.NoError(t, tree.Split(1, 2, Columns))
+	require.NoError(t, tree.Split(1, 3, Rows))
+
+	require.Equal(t, []int{2, 3, 1}, tree.Windows())
+	r, _ := tree.Rect(3)
+	require.Equal(t, Rect{X: 41, Y: 0, Width: 40, Height: 12}, r)
+	r, _ = tree.Rect(2)
+	require.Equal(t, Rect{X: 0, Y: 0, Width: 40, Height: 24}, r)
+}
+
+func TestSplitNoRoom(t *testing.T) {
+	tree := arranged(80, 3)
+	require.ErrorIs(t, tree.Split(1, 2, Rows), ErrNoRoom)
+	require.Equal(t, []int{1}, tree.Windows())
+}
+
+func TestSplitBottomSpansEveryColumn(t *testing.T) {
+	tree := arranged(81, 24)
+	require.NoError(t, tree.Split(1, 2, Columns))
+	require.NoError(t, tree.SplitBottom(3, 10))
+
+	require.Equal(t, []int{2, 1, 3}, tree.Windows())
+	r, _ := tree.Rect(3)
+	require.Equal(t, Rect{X: 0, Y: 14, Width: 81, Height: 10}, r)
+	r, _ = tree.Rect(1)
+	require.Equal(t, Rect{X: 41, Y: 0, Width: 40, Height: 14}, r)
+}
+
+func TestSplitBelow(t *testing.T) {
+	tree := arranged(81, 24)
+	require.NoError(t, tree.Split(1, 2, Columns))
+	require.NoError(t, tree.SplitBelow(2, 3, 30))
+
+	r, _ := tree.Rect(3)
+	require.Equal(t, Rect{X: 0, Y: MinHeight, Width: 40, Height: 24 - MinHeight}, r, "the height shrinks to leave the window its minimum")
+	r, _ = tree.Rect(1)
+	require.Equal(t, Rect{X: 41, Y: 0, Width: 40, Height: 24}, r)
+
+	require.ErrorIs(t, arranged(80, 3).SplitBelow(1, 2, 1), ErrNoRoom)
+}
+
+func TestClose(t *testing.T) {
+	tree := arranged(80, 24)
+	require.NoError(t, tree.Split(1, 2, Rows))
+	require.NoError(t, tree.Split(2, 3, Rows))
+
+	focus, ok := tree.Close(2)
+	require.True(t, ok)
+	require.Equal(t, 3, focus)
+	require.Equal(t, []int{3, 1}, tree.Windows())
+
+	r3, _ := tree.Rect(3)
+	r1, _ := tree.Rect(1)
+	require.Equal(t, 24, r3.Height+r1.Height)
+
+	focus, ok = tree.Close(3)
+	require.True(t, ok)
+	require.Equal(t, 1, focus)
+	r, _ := tree.Rect(1)
+	require.Equal(t, Rect{Width: 80, Height: 24}, r)
+
+	_, ok = tree.Close(1)
+	require.False(t, ok, "the last window stays")
+}
+
+func TestCloseMergesSameSplit(t *testing.T) {
+	tree := arranged(80, 24)
+	require.NoError(t, tree.Split(1, 2, Rows))
+	require.NoError(t, tree.Split(1, 3, Columns))
+	require.NoError(t, tree.Split(1, 4, Rows))
+
+	// Closing 3 leaves a rows container inside the top rows container, which gets flattened
+	tree.Close(3)
+	require.Equal(t, []int{2, 4, 1}, tree.Windows())
+	require.Len(t, tree.root.children, 3)
+}
+
+func TestOnly(t *testing.T) {
+	tree := arranged(80, 24)
+	require.NoError(t, tree.Split(1, 2, Rows))
+	require.NoError(t, tree.Split(2, 3, Columns))
+
+	tree.Only(3)
+	require.Equal(t, []int{3}, tree.Windows())
+	r, _ := tree.Rect(3)
+	require.Equal(t, Rect{Width: 80, Height: 24}, r)
+}
+
+func TestResize(t *testing.T) {
+	tree := arranged(80, 24)
+	require.NoError(t, tree.Split(1, 2, Rows))
+
+	tree.Resize(2, Rows, 5)
+	top, _ := tree.Rect(2)
+	require.Equal(t, 17, top.Height)
+
+	tree.Resize(2, Rows, 100)
+	top, _ = tree.Rect(2)
+	require.Equal(t, 24-MinHeight, top.Height, "the other window keeps its minimum")
+
+	tree.Resize(2, Rows, -100)
+	top, _ = tree.Rect(2)
+	require.Equal(t, MinHeight, top.Height)
+
+	// No columns to resize in
+	tree.Resize(2, Columns, 5)
+	top, _ = tree.Rect(2)
+	require.Equal(t, 80, top.Width)
+}
+
+func TestEqualize(t *testing.T) {
+	tree := arranged(80, 30)
+	require.NoError(t, tree.Split(1, 2, Rows))
+	require.NoError(t, tree.Split(2, 3, Rows))
+	tree.Resize(3, Rows, 10)
+
+	tree.Equalize()
+	for _, id := range tree.Windows() {
+		r, _ := tree.Rect(id)
+		require.Equal(t, 10, r.Height)
+	}
+}
+
+func TestArrangeScalesToNewArea(t *testing.T) {
+	tree := arranged(80, 20)
+	require.NoError(t, tree.Split(1, 2, Rows))
+	tree.Resize(2, Rows, 5) // 15 and 5
+
+	tree.Arrange(Rect{Width: 80, Height: 40})
+	top, _ := tree.Rect(2)
+	bottom, _ := tree.Rect(1)
+	require.Equal(t, 30, top.Height)
+	require.Equal(t, 10, bottom.Height)
+	require.Equal(t, 30, bottom.Y)
+}
+
+func TestNeighbor(t *testing.T) {
+	// +---+---+
+	// | 2 | 3 |
+	// +---+---+
+	// |   1   |
+	// +-------+
+	tree := arranged(81, 24)
+	require.NoError(t, tree.Split(1, 2, Rows))
+	require.NoError(t, tree.Split(2, 3, Columns))
+	require.Equal(t, []int{3, 2, 1}, tree.Windows())
+
+	next := func(id int, dir Direction) int {
+		n, ok := tree.Neighbor(id, dir)
+		if !ok {
+			return 0
+		}
+		return n
+	}
+	require.Equal(t, 2, next(3, Right))
+	require.Equal(t, 3, next(2, Left))
+	require.Equal(t, 1, next(3, Down))
+	require.Equal(t, 1, next(2, Down))
+	require.Equal(t, 3, next(1, Up), "the window above the left edge")
+	require.Equal(t, 0, next(1, Down))
+	require.Equal(t, 0, next(3, Left))
+}
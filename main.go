@@ -2,8 +2,10 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/ogzhanolguncu/go_editor/editor"
+	"github.com/ogzhanolguncu/go_editor/history"
 	"github.com/ogzhanolguncu/go_editor/screen"
 )
 
@@ -12,6 +14,29 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to initialize editor: %v", err)
 	}
+	if len(os.Args) > 1 {
+		if err := editor.OpenFile(os.Args[1]); err != nil {
+			log.Fatalf("failed to open file: %v", err)
+		}
+	}
+
+	historyPath, err := history.DefaultPath()
+	if err == nil {
+		if err := editor.LoadHistory(historyPath); err != nil {
+			editor.SetMessage(err.Error())
+		}
+	}
+
+	// Deferred before the screen so it runs after the terminal is restored, where an error can be printed
+	defer func() {
+		if historyPath == "" {
+			return
+		}
+		if err := editor.SaveHistory(historyPath); err != nil {
+			log.Printf("failed to save history: %v", err)
+		}
+	}()
+
 	screen, err := screen.NewScreen(editor)
 	if err != nil {
 		log.Fatalf("failed to initialize editor: %v", err)
@@ -0,0 +1,251 @@
+// Package register implements Vim style registers: the unnamed register, named a-z registers,
+// numbered 0-9 delete history, the small delete register and a handful of read-only registers.
+package register
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind tells how the stored text should be put back into the buffer.
+type Kind int
+
+const (
+	Charwise Kind = iota
+	Linewise
+	Blockwise
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Linewise:
+		return "l"
+	case Blockwise:
+		return "b"
+	default:
+		return "c"
+	}
+}
+
+const (
+	Unnamed     = '"'
+	SmallDelete = '-'
+	BlackHole   = '_'
+	LastInsert  = '.'
+	FileName    = '%'
+	LastCommand = ':'
+	Clipboard   = '+'
+	Selection   = '*'
+)
+
+// Register is a single register value. Linewise text always ends with a newline,
+// blockwise text is one block row per line without a trailing newline.
+type Register struct {
+	Text string
+	Kind Kind
+}
+
+// Entry is a named register, used for listing.
+type Entry struct {
+	Name rune
+	Register
+}
+
+// ClipboardProvider backs the '+' and '*' registers.
+type ClipboardProvider interface {
+	Read() (string, error)
+	Write(text string) error
+}
+
+type Store struct {
+	regs map[rune]Register
+	// unnamed points to the register that was written last, that's what '"' reads from.
+	unnamed rune
+
+	clipboard ClipboardProvider
+	// clipKind remembers the kind of the last clipboard write, the clipboard itself only holds text.
+	clipKind Kind
+	clipText string
+}
+
+func NewStore() *Store {
+	return &Store{
+		regs:    make(map[rune]Register),
+		unnamed: '0',
+	}
+}
+
+// IsValid reports whether name can be used after a '"' prefix.
+func IsValid(name rune) bool {
+	switch {
+	case name >= 'a' && name <= 'z', name >= 'A' && name <= 'Z', name >= '0' && name <= '9':
+		return true
+	}
+	return strings.ContainsRune(`"-_.%:+*`, name)
+}
+
+// SetClipboard connects '+' and '*' to the system clipboard. Without one they act like named registers.
+func (s *Store) SetClipboard(c ClipboardProvider) {
+	s.clipboard = c
+}
+
+func isClipboard(name rune) bool {
+	return name == Clipboard || name == Selection
+}
+
+func isReadOnly(name rune) bool {
+	return name == LastInsert || name == FileName || name == LastCommand
+}
+
+// Get returns the contents of a register. Uppercase names read their lowercase register.
+func (s *Store) Get(name rune) (Register, bool) {
+	if name == 0 || name == Unnamed {
+		name = s.unnamed
+	}
+	if name >= 'A' && name <= 'Z' {
+		name = name - 'A' + 'a'
+	}
+	if isClipboard(name) && s.clipboard != nil {
+		return s.readClipboard()
+	}
+	reg, ok := s.regs[name]
+	if !ok || reg.Text == "" {
+		return Register{}, false
+	}
+	return reg, true
+}
+
+// SetReadOnly updates one of the read-only registers ('.', '%' and ':'). These can't be written by yanks or deletes.
+func (s *Store) SetReadOnly(name rune, text string) {
+	if !isReadOnly(name) {
+		return
+	}
+	s.regs[name] = Register{Text: text, Kind: Charwise}
+}
+
+// Yank stores yanked text. Without a register name, it goes to '0' and the unnamed register.
+func (s *Store) Yank(name rune, reg Register) error {
+	if name == 0 || name == Unnamed {
+		s.regs['0'] = reg
+		s.unnamed = '0'
+		return nil
+	}
+	return s.write(name, reg)
+}
+
+// Delete stores deleted text. Without a register name, multi-line deletes shift the numbered
+// registers 1-9 and land in '1', small deletes within a line go to '-'.
+func (s *Store) Delete(name rune, reg Register) error {
+	if name == 0 || name == Unnamed {
+		if reg.Kind == Linewise || strings.Contains(reg.Text, "\n") {
+			s.shiftNumbered(reg)
+			s.unnamed = '1'
+			return nil
+		}
+		s.regs[SmallDelete] = reg
+		s.unnamed = SmallDelete
+		return nil
+	}
+	return s.write(name, reg)
+}
+
+func (s *Store) write(name rune, reg Register) error {
+	if !IsValid(name) {
+		return fmt.Errorf("E354: Invalid register name: '%c'", name)
+	}
+	if isReadOnly(name) {
+		return fmt.Errorf("E354: Invalid register name: '%c'", name)
+	}
+	if name == BlackHole {
+		return nil
+	}
+	if isClipboard(name) && s.clipboard != nil {
+		if err := s.clipboard.Write(reg.Text); err != nil {
+			return err
+		}
+		s.clipKind, s.clipText = reg.Kind, reg.Text
+		s.unnamed = name
+		return nil
+	}
+	if name >= 'A' && name <= 'Z' {
+		name = name - 'A' + 'a'
+		reg = appendTo(s.regs[name], reg)
+	}
+	s.regs[name] = reg
+	s.unnamed = name
+	return nil
+}
+
+func (s *Store) readClipboard() (Register, bool) {
+	text, err := s.clipboard.Read()
+	if err != nil || text == "" {
+		return Register{}, false
+	}
+	if text == s.clipText {
+		return Register{Text: text, Kind: s.clipKind}, true
+	}
+	// Text copied elsewhere, guess the kind from the trailing newline
+	if strings.HasSuffix(text, "\n") {
+		return Register{Text: text, Kind: Linewise}, true
+	}
+	return Register{Text: text, Kind: Charwise}, true
+}
+
+// appendTo implements uppercase register appends. If either side is linewise the result is linewise,
+// so appending a word to a yanked line adds a new line, like Vim does.
+func appendTo(old, add Register) Register {
+	if old.Text == "" {
+		return add
+	}
+	switch {
+	case old.Kind == Linewise || add.Kind == Linewise:
+		text := old.Text
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		text += add.Text
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		return Register{Text: text, Kind: Linewise}
+	case old.Kind == Blockwise:
+		return Register{Text: old.Text + "\n" + add.Text, Kind: Blockwise}
+	default:
+		return Register{Text: old.Text + add.Text, Kind: add.Kind}
+	}
+}
+
+func (s *Store) shiftNumbered(reg Register) {
+	for i := '9'; i > '1'; i-- {
+		if prev, ok := s.regs[i-1]; ok {
+			s.regs[i] = prev
+		}
+	}
+	s.regs['1'] = reg
+}
+
+// List returns the non-empty registers in the order ":registers" shows them. With names, only those
+// registers are listed. Without, '+' and '*' are left out, reading them runs the clipboard tool, and so is '"'
+// when it points to them.
+func (s *Store) List(names string) []Entry {
+	order := []rune{Unnamed}
+	for r := '0'; r <= '9'; r++ {
+		order = append(order, r)
+	}
+	for r := 'a'; r <= 'z'; r++ {
+		order = append(order, r)
+	}
+	order = append(order, SmallDelete, Selection, Clipboard, LastInsert, LastCommand, FileName)
+
+	entries := make([]Entry, 0, len(order))
+	for _, name := range order {
+		system := isClipboard(name) || name == Unnamed && isClipboard(s.unnamed)
+		if names == "" && system || names != "" && !strings.ContainsRune(names, name) {
+			continue
+		}
+		if reg, ok := s.Get(name); ok {
+			entries = append(entries, Entry{Name: name, Register: reg})
+		}
+	}
+	return entries
+}
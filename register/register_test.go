@@ -0,0 +1,158 @@
+package register
+
+import (
+	"testing"
+
+	"github.com/ogzhanolguncu/go_editor/clipboard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYankGoesToZeroAndUnnamed(t *testing.T) {
+	s := NewStore()
+
+	require.NoError(t, s.Yank(0, Register{Text: "hello", Kind: Charwise}))
+
+	reg, ok := s.Get('0')
+	require.True(t, ok)
+	require.Equal(t, "hello", reg.Text)
+
+	reg, ok = s.Get(Unnamed)
+	require.True(t, ok)
+	require.Equal(t, "hello", reg.Text)
+}
+
+func TestDeleteShiftsNumberedRegisters(t *testing.T) {
+	s := NewStore()
+
+	for _, text := range []string{"one\n", "two\n", "three\n"} {
+		require.NoError(t, s.Delete(0, Register{Text: text, Kind: Linewise}))
+	}
+
+	reg, _ := s.Get('1')
+	require.Equal(t, "three\n", reg.Text)
+	reg, _ = s.Get('2')
+	require.Equal(t, "two\n", reg.Text)
+	reg, _ = s.Get('3')
+	require.Equal(t, "one\n", reg.Text)
+
+	// Only nine are kept, the oldest falls off
+	for i := range 9 {
+		require.NoError(t, s.Delete(0, Register{Text: string(rune('a'+i)) + "\n", Kind: Linewise}))
+	}
+	reg, _ = s.Get('9')
+	require.Equal(t, "a\n", reg.Text)
+}
+
+func TestSmallDelete(t *testing.T) {
+	s := NewStore()
+
+	require.NoError(t, s.Delete(0, Register{Text: "x", Kind: Charwise}))
+
+	_, ok := s.Get('1')
+	require.False(t, ok)
+
+	reg, ok := s.Get(SmallDelete)
+	require.True(t, ok)
+	require.Equal(t, "x", reg.Text)
+
+	reg, _ = s.Get(Unnamed)
+	require.Equal(t, "x", reg.Text)
+}
+
+func TestNamedAndAppend(t *testing.T) {
+	s := NewStore()
+
+	require.NoError(t, s.Yank('a', Register{Text: "foo", Kind: Charwise}))
+	require.NoError(t, s.Yank('A', Register{Text: "bar", Kind: Charwise}))
+
+	reg, _ := s.Get('a')
+	require.Equal(t, Register{Text: "foobar", Kind: Charwise}, reg)
+
+	// Yanking into a named register doesn't touch '0'
+	_, ok := s.Get('0')
+	require.False(t, ok)
+
+	// Charwise appended to a linewise register becomes a new line
+	require.NoError(t, s.Yank('b', Register{Text: "line\n", Kind: Linewise}))
+	require.NoError(t, s.Yank('B', Register{Text: "word", Kind: Charwise}))
+	reg, _ = s.Get('b')
+	require.Equal(t, Register{Text: "line\nword\n", Kind: Linewise}, reg)
+}
+
+func TestBlackHoleAndReadOnly(t *testing.T) {
+	s := NewStore()
+
+	require.NoError(t, s.Yank(0, Register{Text: "keep", Kind: Charwise}))
+	require.NoError(t, s.Delete(BlackHole, Register{Text: "gone\n", Kind: Linewise}))
+
+	reg, _ := s.Get(Unnamed)
+	require.Equal(t, "keep", reg.Text)
+	_, ok := s.Get('1')
+	require.False(t, ok)
+
+	require.Error(t, s.Yank(LastInsert, Register{Text: "nope"}))
+	require.Error(t, s.Yank('!', Register{Text: "nope"}))
+
+	s.SetReadOnly(FileName, "main.go")
+	reg, ok = s.Get(FileName)
+	require.True(t, ok)
+	require.Equal(t, "main.go", reg.Text)
+}
+
+func TestList(t *testing.T) {
+	s := NewStore()
+
+	require.NoError(t, s.Yank('c', Register{Text: "c", Kind: Charwise}))
+	require.NoError(t, s.Delete(0, Register{Text: "line\n", Kind: Linewise}))
+
+	var names []rune
+	for _, entry := range s.List("") {
+		names = append(names, entry.Name)
+	}
+	require.Equal(t, []rune{'"', '1', 'c'}, names)
+}
+
+func TestListClipboard(t *testing.T) {
+	s := NewStore()
+	cb := &clipboard.Fake{Text: "copied"}
+	s.SetClipboard(cb)
+	require.NoError(t, s.Yank('a', Register{Text: "a", Kind: Charwise}))
+
+	names := func(list []Entry) []rune {
+		var names []rune
+		for _, entry := range list {
+			names = append(names, entry.Name)
+		}
+		return names
+	}
+
+	// Listing everything doesn't run the clipboard tool
+	require.Equal(t, []rune{'"', 'a'}, names(s.List("")))
+	require.NoError(t, s.Yank(Clipboard, Register{Text: "copied", Kind: Charwise}))
+	require.Equal(t, []rune{'a'}, names(s.List("")))
+	require.Zero(t, cb.Reads)
+
+	require.Equal(t, []rune{'+'}, names(s.List("+")))
+	require.Equal(t, 1, cb.Reads)
+	require.Equal(t, []rune{'a', '*'}, names(s.List("*a")))
+}
+
+func TestClipboardRegisters(t *testing.T) {
+	s := NewStore()
+	cb := &clipboard.Fake{}
+	s.SetClipboard(cb)
+
+	require.NoError(t, s.Yank(Clipboard, Register{Text: "a\nb", Kind: Blockwise}))
+	require.Equal(t, "a\nb", cb.Text)
+
+	// Kind survives a round trip through the clipboard
+	reg, ok := s.Get(Selection)
+	require.True(t, ok)
+	require.Equal(t, Blockwise, reg.Kind)
+
+	// Text copied outside of the editor
+	cb.Text = "line\n"
+	reg, ok = s.Get(Clipboard)
+	require.True(t, ok)
+	require.Equal(t, Register{Text: "line\n", Kind: Linewise}, reg)
+}
@@ -0,0 +1,120 @@
+// Package runner runs a command in the background and collects what it prints, for ":make" and the like.
+// Output streams in while the command runs and it can be stopped at any time.
+package runner
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	notifyEvery = 50 * time.Millisecond
+	stopWait    = time.Second // How long a stopped command's children may keep its output open
+)
+
+// Run is a running or finished command.
+type Run struct {
+	mu       sync.Mutex
+	output   []byte // Stdout and stderr, interleaved the way they were written
+	done     bool
+	exitCode int
+	err      error
+	cancel   context.CancelFunc
+
+	notify     func()
+	lastNotify time.Time
+}
+
+// Shell returns the command line that runs command through the system shell, "sh -c" or "cmd /C".
+func Shell(command string) (string, []string) {
+	if runtime.GOOS == "windows" {
+		return "cmd", []string{"/C", command}
+	}
+	return "sh", []string{"-c", command}
+}
+
+// Start runs name with args in dir. notify is called from the run's goroutines when output came in and once more
+// when the command exits, it must be safe to call from there. A command that can't start is returned as an error.
+func Start(dir, name string, args []string, notify func()) (*Run, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Run{cancel: cancel, notify: notify}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Stdout = r
+	cmd.Stderr = r
+	cmd.WaitDelay = stopWait
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, err
+	}
+	go r.wait(ctx, cmd)
+	return r, nil
+}
+
+// Output returns what the command printed so far and whether it exited. The slice must not be modified.
+func (r *Run) Output() ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.output, r.done
+}
+
+// ExitCode is the command's exit status once it's done, -1 when it was stopped or killed.
+func (r *Run) ExitCode() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.exitCode
+}
+
+// Err is why the command didn't run to its end, if it didn't. A non-zero exit isn't an error, stopping it is.
+func (r *Run) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Stop kills a running command. What it printed stays.
+func (r *Run) Stop() {
+	r.cancel()
+}
+
+// Write collects the command's output, it's the Stdout and Stderr of the command.
+func (r *Run) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	r.output = append(r.output, p...)
+	notify := r.notify != nil && time.Since(r.lastNotify) >= notifyEvery
+	if notify {
+		r.lastNotify = time.Now()
+	}
+	r.mu.Unlock()
+	if notify {
+		r.notify()
+	}
+	return len(p), nil
+}
+
+func (r *Run) wait(ctx context.Context, cmd *exec.Cmd) {
+	err := cmd.Wait()
+	code := cmd.ProcessState.ExitCode()
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		err = errors.New("stopped")
+	case errors.As(err, &exitErr) && code >= 0:
+		err = nil
+	}
+	r.cancel()
+
+	r.mu.Lock()
+	r.done = true
+	r.exitCode = code
+	r.err = err
+	r.mu.Unlock()
+	if r.notify != nil {
+		r.notify()
+	}
+}
@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func wait(t *testing.T, r *Run) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if output, done := r.Output(); done {
+			return string(output)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("command didn't finish")
+	return ""
+}
+
+func TestOutputAndExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	notified := make(chan struct{}, 100)
+	name, args := Shell("echo out; echo err >&2; exit 3")
+	r, err := Start(t.TempDir(), name, args, func() { notified <- struct{}{} })
+	require.NoError(t, err)
+
+	output := wait(t, r)
+	require.Contains(t, output, "out\n")
+	require.Contains(t, output, "err\n")
+	require.Equal(t, 3, r.ExitCode())
+	require.NoError(t, r.Err(), "a failing command isn't an error")
+	require.NotEmpty(t, notified)
+}
+
+func TestStop(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	name, args := Shell("sleep 10")
+	r, err := Start(t.TempDir(), name, args, nil)
+	require.NoError(t, err)
+	r.Stop()
+	wait(t, r)
+	require.Error(t, r.Err())
+	require.Equal(t, -1, r.ExitCode())
+}
+
+func TestMissingCommand(t *testing.T) {
+	_, err := Start(t.TempDir(), "no-such-command-anywhere", nil, nil)
+	require.Error(t, err)
+}
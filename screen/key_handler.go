@@ -8,25 +8,82 @@ import (
 )
 
 func (s *Screen) handleKey(ev *tcell.EventKey) bool {
+	// Any key dismisses command output, Enter/Esc/Space only do that
+	if s.output != nil {
+		s.output = nil
+		switch ev.Key() {
+		case tcell.KeyEnter, tcell.KeyEsc:
+			return true
+		case tcell.KeyRune:
+			if ev.Rune() == ' ' {
+				return true
+			}
+		}
+	}
+
+	if s.picker != nil {
+		s.handlePicker(ev)
+		return true
+	}
+
 	mode := s.editor.GetMode()
 
+	if s.editor.InCommandWindow() && mode != editor.ModeCommand && mode != editor.ModeConfirm {
+		if s.handleCommandWindow(ev) {
+			return true
+		}
+	}
+
+	if s.editor.InListWindow() && mode == editor.ModeNormal && s.handleListWindow(ev) {
+		return true
+	}
+
+	if s.editor.TreeFocused() && mode == editor.ModeNormal {
+		return s.handleTree(ev) && !s.editor.ShouldQuit()
+	}
+
+	keepRunning := true
 	switch mode {
 	case editor.ModeNormal:
-		return s.handleNormal(ev)
+		keepRunning = s.handleNormal(ev)
 	case editor.ModeInsert:
-		return s.handleInsert(ev)
+		keepRunning = s.handleInsert(ev)
+	case editor.ModeCommand:
+		keepRunning = s.handleCommand(ev)
+	case editor.ModeConfirm:
+		s.handleConfirm(ev)
 	}
 
-	return true
+	return keepRunning && !s.editor.ShouldQuit()
 }
 
 func (s *Screen) handleNormal(ev *tcell.EventKey) bool {
 	e := s.editor
 
+	if pending := e.GetPending(); pending != "" {
+		return s.handlePending(pending, ev)
+	}
+
 	switch ev.Key() {
 	case tcell.KeyCtrlC:
 		return false
 	case tcell.KeyEsc:
+		e.CancelPending()
+		return true
+	case tcell.KeyCtrlR:
+		e.Redo()
+		return true
+	case tcell.KeyCtrlCarat:
+		e.AlternateBuffer()
+		return true
+	case tcell.KeyCtrlW:
+		e.SetPending(windowPending)
+		return true
+	case tcell.KeyCtrlN:
+		s.toggleTree()
+		return true
+	case tcell.KeyCtrlP:
+		s.openPicker(newFilesSource(s))
 		return true
 	case tcell.KeyLeft:
 		e.MoveLeft()
@@ -38,6 +95,9 @@ func (s *Screen) handleNormal(ev *tcell.EventKey) bool {
 		e.MoveDown()
 	}
 
+	if ev.Key() != tcell.KeyRune {
+		return true
+	}
 	r := ev.Rune()
 
 	// This one handles the command count
@@ -45,42 +105,219 @@ func (s *Screen) handleNormal(ev *tcell.EventKey) bool {
 		return true
 	}
 
-	switch ev.Rune() {
-	case 'i':
-		e.SetMode(editor.ModeInsert)
-	case 'a':
-		e.MoveRight()
-		e.SetMode(editor.ModeInsert)
-	case 'A':
-		e.MoveToLineEnd()
-		e.SetMode(editor.ModeInsert)
-	case 'I':
-		e.MoveToLineStart()
-		e.SetMode(editor.ModeInsert)
+	switch r {
+	case 'i', 'a', 'A', 'I', 'o', 'O':
+		e.StartInsert(r)
 	case 'x':
-		e.Delete()
-	case '0':
-		e.MoveToLineStart()
-	case '$':
-		e.MoveToLineEnd()
-	case 'G':
-		e.MoveToEnd()
-	case 'h':
-		e.MoveLeft()
-	case 'j':
-		e.MoveDown()
-	case 'k':
-		e.MoveUp()
-	case 'l':
-		e.MoveRight()
-	case 'w':
-		e.MoveToNextWord()
-	case 'b':
-		e.MoveToPrevWord()
+		e.DeleteUnderCursor()
+	case 'D':
+		e.DeleteToLineEnd()
+	case 'C':
+		e.ApplyOperator('c', "$")
+	case 'Y':
+		e.YankLines()
+	case 'u':
+		e.Undo()
+	case 'p':
+		e.Paste(true)
+	case 'P':
+		e.Paste(false)
+	case '.':
+		e.RepeatLastChange()
+	case ':':
+		e.StartCommandLine(':')
+	case '/', '?':
+		e.StartSearch(r == '/')
+	case 'n', 'N':
+		e.SearchNext(r == 'N')
+	case '*', '#':
+		e.SearchWord(r == '*')
+	case 'q':
+		if s.macro.recording != 0 {
+			s.stopRecording()
+		} else {
+			e.SetPending("q")
+		}
+	case '"', 'd', 'c', 'y', 'g', 'r', '@', 'm', '\'', '`':
+		e.SetPending(string(r))
+	default:
+		if editor.IsMotion(string(r)) {
+			e.Motion(string(r))
+		}
+	}
+	return true
+}
+
+// handlePending completes multi key commands like "dd", "dw", "gg", "r{char}", "qa", "@a" and '"a'.
+func (s *Screen) handlePending(pending string, ev *tcell.EventKey) bool {
+	e := s.editor
+	e.SetPending("")
+
+	if pending == windowPending {
+		if r, ok := windowKey(ev); ok {
+			e.WindowCommand(r)
+		} else {
+			e.CancelPending()
+		}
+		return true
+	}
+	if ev.Key() != tcell.KeyRune {
+		e.CancelPending()
+		return true
+	}
+	r := ev.Rune()
+	keys := pending + string(r)
+
+	switch {
+	case pending == "q" && (r == ':' || r == '/' || r == '?'):
+		e.ClearCount()
+		if err := e.OpenCommandWindow(r); err != nil {
+			e.SetMessage(err.Error())
+		}
+	case pending == "q":
+		e.ClearCount()
+		s.startRecording(r)
+	case pending == "@":
+		return s.playMacro(r)
+	case pending == `"`:
+		if !e.SelectRegister(r) {
+			e.CancelPending()
+		}
+	case pending == "r":
+		e.ReplaceChar(r)
+	case pending == "m":
+		e.ClearCount()
+		e.SetMark(r)
+	case pending == "'" || pending == "`":
+		e.ClearCount()
+		e.JumpToMark(r, pending == "`")
+	case pending == "g":
+		switch keys {
+		case "gg":
+			e.Motion(keys)
+		case "gt":
+			e.NextTabPage()
+		case "gT":
+			e.PrevTabPage()
+		default:
+			e.CancelPending()
+		}
+	case isOperator(pending) && e.HandleMotionDigit(r):
+		// Count of the motion, "d3w"
+		e.SetPending(pending)
+	case keys == "dd" || keys == "cc" || keys == "yy":
+		e.ApplyOperatorLine(r)
+	case len(pending) == 1 && r == 'g':
+		// Operator followed by "gg"
+		e.SetPending(keys)
+	case len(pending) == 2 && keys[1:] == "gg":
+		e.ApplyOperator(rune(pending[0]), "gg")
+	case len(pending) == 1 && editor.IsMotion(string(r)):
+		e.ApplyOperator(rune(pending[0]), string(r))
+	default:
+		e.CancelPending()
+	}
+	return true
+}
+
+// isOperator reports a pending d, c or y waiting for its motion.
+func isOperator(pending string) bool {
+	return pending == "d" || pending == "c" || pending == "y"
+}
+
+// windowPending is the pending key after Ctrl-W, in the notation macros use.
+const windowPending = "<C-w>"
+
+// windowKey reads the key after Ctrl-W. Ctrl-W Ctrl-h works like Ctrl-W h, as in Vim.
+func windowKey(ev *tcell.EventKey) (rune, bool) {
+	if ev.Key() == tcell.KeyRune {
+		return ev.Rune(), true
+	}
+	if ev.Key() >= tcell.KeyCtrlA && ev.Key() <= tcell.KeyCtrlZ {
+		return rune('a' + ev.Key() - tcell.KeyCtrlA), true
+	}
+	return 0, false
+}
+
+// handleCommandWindow takes the keys the "q:" window uses differently, Enter runs the line in both normal and
+// insert mode and Ctrl-C closes the window. Returns false to let the key through.
+func (s *Screen) handleCommandWindow(ev *tcell.EventKey) bool {
+	e := s.editor
+	if e.GetPending() != "" {
+		return false
+	}
+	switch ev.Key() {
+	case tcell.KeyEnter:
+		if e.GetMode() == editor.ModeInsert {
+			e.SetMode(editor.ModeNormal)
+		}
+		e.ExecuteCommandWindowLine()
+		return true
+	case tcell.KeyCtrlC:
+		e.CloseCommandWindow()
+		return true
+	}
+	return false
+}
+
+// handleListWindow opens the entry under the cursor on Enter in a quickfix or location list window.
+func (s *Screen) handleListWindow(ev *tcell.EventKey) bool {
+	e := s.editor
+	if e.GetPending() != "" || ev.Key() != tcell.KeyEnter {
+		return false
+	}
+	if err := e.OpenListEntry(); err != nil {
+		e.SetMessage(err.Error())
+	}
+	return true
+}
+
+func (s *Screen) handleCommand(ev *tcell.EventKey) bool {
+	e := s.editor
+	switch ev.Key() {
+	case tcell.KeyEsc, tcell.KeyCtrlC:
+		e.CancelCommandLine()
+	case tcell.KeyEnter:
+		e.SubmitCommandLine()
+	case tcell.KeyTab:
+		e.CmdComplete(true)
+	case tcell.KeyBacktab:
+		e.CmdComplete(false)
+	case tcell.KeyUp:
+		e.CmdHistory(true)
+	case tcell.KeyDown:
+		e.CmdHistory(false)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		e.CmdBackspace()
+	case tcell.KeyDelete:
+		e.CmdDelete()
+	case tcell.KeyLeft:
+		e.CmdMoveLeft()
+	case tcell.KeyRight:
+		e.CmdMoveRight()
+	case tcell.KeyHome, tcell.KeyCtrlB:
+		e.CmdMoveToStart()
+	case tcell.KeyEnd, tcell.KeyCtrlE:
+		e.CmdMoveToEnd()
+	case tcell.KeyCtrlU:
+		e.CmdClear()
+	case tcell.KeyCtrlW:
+		e.CmdDeleteWord()
+	case tcell.KeyRune:
+		e.CmdInsert(ev.Rune())
 	}
 	return true
 }
 
+func (s *Screen) handleConfirm(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyEsc, tcell.KeyCtrlC:
+		s.editor.ConfirmSubstitute('q')
+	case tcell.KeyRune:
+		s.editor.ConfirmSubstitute(ev.Rune())
+	}
+}
+
 func (s *Screen) handleInsert(ev *tcell.EventKey) bool {
 	e := s.editor
 	switch ev.Key() {
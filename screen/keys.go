@@ -0,0 +1,100 @@
+package screen
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Keys are stored in registers using Vim's <> notation, so a recorded macro can be pasted, edited and yanked back.
+var keyNames = map[tcell.Key]string{
+	tcell.KeyEsc:        "Esc",
+	tcell.KeyEnter:      "CR",
+	tcell.KeyBackspace:  "BS",
+	tcell.KeyBackspace2: "BS",
+	tcell.KeyTab:        "Tab",
+	tcell.KeyDelete:     "Del",
+	tcell.KeyUp:         "Up",
+	tcell.KeyDown:       "Down",
+	tcell.KeyLeft:       "Left",
+	tcell.KeyRight:      "Right",
+	tcell.KeyHome:       "Home",
+	tcell.KeyEnd:        "End",
+	tcell.KeyPgUp:       "PageUp",
+	tcell.KeyPgDn:       "PageDown",
+}
+
+var namedKeys = map[string]tcell.Key{
+	"esc":      tcell.KeyEsc,
+	"cr":       tcell.KeyEnter,
+	"enter":    tcell.KeyEnter,
+	"bs":       tcell.KeyBackspace2,
+	"tab":      tcell.KeyTab,
+	"del":      tcell.KeyDelete,
+	"up":       tcell.KeyUp,
+	"down":     tcell.KeyDown,
+	"left":     tcell.KeyLeft,
+	"right":    tcell.KeyRight,
+	"home":     tcell.KeyHome,
+	"end":      tcell.KeyEnd,
+	"pageup":   tcell.KeyPgUp,
+	"pagedown": tcell.KeyPgDn,
+}
+
+// encodeKey turns a key event into its text form, e.g. "x", "<Esc>" or "<C-r>".
+func encodeKey(ev *tcell.EventKey) string {
+	if ev.Key() == tcell.KeyRune {
+		if ev.Rune() == '<' {
+			return "<lt>"
+		}
+		return string(ev.Rune())
+	}
+	if name, ok := keyNames[ev.Key()]; ok {
+		return "<" + name + ">"
+	}
+	if ev.Key() >= tcell.KeyCtrlA && ev.Key() <= tcell.KeyCtrlZ {
+		return "<C-" + string(rune('a'+ev.Key()-tcell.KeyCtrlA)) + ">"
+	}
+	return ""
+}
+
+// decodeKeys parses text written by encodeKey back into key events. Unknown <...> sequences are taken literally.
+func decodeKeys(text string) []*tcell.EventKey {
+	var events []*tcell.EventKey
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\n' {
+			events = append(events, tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+			continue
+		}
+		if r == '<' {
+			if end := slices.Index(runes[i+1:], '>'); end >= 0 {
+				if ev := namedKeyEvent(string(runes[i+1 : i+1+end])); ev != nil {
+					events = append(events, ev)
+					i += end + 1
+					continue
+				}
+			}
+		}
+		events = append(events, tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+	}
+	return events
+}
+
+func namedKeyEvent(name string) *tcell.EventKey {
+	lower := strings.ToLower(name)
+	if lower == "lt" {
+		return tcell.NewEventKey(tcell.KeyRune, '<', tcell.ModNone)
+	}
+	if key, ok := namedKeys[lower]; ok {
+		return tcell.NewEventKey(key, 0, tcell.ModNone)
+	}
+	if rest, ok := strings.CutPrefix(lower, "c-"); ok && len(rest) == 1 && rest[0] >= 'a' && rest[0] <= 'z' {
+		key := tcell.KeyCtrlA + tcell.Key(rest[0]-'a')
+		return tcell.NewEventKey(key, rune(key), tcell.ModCtrl)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package screen
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeKeys(t *testing.T) {
+	events := []*tcell.EventKey{
+		tcell.NewEventKey(tcell.KeyRune, 'A', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyRune, '<', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyRune, 'ö', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyCtrlR, 0, tcell.ModCtrl),
+	}
+
+	var text string
+	for _, ev := range events {
+		text += encodeKey(ev)
+	}
+	require.Equal(t, "A<lt>ö<BS><Esc><C-r>", text)
+
+	decoded := decodeKeys(text)
+	require.Len(t, decoded, len(events))
+	for i, ev := range decoded {
+		require.Equal(t, events[i].Key(), ev.Key())
+		if ev.Key() == tcell.KeyRune {
+			require.Equal(t, events[i].Rune(), ev.Rune())
+		}
+	}
+}
+
+func TestDecodeKeysLiteral(t *testing.T) {
+	// Unknown names and a lone '<' are typed as is, a newline from a yanked line is Enter
+	decoded := decodeKeys("<x>a<\n")
+	var runes []rune
+	for _, ev := range decoded {
+		if ev.Key() == tcell.KeyRune {
+			runes = append(runes, ev.Rune())
+		}
+	}
+	require.Equal(t, []rune("<x>a<"), runes)
+	require.Equal(t, tcell.KeyEnter, decoded[len(decoded)-1].Key())
+}
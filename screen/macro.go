@@ -0,0 +1,133 @@
+package screen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ogzhanolguncu/go_editor/editor"
+)
+
+// Recursive macros stop when a motion fails, this guards against the ones that never do
+const maxMacroDepth = 100
+
+type macroState struct {
+	recording rune     // Register being recorded into, 0 when not recording
+	keys      []string // Encoded keys recorded so far
+	last      rune     // Last played register, for "@@"
+	depth     int      // Nesting of running macros
+	aborted   bool     // A key failed, unwinds every running macro
+}
+
+// recordKey appends a key handleKey is about to receive to the macro being recorded.
+func (s *Screen) recordKey(ev *tcell.EventKey) {
+	if s.macro.recording == 0 || s.macro.depth > 0 {
+		return
+	}
+	s.macro.keys = append(s.macro.keys, encodeKey(ev))
+}
+
+func (s *Screen) startRecording(name rune) {
+	if !isMacroRegister(name) {
+		s.editor.SetMessage(fmt.Sprintf("E354: Invalid register name: '%c'", name))
+		return
+	}
+	s.macro.recording = name
+	s.macro.keys = s.macro.keys[:0]
+}
+
+// stopRecording saves the recorded keys, minus the "q" that stopped the recording.
+func (s *Screen) stopRecording() {
+	keys := s.macro.keys
+	if len(keys) > 0 {
+		keys = keys[:len(keys)-1]
+	}
+	if err := s.editor.SetRegister(s.macro.recording, strings.Join(keys, "")); err != nil {
+		s.editor.SetMessage(err.Error())
+	}
+	s.macro.recording = 0
+	s.macro.keys = nil
+}
+
+// playMacro feeds a register's keys through handleKey count times ("{count}@a", "@@").
+// The whole replay is one undo step and stops at the first failing command. Returns false if a key asked to quit.
+func (s *Screen) playMacro(name rune) bool {
+	e := s.editor
+	n := e.GetCountAndClear()
+
+	if name == '@' {
+		if s.macro.last == 0 {
+			e.SetMessage("E748: No previously used register")
+			return true
+		}
+		name = s.macro.last
+	}
+
+	text, ok := e.GetRegister(name)
+	if !ok {
+		e.SetMessage(fmt.Sprintf("E353: Nothing in register %c", name))
+		return true
+	}
+	if s.macro.depth >= maxMacroDepth {
+		e.SetMessage("E169: Command too recursive")
+		s.macro.aborted = true
+		return true
+	}
+
+	s.macro.last = name
+	s.macro.depth++
+	e.BeginUndoGroup()
+	e.BeginKeyReplay()
+	defer func() {
+		e.EndKeyReplay()
+		e.EndUndoGroup()
+		s.macro.depth--
+		if s.macro.depth == 0 {
+			s.macro.aborted = false
+		}
+	}()
+
+	// Only failures of the macro's own keys stop it, not one from before it was played, like "j" on the last line
+	e.TakeFailure()
+	events := decodeKeys(text)
+	for range n {
+		for _, ev := range events {
+			if !s.handleKey(ev) {
+				return false
+			}
+			if e.TakeFailure() {
+				s.macro.aborted = true
+			}
+			if s.macro.aborted {
+				return true
+			}
+		}
+	}
+	return true
+}
+
+// executeKeys runs ":normal" keys. They're taken literally, and a command left unfinished is cancelled
+// the way Vim does: insert mode is left and a half typed operator dropped.
+func (s *Screen) executeKeys(keys string) {
+	e := s.editor
+	e.TakeFailure() // A failure from before isn't one of these keys
+	e.BeginKeyReplay()
+	defer e.EndKeyReplay()
+	for _, r := range keys {
+		if !s.handleKey(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)) || e.TakeFailure() {
+			break
+		}
+	}
+
+	switch e.GetMode() {
+	case editor.ModeInsert:
+		e.SetMode(editor.ModeNormal)
+	case editor.ModeCommand:
+		e.CancelCommandLine()
+	}
+	e.CancelPending()
+}
+
+func isMacroRegister(name rune) bool {
+	return (name >= 'a' && name <= 'z') || (name >= 'A' && name <= 'Z') || (name >= '0' && name <= '9') || name == '"'
+}
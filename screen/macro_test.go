@@ -0,0 +1,57 @@
+package screen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacro(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		keys    string
+		want    string
+		cursor  int
+		message string
+	}{
+		{name: "record and play", text: "abc def ghi", keys: "qaxwq@a", want: "bc ef ghi", cursor: 6},
+		{name: "count", text: "abcdef", keys: "qaxq3@a", want: "ef", cursor: 0},
+		{name: "repeat last", text: "abcdef", keys: "qaxq@a@@", want: "def", cursor: 0},
+		// "j" fails on the only line, the macro played after it still runs
+		{name: "failure before playing", text: "abcde", keys: "qaxxqj@a", want: "e", cursor: 0},
+		// "j" fails on the last line and stops the recursion
+		{name: "recursive stops at failure", text: "a\nb\nc", keys: "qaq" + "qarXj@aq" + "gg@a", want: "X\nX\nX", cursor: 4},
+		{name: "nothing recorded", text: "abc", keys: "@b", want: "abc", message: "E353: Nothing in register b"},
+		{name: "no previous", text: "abc", keys: "@@", want: "abc", message: "E748: No previously used register"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestScreen(t, tt.text)
+			s.feed(tt.keys)
+			require.Equal(t, tt.want, s.editor.GetContent())
+			require.Equal(t, tt.cursor, s.editor.GetCursorPosition())
+			if tt.message != "" {
+				require.Equal(t, tt.message, s.editor.GetMessage())
+			}
+		})
+	}
+}
+
+func TestMacroRecursionDepth(t *testing.T) {
+	// Nothing in the macro fails, so only the depth limit ends it
+	s := newTestScreen(t, "abc")
+	require.NoError(t, s.editor.SetRegister('a', "ix<Esc>@a"))
+	s.feed("@a")
+
+	require.Equal(t, strings.Repeat("x", maxMacroDepth)+"abc", s.editor.GetContent())
+	require.Equal(t, "E169: Command too recursive", s.editor.GetMessage())
+	require.Zero(t, s.macro.depth)
+	require.False(t, s.macro.aborted)
+
+	// The whole run is one undo step
+	s.feed("u")
+	require.Equal(t, "abc", s.editor.GetContent())
+}
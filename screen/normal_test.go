@@ -0,0 +1,35 @@
+package screen
+
+import (
+	"testing"
+
+	"github.com/ogzhanolguncu/go_editor/editor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		keys string
+		want string
+	}{
+		{name: "current line", text: "a\nb", keys: ":normal A;<CR>", want: "a;\nb"},
+		{name: "range", text: "a\nb\nc", keys: ":1,2normal I-<CR>", want: "-a\n-b\nc"},
+		{name: "global", text: "x1\ny\nx2", keys: ":g/x/normal A;<CR>", want: "x1;\ny\nx2;"},
+		// "j" fails on the last line before ":g" runs, the keys still run on every line
+		{name: "global after a failure", text: "x1\ny\nx2", keys: "Gj:g/x/normal A;<CR>", want: "x1;\ny\nx2;"},
+		// A failing key stops the keys on that line only
+		{name: "failure stops the line", text: "ab\nc\nde", keys: ":%normal lx<CR>", want: "a\nc\nd"},
+		{name: "pending operator dropped", text: "abc", keys: ":normal d<CR>x", want: "bc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestScreen(t, tt.text)
+			s.feed(tt.keys)
+			require.Equal(t, tt.want, s.editor.GetContent())
+			require.Equal(t, editor.ModeNormal, s.editor.GetMode())
+		})
+	}
+}
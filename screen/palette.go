@@ -1,11 +1,15 @@
 package screen
 
 import (
+	"github.com/alecthomas/chroma/v2"
 	"github.com/gdamore/tcell/v2"
 	"github.com/ogzhanolguncu/go_editor/editor"
+	"github.com/ogzhanolguncu/go_editor/gotest"
 )
 
 type Palette struct {
+	name string
+
 	lineNumStyle          tcell.Style
 	gutterStyle           tcell.Style
 	currentLineStyle      tcell.Style
@@ -13,31 +17,192 @@ type Palette struct {
 	insertModeStyle       tcell.Style
 	statusBarMessageStyle tcell.Style
 	normalTextStyle       tcell.Style
+	commandLineStyle      tcell.Style
+	errorMessageStyle     tcell.Style
+	confirmMatchStyle     tcell.Style
+	searchMatchStyle      tcell.Style
+	currentMatchStyle     tcell.Style
+	wildmenuStyle         tcell.Style
+	wildmenuSelectedStyle tcell.Style
+	inactiveStatusStyle   tcell.Style
+	separatorStyle        tcell.Style
+	tablineStyle          tcell.Style
+	tablineSelectedStyle  tcell.Style
+	treeCurrentColor      tcell.Color
+	popupStyle            tcell.Style
+	popupSelectedStyle    tcell.Style
+	popupBorderStyle      tcell.Style
+	popupMatchColor       tcell.Color
+	popupDimColor         tcell.Color
+	testSignStyles        map[gotest.Status]tcell.Style
+	syntaxColors          map[chroma.TokenType]tcell.Color
 }
 
-func NewPalette() *Palette {
-	s := tcell.StyleDefault
+// theme is the handful of colors a palette is built from.
+type theme struct {
+	name          string
+	bg            tcell.Color
+	currentLineBg tcell.Color
+	text          tcell.Color
+	lineNum       tcell.Color
+	accent        tcell.Color // Insert mode, selections
+	primary       tcell.Color // Normal mode messages, current match
+	primaryDark   tcell.Color // Normal mode status line
+	err           tcell.Color
+	matchBg       tcell.Color // Search matches
+
+	// Syntax highlighting
+	keyword  tcell.Color
+	str      tcell.Color
+	comment  tcell.Color
+	number   tcell.Color
+	function tcell.Color
+	typ      tcell.Color
+	operator tcell.Color
+}
+
+// themes are what the themes picker offers, the first one is the default.
+var themes = []theme{
+	{
+		name:          "mint",
+		bg:            tcell.NewRGBColor(16, 16, 16),
+		currentLineBg: tcell.NewRGBColor(22, 22, 22),
+		text:          tcell.NewRGBColor(255, 255, 255),
+		lineNum:       tcell.NewRGBColor(80, 80, 80),
+		accent:        tcell.NewRGBColor(255, 179, 102),
+		primary:       tcell.NewRGBColor(153, 255, 228),
+		primaryDark:   tcell.NewRGBColor(72, 134, 119),
+		err:           tcell.NewRGBColor(255, 107, 107),
+		matchBg:       tcell.NewRGBColor(92, 78, 38),
 
-	editorBg := tcell.NewRGBColor(16, 16, 16)
-	currentLineBg := tcell.NewRGBColor(22, 22, 22)
-	textColor := tcell.NewRGBColor(255, 255, 255)
-	lineNumColor := tcell.NewRGBColor(80, 80, 80)
+		keyword:  tcell.NewRGBColor(199, 146, 234),
+		str:      tcell.NewRGBColor(195, 232, 141),
+		comment:  tcell.NewRGBColor(98, 114, 110),
+		number:   tcell.NewRGBColor(247, 140, 108),
+		function: tcell.NewRGBColor(130, 170, 255),
+		typ:      tcell.NewRGBColor(153, 255, 228),
+		operator: tcell.NewRGBColor(137, 221, 255),
+	},
+	{
+		name:          "gruvbox",
+		bg:            tcell.NewRGBColor(40, 40, 40),
+		currentLineBg: tcell.NewRGBColor(50, 48, 47),
+		text:          tcell.NewRGBColor(235, 219, 178),
+		lineNum:       tcell.NewRGBColor(124, 111, 100),
+		accent:        tcell.NewRGBColor(254, 128, 25),
+		primary:       tcell.NewRGBColor(184, 187, 38),
+		primaryDark:   tcell.NewRGBColor(121, 116, 14),
+		err:           tcell.NewRGBColor(251, 73, 52),
+		matchBg:       tcell.NewRGBColor(80, 73, 69),
 
-	warmOrange := tcell.NewRGBColor(255, 179, 102)
-	mintGreen := tcell.NewRGBColor(153, 255, 228)
-	darkMint := tcell.NewRGBColor(72, 134, 119)
+		keyword:  tcell.NewRGBColor(251, 73, 52),
+		str:      tcell.NewRGBColor(184, 187, 38),
+		comment:  tcell.NewRGBColor(146, 131, 116),
+		number:   tcell.NewRGBColor(211, 134, 155),
+		function: tcell.NewRGBColor(142, 192, 124),
+		typ:      tcell.NewRGBColor(250, 189, 47),
+		operator: tcell.NewRGBColor(254, 128, 25),
+	},
+	{
+		name:          "nord",
+		bg:            tcell.NewRGBColor(46, 52, 64),
+		currentLineBg: tcell.NewRGBColor(59, 66, 82),
+		text:          tcell.NewRGBColor(236, 239, 244),
+		lineNum:       tcell.NewRGBColor(76, 86, 106),
+		accent:        tcell.NewRGBColor(208, 135, 112),
+		primary:       tcell.NewRGBColor(136, 192, 208),
+		primaryDark:   tcell.NewRGBColor(94, 129, 172),
+		err:           tcell.NewRGBColor(191, 97, 106),
+		matchBg:       tcell.NewRGBColor(67, 76, 94),
+
+		keyword:  tcell.NewRGBColor(129, 161, 193),
+		str:      tcell.NewRGBColor(163, 190, 140),
+		comment:  tcell.NewRGBColor(97, 110, 136),
+		number:   tcell.NewRGBColor(180, 142, 173),
+		function: tcell.NewRGBColor(136, 192, 208),
+		typ:      tcell.NewRGBColor(143, 188, 187),
+		operator: tcell.NewRGBColor(129, 161, 193),
+	},
+}
+
+func NewPalette() *Palette {
+	return newPalette(themes[0])
+}
+
+func newPalette(t theme) *Palette {
+	s := tcell.StyleDefault
 
 	return &Palette{
-		lineNumStyle:          s.Foreground(lineNumColor).Background(editorBg),
-		gutterStyle:           s.Foreground(mintGreen).Background(editorBg).Dim(true),
-		currentLineStyle:      s.Background(currentLineBg).Foreground(textColor),
-		normalModeStyle:       s.Background(darkMint).Foreground(textColor),
-		insertModeStyle:       s.Background(warmOrange).Foreground(editorBg),
-		statusBarMessageStyle: s.Background(mintGreen).Foreground(editorBg),
-		normalTextStyle:       s.Foreground(textColor).Background(editorBg),
+		name:                  t.name,
+		lineNumStyle:          s.Foreground(t.lineNum).Background(t.bg),
+		gutterStyle:           s.Foreground(t.primary).Background(t.bg).Dim(true),
+		currentLineStyle:      s.Background(t.currentLineBg).Foreground(t.text),
+		normalModeStyle:       s.Background(t.primaryDark).Foreground(t.text),
+		insertModeStyle:       s.Background(t.accent).Foreground(t.bg),
+		statusBarMessageStyle: s.Background(t.primary).Foreground(t.bg),
+		normalTextStyle:       s.Foreground(t.text).Background(t.bg),
+		commandLineStyle:      s.Foreground(t.text).Background(t.bg),
+		errorMessageStyle:     s.Background(t.err).Foreground(t.bg),
+		confirmMatchStyle:     s.Background(t.accent).Foreground(t.bg).Bold(true),
+		searchMatchStyle:      s.Background(t.matchBg).Foreground(t.text),
+		currentMatchStyle:     s.Background(t.primary).Foreground(t.bg).Bold(true),
+		wildmenuStyle:         s.Background(t.currentLineBg).Foreground(t.text),
+		wildmenuSelectedStyle: s.Background(t.accent).Foreground(t.bg).Bold(true),
+		inactiveStatusStyle:   s.Background(t.currentLineBg).Foreground(t.lineNum),
+		separatorStyle:        s.Background(t.bg).Foreground(t.lineNum),
+		tablineStyle:          s.Background(t.currentLineBg).Foreground(t.lineNum),
+		tablineSelectedStyle:  s.Background(t.primaryDark).Foreground(t.text).Bold(true),
+		treeCurrentColor:      t.primary,
+		popupStyle:            s.Background(t.currentLineBg).Foreground(t.text),
+		popupSelectedStyle:    s.Background(t.primaryDark).Foreground(t.text),
+		popupBorderStyle:      s.Background(t.currentLineBg).Foreground(t.lineNum),
+		popupMatchColor:       t.accent,
+		popupDimColor:         t.lineNum,
+		testSignStyles: map[gotest.Status]tcell.Style{
+			gotest.Running: s.Foreground(t.accent).Background(t.bg),
+			gotest.Passed:  s.Foreground(t.primary).Background(t.bg).Bold(true),
+			gotest.Failed:  s.Foreground(t.err).Background(t.bg).Bold(true),
+			gotest.Skipped: s.Foreground(t.lineNum).Background(t.bg),
+		},
+		syntaxColors: map[chroma.TokenType]tcell.Color{
+			chroma.Keyword:           t.keyword,
+			chroma.KeywordType:       t.typ,
+			chroma.KeywordConstant:   t.number,
+			chroma.NameFunction:      t.function,
+			chroma.NameBuiltin:       t.function,
+			chroma.NameBuiltinPseudo: t.number,
+			chroma.NameClass:         t.typ,
+			chroma.NameConstant:      t.number,
+			chroma.NameTag:           t.keyword,
+			chroma.NameAttribute:     t.function,
+			chroma.NameDecorator:     t.function,
+			chroma.LiteralString:     t.str,
+			chroma.LiteralNumber:     t.number,
+			chroma.Comment:           t.comment,
+			chroma.CommentPreproc:    t.keyword,
+			chroma.Operator:          t.operator,
+			chroma.GenericInserted:   t.str,
+			chroma.GenericDeleted:    t.err,
+			chroma.GenericHeading:    t.keyword,
+			chroma.GenericSubheading: t.function,
+		},
 	}
 }
 
+// themeNames lists the themes in picker order.
+func themeNames() []string {
+	names := make([]string, len(themes))
+	for i, t := range themes {
+		names[i] = t.name
+	}
+	return names
+}
+
+// Name is the theme the palette was built from.
+func (p *Palette) Name() string {
+	return p.name
+}
+
 func (p *Palette) StyleForLineNum() tcell.Style {
 	return p.lineNumStyle
 }
@@ -64,3 +229,103 @@ func (p *Palette) StyleForStatusMessage() tcell.Style {
 func (p *Palette) StyleForNormalText() tcell.Style {
 	return p.normalTextStyle
 }
+
+func (p *Palette) StyleForCommandLine() tcell.Style {
+	return p.commandLineStyle
+}
+
+func (p *Palette) StyleForErrorMessage() tcell.Style {
+	return p.errorMessageStyle
+}
+
+func (p *Palette) StyleForConfirmMatch() tcell.Style {
+	return p.confirmMatchStyle
+}
+
+func (p *Palette) StyleForSearchMatch() tcell.Style {
+	return p.searchMatchStyle
+}
+
+// StyleForCurrentMatch marks the match "/" previews while the pattern is typed.
+func (p *Palette) StyleForCurrentMatch() tcell.Style {
+	return p.currentMatchStyle
+}
+
+func (p *Palette) StyleForWildmenu() tcell.Style {
+	return p.wildmenuStyle
+}
+
+func (p *Palette) StyleForWildmenuSelected() tcell.Style {
+	return p.wildmenuSelectedStyle
+}
+
+// StyleForInactiveStatusBar is the status line of windows other than the current one.
+func (p *Palette) StyleForInactiveStatusBar() tcell.Style {
+	return p.inactiveStatusStyle
+}
+
+// StyleForSeparator draws the line between windows split side by side.
+func (p *Palette) StyleForSeparator() tcell.Style {
+	return p.separatorStyle
+}
+
+func (p *Palette) StyleForTabline() tcell.Style {
+	return p.tablineStyle
+}
+
+func (p *Palette) StyleForTablineSelected() tcell.Style {
+	return p.tablineSelectedStyle
+}
+
+// ColorForTreeCurrent is the text color of the current file in the file tree.
+func (p *Palette) ColorForTreeCurrent() tcell.Color {
+	return p.treeCurrentColor
+}
+
+// StyleForPopup is the body of popups drawn over the windows, like the fuzzy finder.
+func (p *Palette) StyleForPopup() tcell.Style {
+	return p.popupStyle
+}
+
+func (p *Palette) StyleForPopupSelected() tcell.Style {
+	return p.popupSelectedStyle
+}
+
+func (p *Palette) StyleForPopupBorder() tcell.Style {
+	return p.popupBorderStyle
+}
+
+// ColorForPopupMatch is the text color of the characters a fuzzy query matched.
+func (p *Palette) ColorForPopupMatch() tcell.Color {
+	return p.popupMatchColor
+}
+
+// ColorForPopupDim is the text color of picker labels and preview line numbers.
+func (p *Palette) ColorForPopupDim() tcell.Color {
+	return p.popupDimColor
+}
+
+// StyleForTestSign colors the gutter sign of a test's result.
+func (p *Palette) StyleForTestSign(status gotest.Status) tcell.Style {
+	return p.testSignStyles[status]
+}
+
+// StyleForToken colors text of a syntax token type over base, the line's own style, so the current line keeps its
+// background. Types without a color of their own take their category's, "string" for a raw string.
+func (p *Palette) StyleForToken(base tcell.Style, typ chroma.TokenType) tcell.Style {
+	for _, t := range []chroma.TokenType{typ, typ.SubCategory(), typ.Category()} {
+		color, ok := p.syntaxColors[t]
+		if !ok {
+			continue
+		}
+		style := base.Foreground(color)
+		switch {
+		case t == chroma.Keyword:
+			style = style.Bold(true)
+		case t.InCategory(chroma.Comment) && t != chroma.CommentPreproc:
+			style = style.Italic(true)
+		}
+		return style
+	}
+	return base
+}
@@ -0,0 +1,296 @@
+package screen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ogzhanolguncu/go_editor/editor"
+	"github.com/ogzhanolguncu/go_editor/fuzzy"
+)
+
+// Source feeds a picker: the items the query filters, a preview of one of them and what picking it does.
+type Source interface {
+	Title() string
+	// Items returns the items so far and whether more are coming. Later calls may return more items, but the
+	// ones already returned keep their index.
+	Items() ([]string, bool)
+	// Preview shows item i in about height lines. False for sources without a preview.
+	Preview(i, height int) (editor.Preview, bool)
+	// Accept picks item i. target tells Enter, Ctrl-V and Ctrl-X apart, sources that don't open files ignore it.
+	Accept(i int, target editor.OpenTarget) error
+}
+
+// labeler is a Source showing something in front of each item that the query doesn't match, like a line number.
+type labeler interface {
+	Label(i int) string
+}
+
+// closer is a Source with background work to stop when the picker closes.
+type closer interface {
+	Close()
+}
+
+const (
+	pickerMaxResults   = 15
+	pickerMaxWidth     = 120
+	pickerPreviewWidth = 60 // Narrower popups drop the preview pane
+)
+
+// picker is the open popup. Items can keep arriving while it's open, the results are filtered again whenever
+// the query or the item count changed.
+type picker struct {
+	source   Source
+	query    []rune
+	selected int
+
+	results     []fuzzy.Match
+	matched     int    // How many items matched, results only keeps the best
+	resultQuery string // Query and item count the results are for
+	resultCount int
+}
+
+func (s *Screen) openPicker(source Source) {
+	s.closePicker()
+	s.editor.CancelPending()
+	s.picker = &picker{source: source, resultCount: -1}
+}
+
+func (s *Screen) closePicker() {
+	if s.picker == nil {
+		return
+	}
+	if c, ok := s.picker.source.(closer); ok {
+		c.Close()
+	}
+	s.picker = nil
+}
+
+func (p *picker) update() {
+	items, _ := p.source.Items()
+	query := string(p.query)
+	if query == p.resultQuery && len(items) == p.resultCount {
+		return
+	}
+	matches := fuzzy.Filter(query, items)
+	p.matched = len(matches)
+	p.results = matches[:min(len(matches), pickerMaxResults)]
+	p.resultQuery, p.resultCount = query, len(items)
+	p.selected = min(p.selected, max(0, len(p.results)-1))
+}
+
+// move moves the selection down or up, wrapping around.
+func (p *picker) move(down bool) {
+	p.update()
+	if len(p.results) == 0 {
+		return
+	}
+	if down {
+		p.selected = (p.selected + 1) % len(p.results)
+	} else {
+		p.selected = (p.selected - 1 + len(p.results)) % len(p.results)
+	}
+}
+
+func (p *picker) edit(query []rune) {
+	p.query = query
+	p.selected = 0
+}
+
+// accept closes the picker and picks the selection.
+func (s *Screen) acceptPicker(target editor.OpenTarget) {
+	p := s.picker
+	p.update()
+	if len(p.results) == 0 {
+		return
+	}
+	index := p.results[p.selected].Index
+	s.closePicker()
+	if err := p.source.Accept(index, target); err != nil {
+		s.editor.SetMessage(err.Error())
+	}
+}
+
+// handlePicker takes every key while a picker is open.
+func (s *Screen) handlePicker(ev *tcell.EventKey) {
+	p := s.picker
+	switch ev.Key() {
+	case tcell.KeyEsc, tcell.KeyCtrlC:
+		s.closePicker()
+	case tcell.KeyEnter:
+		s.acceptPicker(editor.OpenHere)
+	case tcell.KeyCtrlV:
+		s.acceptPicker(editor.OpenVSplit)
+	case tcell.KeyCtrlX:
+		s.acceptPicker(editor.OpenSplit)
+	case tcell.KeyDown, tcell.KeyCtrlN, tcell.KeyTab:
+		p.move(true)
+	case tcell.KeyUp, tcell.KeyCtrlP, tcell.KeyBacktab:
+		p.move(false)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if n := len(p.query); n > 0 {
+			p.edit(p.query[:n-1])
+		}
+	case tcell.KeyCtrlU:
+		p.edit(p.query[:0])
+	case tcell.KeyRune:
+		p.edit(append(p.query, ev.Rune()))
+	}
+}
+
+// renderPicker draws the open picker centered over the windows: the query and a count, the best matches with
+// their matched characters highlighted and, when there's room, a preview of the selection on the right.
+// Returns where the cursor goes.
+func (s *Screen) renderPicker() (int, int) {
+	p := s.picker
+	p.update()
+	items, loading := p.source.Items()
+
+	width := min(pickerMaxWidth, s.width-4)
+	height := min(pickerMaxResults+4, s.height-cmdlineHeight-2)
+	if width < 10 || height < 5 {
+		return 0, 0
+	}
+	x := (s.width - width) / 2
+	y := (s.height - cmdlineHeight - height) / 2
+
+	var preview editor.Preview
+	hasPreview := false
+	if width >= pickerPreviewWidth && len(p.results) > 0 {
+		preview, hasPreview = p.source.Preview(p.results[p.selected].Index, height-2)
+	}
+	listWidth := width
+	if hasPreview {
+		listWidth = width * 2 / 5
+	}
+	inner := listWidth - 2
+
+	border := s.palette.StyleForPopupBorder()
+	style := s.palette.StyleForPopup()
+	s.drawBox(x, y, width, height, " "+p.source.Title()+" ", border)
+
+	count := fmt.Sprintf("%d/%d", p.matched, len(items))
+	if loading {
+		count = "… " + count
+	}
+	prompt := "> " + string(p.query)
+	s.drawSpan(x+1, y+1, inner, prompt, style)
+	if countX := x + listWidth - 2 - len([]rune(count)); countX > x+1+len([]rune(prompt)) {
+		s.drawText(countX, y+1, count, border)
+	}
+	for col := x + 1; col < x+listWidth-1; col++ {
+		s.screen.SetContent(col, y+2, '─', nil, border)
+	}
+	s.screen.SetContent(x, y+2, '├', nil, border)
+
+	for i := 0; i < height-4; i++ {
+		rowStyle := style
+		if i == p.selected && i < len(p.results) {
+			rowStyle = s.palette.StyleForPopupSelected()
+		}
+		s.drawSpan(x+1, y+3+i, inner, "", rowStyle)
+		if i < len(p.results) {
+			s.drawPickerItem(x+1, y+3+i, inner, p.results[i], rowStyle)
+		}
+	}
+
+	if hasPreview {
+		sep := x + listWidth - 1
+		for row := y + 1; row < y+height-1; row++ {
+			s.screen.SetContent(sep, row, '│', nil, border)
+		}
+		s.screen.SetContent(sep, y, '┬', nil, border)
+		s.screen.SetContent(sep, y+2, '┤', nil, border)
+		s.screen.SetContent(sep, y+height-1, '┴', nil, border)
+		s.drawPreview(sep+1, y+1, x+width-1-(sep+1), height-2, preview)
+	} else {
+		s.screen.SetContent(x+width-1, y+2, '┤', nil, border)
+	}
+
+	return min(x+1+len([]rune(prompt)), x+listWidth-2), y + 1
+}
+
+// drawPickerItem draws an item with its matched characters highlighted, after its label if it has one.
+// Items too long for the popup lose their start, for paths the file name is the part worth seeing.
+func (s *Screen) drawPickerItem(x, y, width int, m fuzzy.Match, style tcell.Style) {
+	col := x + 1
+	if l, ok := s.picker.source.(labeler); ok {
+		label := l.Label(m.Index) + " "
+		s.drawSpan(col, y, max(0, x+width-col), label, style.Foreground(s.palette.ColorForPopupDim()))
+		col += len([]rune(label))
+	}
+
+	runes := []rune(m.Str)
+	room := x + width - col
+	if room <= 0 {
+		return
+	}
+	start := 0
+	if len(runes) > room {
+		start = len(runes) - room + 1
+		s.screen.SetContent(col, y, '…', nil, style)
+		col++
+	}
+	match := style.Foreground(s.palette.ColorForPopupMatch()).Bold(true)
+	for i, r := range runes[start:] {
+		st := style
+		if slices.Contains(m.Positions, start+i) {
+			st = match
+		}
+		if r == '\t' {
+			r = ' '
+		}
+		s.screen.SetContent(col+i, y, r, nil, st)
+	}
+}
+
+// drawPreview draws preview lines with their line numbers, the focused line highlighted.
+func (s *Screen) drawPreview(x, y, width, height int, preview editor.Preview) {
+	style := s.palette.StyleForPopup()
+	numStyle := style.Foreground(s.palette.ColorForPopupDim())
+	gutter := len(fmt.Sprint(preview.First+len(preview.Lines))) + 1
+
+	for i := 0; i < height; i++ {
+		rowStyle := style
+		if i >= len(preview.Lines) {
+			s.drawSpan(x, y+i, width, "", style)
+			continue
+		}
+		line := preview.First + i
+		if line == preview.Focus {
+			rowStyle = s.palette.StyleForPopupSelected()
+		}
+		text := strings.ReplaceAll(preview.Lines[i], "\t", strings.Repeat(" ", tabSize))
+		s.drawSpan(x, y+i, width, "", rowStyle)
+		s.drawSpan(x, y+i, min(width, gutter), fmt.Sprintf("%*d ", gutter-1, line+1), numStyle)
+		if width > gutter+1 {
+			s.drawSpan(x+gutter+1, y+i, width-gutter-1, text, rowStyle)
+		}
+	}
+}
+
+// drawBox draws a rounded border with a title on its top edge.
+func (s *Screen) drawBox(x, y, width, height int, title string, style tcell.Style) {
+	for col := x + 1; col < x+width-1; col++ {
+		s.screen.SetContent(col, y, '─', nil, style)
+		s.screen.SetContent(col, y+height-1, '─', nil, style)
+	}
+	for row := y + 1; row < y+height-1; row++ {
+		s.screen.SetContent(x, row, '│', nil, style)
+		s.screen.SetContent(x+width-1, row, '│', nil, style)
+	}
+	s.screen.SetContent(x, y, '╭', nil, style)
+	s.screen.SetContent(x+width-1, y, '╮', nil, style)
+	s.screen.SetContent(x, y+height-1, '╰', nil, style)
+	s.screen.SetContent(x+width-1, y+height-1, '╯', nil, style)
+	s.drawText(x+2, y, title, style)
+}
+
+// redrawTick wakes the event loop when background work, like the files picker's walk or a ":grep", has something
+// new to show.
+type redrawTick struct{}
+
+func (s *Screen) postRedraw() {
+	_ = s.screen.PostEvent(tcell.NewEventInterrupt(redrawTick{}))
+}
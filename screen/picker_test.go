@@ -0,0 +1,49 @@
+package screen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPicker(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    string
+		line    int  // Cursor line after, 0-based
+		open    bool // Whether the picker is still open
+		message string
+	}{
+		{name: "accept the best match", keys: ":pick lines<CR>gam<CR>", line: 2},
+		{name: "tab moves down", keys: ":pick lines<CR><Tab><Tab><CR>", line: 2},
+		{name: "moving up wraps", keys: ":pick lines<CR><Up><CR>", line: 3},
+		{name: "ctrl-u clears the query", keys: ":pick lines<CR>del<C-U><Down><CR>", line: 1},
+		{name: "no match keeps it open", keys: ":pick lines<CR>zzz<CR>", open: true},
+		{name: "escape closes", keys: ":pick lines<CR>del<Esc>", line: 0},
+		{name: "marks", keys: "jjmaggjj:pick marks<CR><CR>", line: 2},
+		{name: "unknown source", keys: ":pick nope<CR>", message: "E475: Invalid argument: nope"},
+		{name: "no source", keys: ":pick<CR>", message: "E471: Argument required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestScreen(t, "alpha\nbeta\ngamma\ndelta")
+			s.feed(tt.keys)
+			require.Equal(t, tt.open, s.picker != nil)
+			line, _ := s.editor.GetLineColumn()
+			require.Equal(t, tt.line, line)
+			if tt.message != "" {
+				require.Equal(t, tt.message, s.editor.GetMessage())
+			}
+		})
+	}
+}
+
+func TestBuffersPickerLabel(t *testing.T) {
+	s := newTestScreen(t, "text")
+	s.feed("x")
+	source := newBuffersSource(s).(*buffersSource)
+	require.Equal(t, "test.txt", filepath.Base(source.names[0]))
+	require.Equal(t, "  1 %+", source.Label(0))
+}
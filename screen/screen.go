@@ -2,9 +2,14 @@ package screen
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/ogzhanolguncu/go_editor/clipboard"
 	"github.com/ogzhanolguncu/go_editor/editor"
+	"github.com/ogzhanolguncu/go_editor/gotest"
+	"github.com/ogzhanolguncu/go_editor/highlighter"
+	"github.com/ogzhanolguncu/go_editor/layout"
 )
 
 type Screen struct {
@@ -12,14 +17,29 @@ type Screen struct {
 	editor  *editor.Editor
 	palette *Palette
 
-	width   int
-	height  int
-	yOffset int
-	xOffset int
+	width  int
+	height int
+
+	// Bracketed paste arrives as key events between a start and end EventPaste
+	pasting bool
+	pasted  strings.Builder
+
+	macro macroState
+
+	output []string // Command output shown over the text until the next key
+
+	tabLabels []tabLabel // Where the tabline labels were drawn, for mouse clicks
+
+	picker *picker // Open fuzzy picker, it takes every key
+
+	typeahead []*tcell.EventKey // Keys typed while a search jump waits for its matches
+
+	stop chan struct{} // Closed by Close to end background goroutines
 }
 
 const (
-	statusBarHeight = 1
+	statusBarHeight = 1 // Every window's last row
+	cmdlineHeight   = 1 // Bottom row of the screen, for messages and the command line
 	gutterPadding   = 2 // Space between gutter and text (separator + margin)
 	tabSize         = 4
 )
@@ -34,9 +54,21 @@ func NewScreen(editor *editor.Editor) (*Screen, error) {
 		return nil, err
 	}
 
+	screen.EnablePaste()
+	screen.EnableMouse(tcell.MouseButtonEvents)
 	width, height := screen.Size()
 
-	return &Screen{
+	cb, err := clipboard.Detect(clipboard.ConfigFromEnv(), screen)
+	if err != nil {
+		editor.SetMessage(err.Error())
+		cb = clipboard.New(clipboard.NewOSC52(screen))
+	}
+	// Provider "none" leaves + and * as plain registers
+	if cb != nil {
+		editor.SetClipboard(cb)
+	}
+
+	s := &Screen{
 		screen:  screen,
 		editor:  editor,
 		palette: NewPalette(),
@@ -44,9 +76,14 @@ func NewScreen(editor *editor.Editor) (*Screen, error) {
 		width:  width,
 		height: height,
 
-		yOffset: 0,
-		xOffset: 0,
-	}, nil
+		stop: make(chan struct{}),
+	}
+	editor.SetKeyExecutor(s.executeKeys)
+	editor.SetRedraw(s.postRedraw)
+	editor.SetSyntaxPost(s.postSyntax)
+	s.registerPickCommand()
+	go s.watchFiles()
+	return s, nil
 }
 
 func (s *Screen) Run() {
@@ -56,17 +93,103 @@ func (s *Screen) Run() {
 
 		switch ev := ev.(type) {
 		case *tcell.EventKey:
-			if !s.handleKey(ev) {
+			if !s.typeKey(ev) {
 				return
 			}
 
+		case *tcell.EventPaste:
+			if ev.Start() {
+				s.pasting = true
+				s.pasted.Reset()
+				continue
+			}
+			s.pasting = false
+			s.editor.PasteText(s.pasted.String())
+
+		case *tcell.EventMouse:
+			s.handleMouse(ev)
+
+		case *tcell.EventInterrupt:
+			switch data := ev.Data().(type) {
+			case fileTick:
+				s.editor.CheckFileTree()
+				// A finished job waiting for normal mode to jump gets its turn
+				if !s.poll() {
+					return
+				}
+			case redrawTick:
+				if !s.poll() {
+					return
+				}
+			case highlighter.Update:
+				s.editor.ApplySyntax(data)
+			}
+
 		case *tcell.EventResize:
 			s.screen.Sync()
 		}
 	}
 }
 
+// collectPaste buffers a pasted key instead of dispatching it, so the whole paste becomes one insert.
+// Tabs and line breaks arrive as keys of their own. Line breaks are kept as they came, "\r\n" is two keys
+// and PasteText turns it into one newline.
+func (s *Screen) collectPaste(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyRune:
+		s.pasted.WriteRune(ev.Rune())
+	case tcell.KeyEnter:
+		s.pasted.WriteRune('\r')
+	case tcell.KeyLF:
+		s.pasted.WriteRune('\n')
+	case tcell.KeyTab:
+		s.pasted.WriteRune('\t')
+	}
+}
+
+// typeKey takes a key from the terminal. Returns false when it quits.
+func (s *Screen) typeKey(ev *tcell.EventKey) bool {
+	switch {
+	case s.pasting:
+		s.collectPaste(ev)
+	case s.editor.SearchPending():
+		s.holdKey(ev)
+	default:
+		s.recordKey(ev)
+		return s.handleKey(ev)
+	}
+	return true
+}
+
+// holdKey keeps a key typed while a search jump waits, what it does depends on where the jump goes. Esc and
+// Ctrl-C give up on the search instead, along with the keys held so far.
+func (s *Screen) holdKey(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyEsc, tcell.KeyCtrlC:
+		s.editor.StopSearch()
+		s.typeahead = nil
+	default:
+		s.typeahead = append(s.typeahead, ev)
+	}
+}
+
+// poll lets the editor pick up finished background work, then runs the keys held for a search jump once it's
+// made. Returns false when one of them quits.
+func (s *Screen) poll() bool {
+	s.editor.Poll()
+	for len(s.typeahead) > 0 && !s.editor.SearchPending() {
+		ev := s.typeahead[0]
+		s.typeahead = s.typeahead[1:]
+		s.recordKey(ev)
+		if !s.handleKey(ev) {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *Screen) Close() {
+	close(s.stop)
 	s.screen.Fini()
 }
 
@@ -75,34 +198,52 @@ func (s *Screen) render() {
 	s.width, s.height = s.screen.Size()
 	s.fillBg()
 
-	// Content height has to account for status bar thats why we skip the last line
-	contentHeight := s.height - statusBarHeight
-	cursorLine, cursorCol := s.editor.GetLineColumn()
+	tabs := s.editor.Tabs()
+	top := s.tablineHeight(tabs)
+	if top > 0 {
+		s.renderTabline(tabs)
+	}
 
-	if cursorLine < s.yOffset {
-		s.yOffset = cursorLine
+	// Windows share everything between the tabline and the command line, each one ends with its own status line
+	screenCol, screenRow := 0, 0
+	area := layout.Rect{Y: top, Width: s.width, Height: max(0, s.height-top-cmdlineHeight)}
+	if s.editor.FileTreeOpen() {
+		col, row := s.renderTree(area)
+		if s.editor.TreeFocused() {
+			screenCol, screenRow = col, row
+		}
+		area.X = editor.TreeWidth + 1
+		area.Width = max(0, s.width-area.X)
 	}
-	if cursorLine >= s.yOffset+contentHeight {
-		s.yOffset = cursorLine - contentHeight + 1
+	for _, view := range s.editor.Layout(area) {
+		s.editor.ViewWindow(view.ID, func() {
+			col, row := s.renderWindow(view)
+			if view.Current && !s.editor.TreeFocused() {
+				screenCol, screenRow = col, row
+			}
+		})
 	}
-
-	// Calculate gutter width based on max line number
-	gutterWidth := len(fmt.Sprintf("%d", s.yOffset+contentHeight)) + 1 // +1 for space after number
-	availableWidth := s.width - gutterWidth - gutterPadding
-
-	if cursorCol < s.xOffset {
-		s.xOffset = cursorCol
+	s.renderMessage()
+
+	switch s.editor.GetMode() {
+	case editor.ModeCommand:
+		s.renderWildmenu()
+		screenCol, screenRow = s.renderCommandLine()
+	case editor.ModeConfirm:
+		prompt, _, _, _ := s.editor.GetConfirmPrompt()
+		s.drawLine(0, s.height-cmdlineHeight, prompt, s.palette.StyleForStatusMessage())
+		screenCol, screenRow = min(len([]rune(prompt)), s.width-1), s.height-cmdlineHeight
 	}
-	if cursorCol >= s.xOffset+availableWidth {
-		s.xOffset = cursorCol - availableWidth + 1
+	if s.picker != nil {
+		screenCol, screenRow = s.renderPicker()
+	}
+	if output := s.editor.TakeOutput(); output != nil {
+		s.output = output
+	}
+	if s.output != nil {
+		s.renderOutput()
+		screenCol, screenRow = len(pressEnterPrompt), s.height-1
 	}
-
-	textStartCol := gutterWidth + gutterPadding
-	s.renderLines(gutterWidth, cursorLine, textStartCol)
-	s.renderStatusBar()
-
-	screenRow := cursorLine - s.yOffset
-	screenCol := (cursorCol - s.xOffset) + textStartCol
 
 	s.screen.ShowCursor(screenCol, screenRow)
 	if s.editor.GetMode() == editor.ModeNormal {
@@ -122,61 +263,282 @@ func (s *Screen) fillBg() {
 	}
 }
 
-func (s *Screen) renderLines(gutterWidth, cursorLine, textStartCol int) {
-	availableWidth := s.width - gutterWidth - gutterPadding
-	availableHeight := s.height - statusBarHeight
-	lines := s.editor.GetVisibleContent(s.yOffset, availableHeight)
+// pane is a window being drawn: where it is on the screen and which part of its buffer shows.
+type pane struct {
+	editor.WindowView
+	yOffset     int
+	xOffset     int
+	gutterWidth int
+	textStart   int // Screen column of the first text column
+	textWidth   int
+	textHeight  int
+}
+
+// renderWindow draws the window ViewWindow made current, scrolling it to its cursor first. Returns where
+// its cursor is on the screen.
+func (s *Screen) renderWindow(view editor.WindowView) (int, int) {
+	r := view.Rect
+	p := pane{WindowView: view, textHeight: r.Height - statusBarHeight}
+	if p.textHeight <= 0 || r.Width <= 0 {
+		return r.X, r.Y
+	}
+
+	cursorLine, cursorCol := s.editor.GetLineColumn()
+	p.yOffset, p.xOffset = s.editor.GetScroll()
+
+	if cursorLine < p.yOffset {
+		p.yOffset = cursorLine
+	}
+	if cursorLine >= p.yOffset+p.textHeight {
+		p.yOffset = cursorLine - p.textHeight + 1
+	}
+
+	// Calculate gutter width based on max line number
+	p.gutterWidth = len(fmt.Sprintf("%d", p.yOffset+p.textHeight)) + 1 // +1 for space after number
+	p.textWidth = max(1, r.Width-p.gutterWidth-gutterPadding)
+	p.textStart = r.X + p.gutterWidth + gutterPadding
+
+	if cursorCol < p.xOffset {
+		p.xOffset = cursorCol
+	}
+	if cursorCol >= p.xOffset+p.textWidth {
+		p.xOffset = cursorCol - p.textWidth + 1
+	}
+	s.editor.SetScroll(p.yOffset, p.xOffset)
+
+	s.renderLines(p, cursorLine)
+	s.renderStatusBar(p)
+	if r.X+r.Width < s.width {
+		s.renderSeparator(r)
+	}
+
+	return (cursorCol - p.xOffset) + p.textStart, r.Y + cursorLine - p.yOffset
+}
+
+// testSigns are drawn in the gutter next to test functions ":gotest" ran.
+var testSigns = map[gotest.Status]rune{
+	gotest.Running: '●',
+	gotest.Passed:  '✓',
+	gotest.Failed:  '✗',
+	gotest.Skipped: '-',
+}
+
+func (s *Screen) renderLines(p pane, cursorLine int) {
+	lines := s.editor.GetVisibleContent(p.yOffset, p.textHeight)
+	signs := s.editor.TestSigns(p.yOffset, p.textHeight)
 
 	for row, lineContent := range lines {
-		lineNum := s.yOffset + row + 1
+		lineNum := p.yOffset + row + 1
+		y := p.Rect.Y + row
 
 		// Draw line number
-		gutterText := fmt.Sprintf("%*d ", gutterWidth-1, lineNum)
-		for col, ch := range gutterText {
-			s.screen.SetContent(col, row, ch, nil, s.palette.StyleForLineNum())
+		gutterText := fmt.Sprintf("%*d ", p.gutterWidth-1, lineNum)
+		s.drawSpan(p.Rect.X, y, p.Rect.Width, gutterText, s.palette.StyleForLineNum())
+
+		// Draw the vertical separator, where a test's result goes
+		if p.gutterWidth < p.Rect.Width {
+			if status, ok := signs[p.yOffset+row]; ok {
+				s.screen.SetContent(p.Rect.X+p.gutterWidth, y, testSigns[status], nil, s.palette.StyleForTestSign(status))
+			} else {
+				s.screen.SetContent(p.Rect.X+p.gutterWidth, y, ' ', nil, s.palette.StyleForGutter())
+			}
 		}
 
-		// Draw empty vertical separator
-		s.screen.SetContent(gutterWidth, row, ' ', nil, s.palette.StyleForGutter())
-
 		// Draw text content
 		style := s.palette.StyleForNormalText()
-		if s.yOffset+row == cursorLine {
+		if p.yOffset+row == cursorLine {
 			style = s.palette.StyleForCurrentLine()
 		}
 
-		visibleContent := s.getVisibleSlice(lineContent, s.xOffset, availableWidth)
+		lineContent = strings.TrimSuffix(lineContent, "\n")
+		visibleContent := s.getVisibleSlice(lineContent, p.xOffset, p.textWidth)
+
+		s.drawSpan(p.textStart, y, p.Rect.X+p.Rect.Width-p.textStart, visibleContent, style)
 
-		s.drawLine(textStartCol, row, visibleContent, style)
+		runes := []rune(lineContent)
+		for _, t := range s.editor.LineTokens(p.yOffset + row) {
+			s.drawHighlight(p, y, runes, highlight{start: t.Start, end: t.End, style: s.palette.StyleForToken(style, t.Type)})
+		}
+		for _, h := range s.lineHighlights(p.yOffset+row, len(runes), p.Current) {
+			s.drawHighlight(p, y, runes, h)
+		}
+	}
+}
+
+// postSyntax hands lines highlighted in the background to the event loop. The update is refused when the queue
+// is full, the highlighter offers it again.
+func (s *Screen) postSyntax(u highlighter.Update) bool {
+	return s.screen.PostEvent(tcell.NewEventInterrupt(u)) == nil
+}
+
+// renderSeparator draws the column between a window and the one on its right.
+func (s *Screen) renderSeparator(r layout.Rect) {
+	for y := r.Y; y < r.Y+r.Height; y++ {
+		s.screen.SetContent(r.X+r.Width, y, '│', nil, s.palette.StyleForSeparator())
 	}
 }
 
 func (s *Screen) getVisibleSlice(line string, offset, width int) string {
 	runes := []rune(line)
+	start := min(offset, len(runes))
 	end := min(offset+width, len(runes))
 
-	return string(runes[offset:end])
+	return string(runes[start:end])
+}
+
+// highlight restyles chars start..end (rune columns, end exclusive) of a line, e.g. a search match.
+type highlight struct {
+	start int
+	end   int
+	style tcell.Style
+}
+
+// lineHighlights collects what has to be drawn over a line's text. The search preview and the ":s///c" match
+// only show in the current window.
+func (s *Screen) lineHighlights(line, lineLen int, current bool) []highlight {
+	var highlights []highlight
+
+	for _, m := range s.editor.MatchesInLines(line, line) {
+		if h, ok := s.spanOnLine(line, lineLen, m.Start, m.End, s.palette.StyleForSearchMatch()); ok {
+			highlights = append(highlights, h)
+		}
+	}
+	if !current {
+		return highlights
+	}
+	if m, ok := s.editor.GetSearchPreview(); ok {
+		if h, ok := s.spanOnLine(line, lineLen, m.Start, m.End, s.palette.StyleForCurrentMatch()); ok {
+			highlights = append(highlights, h)
+		}
+	}
+	if _, start, end, ok := s.editor.GetConfirmPrompt(); ok {
+		if h, ok := s.spanOnLine(line, lineLen, start, end, s.palette.StyleForConfirmMatch()); ok {
+			highlights = append(highlights, h)
+		}
+	}
+	return highlights
+}
+
+// spanOnLine clips the char range start..end to one line. Empty ranges still cover one cell so they're visible.
+func (s *Screen) spanOnLine(line, lineLen, start, end int, style tcell.Style) (highlight, bool) {
+	startLine, startCol := s.editor.LineColumnAt(start)
+	endLine, endCol := s.editor.LineColumnAt(end)
+	if line < startLine || line > endLine {
+		return highlight{}, false
+	}
+
+	h := highlight{start: 0, end: lineLen + 1, style: style}
+	if line == startLine {
+		h.start = startCol
+	}
+	if line == endLine {
+		h.end = endCol
+	}
+	if h.end <= h.start {
+		h.end = h.start + 1
+	}
+	return h, true
 }
 
-func (ui *Screen) renderStatusBar() {
+func (s *Screen) drawHighlight(p pane, y int, runes []rune, h highlight) {
+	for col := max(h.start, p.xOffset); col < min(h.end, p.xOffset+p.textWidth); col++ {
+		ch := ' '
+		if col < len(runes) {
+			ch = runes[col]
+		}
+		x := p.textStart + col - p.xOffset
+		if x >= p.Rect.X+p.Rect.Width {
+			break
+		}
+		s.screen.SetContent(x, y, ch, nil, h.style)
+	}
+}
+
+// renderStatusBar draws the window's last row. Only the current window shows the mode.
+func (ui *Screen) renderStatusBar(p pane) {
 	mode := ui.editor.GetMode()
+	y := p.Rect.Y + p.Rect.Height - statusBarHeight
+	if !p.Current {
+		statusLine := fmt.Sprintf(" %s", ui.editor.GetStatusLine())
+		ui.drawSpan(p.Rect.X, y, p.Rect.Width, statusLine, ui.palette.StyleForInactiveStatusBar())
+		return
+	}
+
 	modeStr := "NORMAL"
-	if mode == editor.ModeInsert {
+	switch mode {
+	case editor.ModeInsert:
 		modeStr = "INSERT"
+	case editor.ModeCommand:
+		modeStr = "COMMAND"
+	}
+
+	if ui.macro.recording != 0 {
+		modeStr += fmt.Sprintf(" recording @%c", ui.macro.recording)
 	}
 
 	statusLine := fmt.Sprintf(" %s | %s", modeStr, ui.editor.GetStatusLine())
-	ui.drawLine(0, ui.height-statusBarHeight, statusLine, ui.palette.StyleForStatusBar(mode))
+	if index, total, ok := ui.editor.SearchCount(); ok {
+		statusLine += fmt.Sprintf(" | [%d/%d]", index, total)
+	}
+	if running := ui.editor.Running(); running != "" {
+		statusLine += fmt.Sprintf(" | running: %s", running)
+	}
+	ui.drawSpan(p.Rect.X, y, p.Rect.Width, statusLine, ui.palette.StyleForStatusBar(mode))
+}
 
-	if msg := ui.editor.GetMessage(); msg != "" {
-		ui.drawLine(0, ui.height-statusBarHeight, msg, ui.palette.StyleForStatusMessage())
+// renderMessage draws the last message on the bottom row, where the command line goes while typing.
+func (s *Screen) renderMessage() {
+	msg := s.editor.GetMessage()
+	if msg == "" {
+		return
+	}
+	style := s.palette.StyleForStatusMessage()
+	if isErrorMessage(msg) {
+		style = s.palette.StyleForErrorMessage()
 	}
+	s.drawLine(0, s.height-cmdlineHeight, msg, style)
+}
+
+// renderCommandLine draws the ":" line on the bottom row and returns where the cursor goes.
+func (s *Screen) renderCommandLine() (int, int) {
+	prompt, text, cursor := s.editor.GetCommandLine()
+	row := s.height - cmdlineHeight
+
+	// Scroll the line when it's wider than the screen so the cursor stays visible
+	runes := []rune(text)
+	promptWidth := len([]rune(prompt))
+	offset := max(0, cursor+promptWidth+1-s.width)
+	visible := string(runes[min(offset, len(runes)):])
+
+	s.drawLine(0, row, prompt+visible, s.palette.StyleForCommandLine())
+	return cursor - offset + promptWidth, row
+}
+
+const pressEnterPrompt = "Press ENTER or type command to continue"
+
+// renderOutput draws multi line command output above the bottom row, like Vim's message area.
+func (s *Screen) renderOutput() {
+	lines := s.output
+	if len(lines) > s.height-1 {
+		lines = lines[len(lines)-(s.height-1):]
+	}
+	top := s.height - 1 - len(lines)
+	for i, line := range lines {
+		s.drawLine(0, top+i, line, s.palette.StyleForCommandLine())
+	}
+	s.drawLine(0, s.height-1, pressEnterPrompt, s.palette.StyleForStatusMessage())
 }
 
 func (s *Screen) drawLine(x, y int, text string, style tcell.Style) {
+	s.drawSpan(x, y, s.width-x, text, style)
+}
+
+// drawSpan draws text in the width cells starting at x, filling what the text doesn't cover.
+func (s *Screen) drawSpan(x, y, width int, text string, style tcell.Style) {
+	end := min(x+width, s.width)
 	col := x
 	for _, ch := range text {
-		if col >= s.width {
+		if col >= end {
 			break
 		}
 		s.screen.SetContent(col, y, ch, nil, style)
@@ -184,8 +546,25 @@ func (s *Screen) drawLine(x, y int, text string, style tcell.Style) {
 	}
 
 	// Fill rest of line with spaces (for background color)
-	for col < s.width {
+	for col < end {
 		s.screen.SetContent(col, y, ' ', nil, style)
 		col++
 	}
 }
+
+// isErrorMessage spots Vim style error messages like "E37: No write since last change".
+func isErrorMessage(msg string) bool {
+	if len(msg) < 3 || msg[0] != 'E' {
+		return false
+	}
+	code, _, ok := strings.Cut(msg[1:], ":")
+	if !ok || code == "" {
+		return false
+	}
+	for _, ch := range code {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
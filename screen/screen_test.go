@@ -0,0 +1,90 @@
+package screen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ogzhanolguncu/go_editor/editor"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestScreen returns a screen on a simulated terminal, editing a file holding text.
+func newTestScreen(t *testing.T, text string) *Screen {
+	t.Helper()
+	sim := tcell.NewSimulationScreen("")
+	require.NoError(t, sim.Init())
+	t.Cleanup(sim.Fini)
+
+	path := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(path, []byte(text), 0o644))
+	e, err := editor.New()
+	require.NoError(t, err)
+	require.NoError(t, e.OpenFile(path))
+
+	width, height := sim.Size()
+	s := &Screen{
+		screen:  sim,
+		editor:  e,
+		palette: NewPalette(),
+		width:   width,
+		height:  height,
+		stop:    make(chan struct{}),
+	}
+	e.SetKeyExecutor(s.executeKeys)
+	s.registerPickCommand()
+	return s
+}
+
+// feed types keys in the notation macros use, the way Run hands them over.
+func (s *Screen) feed(keys string) {
+	for _, ev := range decodeKeys(keys) {
+		if !s.typeKey(ev) {
+			return
+		}
+	}
+}
+
+func TestCollectPaste(t *testing.T) {
+	// Raw bytes as the terminal sends them, tcell turns tabs and line breaks into keys of their own
+	var s Screen
+	for _, ch := range "if x {\r\n\treturn\n}\r" {
+		s.collectPaste(tcell.NewEventKey(tcell.KeyRune, ch, tcell.ModNone))
+	}
+	require.Equal(t, "if x {\r\n\treturn\n}\r", s.pasted.String())
+}
+
+func TestTypeaheadWaitsForSearch(t *testing.T) {
+	tests := []struct {
+		name string
+		keys string
+		want string
+	}{
+		{name: "keys run after the jump", keys: "/foo<CR>xx", want: "a o\nfoo"},
+		{name: "n waits too", keys: "/foo<CR>nx", want: "a foo\noo"},
+		{name: "escape drops the search and the keys", keys: "/foo<CR>x<Esc>x", want: " foo\nfoo"},
+		// Replayed keys wait on the spot
+		{name: "macro", keys: "@a", want: "a oo\nfoo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestScreen(t, "a foo\nfoo")
+			redraws := make(chan struct{}, 10)
+			s.editor.SetRedraw(func() { redraws <- struct{}{} })
+			require.NoError(t, s.editor.SetRegister('a', "/foo\rx"))
+			s.feed(tt.keys)
+			for s.editor.SearchPending() {
+				select {
+				case <-redraws:
+				case <-time.After(5 * time.Second):
+					t.Fatal("no redraw")
+				}
+				require.True(t, s.poll())
+			}
+			require.Equal(t, tt.want, s.editor.GetContent())
+		})
+	}
+}
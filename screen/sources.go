@@ -0,0 +1,322 @@
+package screen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ogzhanolguncu/go_editor/editor"
+	"github.com/ogzhanolguncu/go_editor/finder"
+	"github.com/ogzhanolguncu/go_editor/register"
+)
+
+// pickerSources are the pickers ":pick {name}" opens, in completion order.
+var pickerSources = []struct {
+	name string
+	open func(s *Screen) Source
+}{
+	{"files", newFilesSource},
+	{"buffers", newBuffersSource},
+	{"lines", newLinesSource},
+	{"commands", newCommandsSource},
+	{"marks", newMarksSource},
+	{"registers", newRegistersSource},
+	{"jumps", newJumpsSource},
+	{"recent", newRecentSource},
+	{"themes", newThemesSource},
+}
+
+// registerPickCommand adds ":pick {name}", which opens one of the pickers.
+func (s *Screen) registerPickCommand() {
+	s.editor.RegisterCommand(editor.ExCommand{
+		Name:   "pick",
+		MinLen: 4,
+		Run: func(e *editor.Editor, args editor.ExArgs) error {
+			name := strings.TrimSpace(args.Args)
+			if name == "" {
+				return errors.New("E471: Argument required")
+			}
+			for _, src := range pickerSources {
+				if src.name == name {
+					s.openPicker(src.open(s))
+					return nil
+				}
+			}
+			return fmt.Errorf("E475: Invalid argument: %s", name)
+		},
+		Complete: func(e *editor.Editor, arg string) []string {
+			names := make([]string, len(pickerSources))
+			for i, src := range pickerSources {
+				names[i] = src.name
+			}
+			return names
+		},
+	})
+}
+
+// filesSource lists the project's files while they're found, Ctrl-P.
+type filesSource struct {
+	editor *editor.Editor
+	files  *finder.Collector
+}
+
+func newFilesSource(s *Screen) Source {
+	return &filesSource{editor: s.editor, files: finder.Start(".", s.postRedraw)}
+}
+
+func (f *filesSource) Title() string { return "Files" }
+
+func (f *filesSource) Items() ([]string, bool) {
+	files, done := f.files.Files()
+	return files, !done
+}
+
+func (f *filesSource) Preview(i, height int) (editor.Preview, bool) {
+	files, _ := f.files.Files()
+	return f.editor.FilePreview(files[i], 0, height), true
+}
+
+func (f *filesSource) Accept(i int, target editor.OpenTarget) error {
+	files, _ := f.files.Files()
+	return f.editor.OpenFileIn(files[i], target)
+}
+
+func (f *filesSource) Close() {
+	f.files.Stop()
+}
+
+// buffersSource lists the open buffers.
+type buffersSource struct {
+	editor  *editor.Editor
+	buffers []editor.BufferInfo
+	names   []string
+}
+
+func newBuffersSource(s *Screen) Source {
+	b := &buffersSource{editor: s.editor, buffers: s.editor.Buffers()}
+	for _, info := range b.buffers {
+		b.names = append(b.names, info.Name)
+	}
+	return b
+}
+
+func (b *buffersSource) Title() string { return "Buffers" }
+
+func (b *buffersSource) Items() ([]string, bool) { return b.names, false }
+
+// Label is the buffer number with ":ls" flags, % for the current buffer and + for unsaved changes.
+func (b *buffersSource) Label(i int) string {
+	info := b.buffers[i]
+	flag, mod := ' ', ' '
+	if info.Current {
+		flag = '%'
+	}
+	if info.Modified {
+		mod = '+'
+	}
+	return fmt.Sprintf("%3d %c%c", info.ID, flag, mod)
+}
+
+func (b *buffersSource) Preview(i, height int) (editor.Preview, bool) {
+	return b.editor.BufferPreview(b.buffers[i].ID, b.buffers[i].Line, height), true
+}
+
+func (b *buffersSource) Accept(i int, target editor.OpenTarget) error {
+	return b.editor.ShowBufferIn(b.buffers[i].ID, target)
+}
+
+// linesSource lists the current buffer's lines.
+type linesSource struct {
+	editor   *editor.Editor
+	bufferID int
+	lines    []string
+}
+
+func newLinesSource(s *Screen) Source {
+	l := &linesSource{editor: s.editor, bufferID: s.editor.ID()}
+	for i := range s.editor.GetLineCount() {
+		l.lines = append(l.lines, strings.TrimRight(s.editor.GetLine(i), "\n"))
+	}
+	return l
+}
+
+func (l *linesSource) Title() string { return "Lines" }
+
+func (l *linesSource) Items() ([]string, bool) { return l.lines, false }
+
+func (l *linesSource) Label(i int) string {
+	return fmt.Sprintf("%*d", len(fmt.Sprint(len(l.lines))), i+1)
+}
+
+func (l *linesSource) Preview(i, height int) (editor.Preview, bool) {
+	return l.editor.BufferPreview(l.bufferID, i, height), true
+}
+
+func (l *linesSource) Accept(i int, _ editor.OpenTarget) error {
+	l.editor.GoToLine(i)
+	return nil
+}
+
+// commandsSource lists the ":" commands. Picking one starts a command line with it typed.
+type commandsSource struct {
+	editor *editor.Editor
+	names  []string
+}
+
+func newCommandsSource(s *Screen) Source {
+	return &commandsSource{editor: s.editor, names: s.editor.CommandNames()}
+}
+
+func (c *commandsSource) Title() string { return "Commands" }
+
+func (c *commandsSource) Items() ([]string, bool) { return c.names, false }
+
+func (c *commandsSource) Preview(int, int) (editor.Preview, bool) { return editor.Preview{}, false }
+
+func (c *commandsSource) Accept(i int, _ editor.OpenTarget) error {
+	c.editor.StartCommandLine(':')
+	for _, r := range c.names[i] + " " {
+		c.editor.CmdInsert(r)
+	}
+	return nil
+}
+
+// marksSource lists the current buffer's marks.
+type marksSource struct {
+	editor   *editor.Editor
+	bufferID int
+	marks    []editor.MarkInfo
+	items    []string
+}
+
+func newMarksSource(s *Screen) Source {
+	m := &marksSource{editor: s.editor, bufferID: s.editor.ID(), marks: s.editor.Marks()}
+	for _, mark := range m.marks {
+		m.items = append(m.items, fmt.Sprintf("%c %s", mark.Name, strings.TrimSpace(mark.Text)))
+	}
+	return m
+}
+
+func (m *marksSource) Title() string { return "Marks" }
+
+func (m *marksSource) Items() ([]string, bool) { return m.items, false }
+
+func (m *marksSource) Preview(i, height int) (editor.Preview, bool) {
+	return m.editor.BufferPreview(m.bufferID, m.marks[i].Line, height), true
+}
+
+func (m *marksSource) Accept(i int, _ editor.OpenTarget) error {
+	m.editor.JumpToMark(m.marks[i].Name, true)
+	return nil
+}
+
+// registersSource lists the registers holding something. Picking one puts it after the cursor.
+type registersSource struct {
+	editor    *editor.Editor
+	registers []register.Entry
+	items     []string
+}
+
+func newRegistersSource(s *Screen) Source {
+	r := &registersSource{editor: s.editor, registers: s.editor.Registers()}
+	replacer := strings.NewReplacer("\n", "^J", "\t", "^I")
+	for _, entry := range r.registers {
+		r.items = append(r.items, fmt.Sprintf("\"%c %s", entry.Name, replacer.Replace(entry.Text)))
+	}
+	return r
+}
+
+func (r *registersSource) Title() string { return "Registers" }
+
+func (r *registersSource) Items() ([]string, bool) { return r.items, false }
+
+// Preview shows the register's text as it would be put.
+func (r *registersSource) Preview(i, height int) (editor.Preview, bool) {
+	lines := strings.Split(strings.TrimSuffix(r.registers[i].Text, "\n"), "\n")
+	return editor.Preview{Lines: lines[:min(len(lines), height)], Focus: -1}, true
+}
+
+func (r *registersSource) Accept(i int, _ editor.OpenTarget) error {
+	if r.editor.SelectRegister(r.registers[i].Name) {
+		r.editor.Paste(true)
+	}
+	return nil
+}
+
+// jumpsSource lists the jump list, newest first.
+type jumpsSource struct {
+	editor *editor.Editor
+	jumps  []editor.JumpInfo
+	items  []string
+}
+
+func newJumpsSource(s *Screen) Source {
+	j := &jumpsSource{editor: s.editor, jumps: s.editor.Jumps()}
+	for _, jump := range j.jumps {
+		j.items = append(j.items, fmt.Sprintf("%s:%d: %s", jump.Name, jump.Line+1, jump.Text))
+	}
+	return j
+}
+
+func (j *jumpsSource) Title() string { return "Jumps" }
+
+func (j *jumpsSource) Items() ([]string, bool) { return j.items, false }
+
+func (j *jumpsSource) Preview(i, height int) (editor.Preview, bool) {
+	return j.editor.BufferPreview(j.jumps[i].BufferID, j.jumps[i].Line, height), true
+}
+
+func (j *jumpsSource) Accept(i int, _ editor.OpenTarget) error {
+	return j.editor.GoToJump(j.jumps[i])
+}
+
+// recentSource lists files edited in this and earlier sessions.
+type recentSource struct {
+	editor *editor.Editor
+	files  []string
+}
+
+func newRecentSource(s *Screen) Source {
+	return &recentSource{editor: s.editor, files: s.editor.RecentFiles()}
+}
+
+func (r *recentSource) Title() string { return "Recent files" }
+
+func (r *recentSource) Items() ([]string, bool) { return r.files, false }
+
+func (r *recentSource) Preview(i, height int) (editor.Preview, bool) {
+	return r.editor.FilePreview(r.files[i], 0, height), true
+}
+
+func (r *recentSource) Accept(i int, target editor.OpenTarget) error {
+	return r.editor.OpenFileIn(r.files[i], target)
+}
+
+// themesSource switches the color theme.
+type themesSource struct {
+	screen *Screen
+	names  []string
+}
+
+func newThemesSource(s *Screen) Source {
+	return &themesSource{screen: s, names: themeNames()}
+}
+
+func (t *themesSource) Title() string { return "Themes" }
+
+func (t *themesSource) Items() ([]string, bool) { return t.names, false }
+
+// Label marks the theme in use.
+func (t *themesSource) Label(i int) string {
+	if t.names[i] == t.screen.palette.Name() {
+		return "*"
+	}
+	return " "
+}
+
+func (t *themesSource) Preview(int, int) (editor.Preview, bool) { return editor.Preview{}, false }
+
+func (t *themesSource) Accept(i int, _ editor.OpenTarget) error {
+	t.screen.palette = newPalette(themes[i])
+	return nil
+}
@@ -0,0 +1,67 @@
+package screen
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/ogzhanolguncu/go_editor/editor"
+)
+
+// tabLabel is where a tab's label was drawn on the tabline, so a click can find its tab.
+type tabLabel struct {
+	start int
+	end   int
+	tab   int // Counted from 1
+}
+
+// tablineHeight is 1 while there's more than one tab page, Vim hides the tabline for a single one.
+func (s *Screen) tablineHeight(tabs []editor.TabInfo) int {
+	if len(tabs) > 1 {
+		return 1
+	}
+	return 0
+}
+
+// renderTabline draws a label per tab on the top row, " + name " where "+" marks a modified buffer.
+func (s *Screen) renderTabline(tabs []editor.TabInfo) {
+	s.tabLabels = s.tabLabels[:0]
+	s.drawLine(0, 0, "", s.palette.StyleForTabline())
+
+	col := 0
+	for i, tab := range tabs {
+		label := " " + tab.Name + " "
+		if tab.Modified {
+			label = " +" + label
+		}
+		style := s.palette.StyleForTabline()
+		if tab.Current {
+			style = s.palette.StyleForTablineSelected()
+		}
+		s.drawText(col, 0, label, style)
+
+		end := col + len([]rune(label))
+		s.tabLabels = append(s.tabLabels, tabLabel{start: col, end: end, tab: i + 1})
+		col = end + 1
+		if col >= s.width {
+			break
+		}
+	}
+}
+
+// handleMouse switches tabs when a label on the tabline is clicked.
+func (s *Screen) handleMouse(ev *tcell.EventMouse) {
+	if ev.Buttons()&tcell.Button1 == 0 {
+		return
+	}
+	if mode := s.editor.GetMode(); mode != editor.ModeNormal && mode != editor.ModeInsert {
+		return
+	}
+	x, y := ev.Position()
+	if y != 0 {
+		return
+	}
+	for _, label := range s.tabLabels {
+		if x >= label.start && x < label.end {
+			s.editor.GoToTab(label.tab)
+			return
+		}
+	}
+}
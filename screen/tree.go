@@ -0,0 +1,129 @@
+package screen
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ogzhanolguncu/go_editor/editor"
+	"github.com/ogzhanolguncu/go_editor/layout"
+)
+
+// renderTree draws the file tree in the left editor.TreeWidth columns of area, with its own status line and a
+// separator on the right. Returns where the cursor goes while the tree has focus.
+func (s *Screen) renderTree(area layout.Rect) (int, int) {
+	width := min(editor.TreeWidth, area.Width)
+	height := area.Height - statusBarHeight
+	focused := s.editor.TreeFocused()
+
+	for i, row := range s.editor.TreeView(height) {
+		icon := "  "
+		if row.IsDir {
+			icon = "▸ "
+			if row.Expanded {
+				icon = "▾ "
+			}
+		}
+		text := " " + strings.Repeat("  ", row.Depth) + icon + row.Name
+
+		style := s.palette.StyleForNormalText()
+		if row.Selected && focused {
+			style = s.palette.StyleForCurrentLine()
+		}
+		if row.Current {
+			style = style.Foreground(s.palette.ColorForTreeCurrent())
+		}
+		s.drawSpan(area.X, area.Y+i, width, text, style)
+	}
+
+	statusStyle := s.palette.StyleForInactiveStatusBar()
+	if focused {
+		statusStyle = s.palette.StyleForStatusBar(editor.ModeNormal)
+	}
+	s.drawSpan(area.X, area.Y+area.Height-statusBarHeight, width, " "+s.editor.TreeRoot()+"/", statusStyle)
+
+	if area.X+width < s.width {
+		s.renderSeparator(layout.Rect{X: area.X, Y: area.Y, Width: width, Height: area.Height})
+	}
+	return area.X + 1, area.Y + s.editor.TreeSelectedRow()
+}
+
+// handleTree takes normal mode keys while the file tree has focus. Keys it doesn't know are dropped so they
+// can't edit a buffer that isn't focused.
+func (s *Screen) handleTree(ev *tcell.EventKey) bool {
+	e := s.editor
+	if e.GetPending() == windowPending {
+		return s.handlePending(windowPending, ev)
+	}
+
+	switch ev.Key() {
+	case tcell.KeyCtrlC:
+		return false
+	case tcell.KeyEsc:
+		e.CancelPending()
+	case tcell.KeyCtrlW:
+		e.SetPending(windowPending)
+	case tcell.KeyCtrlN:
+		s.toggleTree()
+	case tcell.KeyCtrlP:
+		s.openPicker(newFilesSource(s))
+	case tcell.KeyEnter:
+		e.TreeOpen()
+	case tcell.KeyDown:
+		e.TreeMove(true)
+	case tcell.KeyUp:
+		e.TreeMove(false)
+	case tcell.KeyRune:
+		r := ev.Rune()
+		if e.HandleDigit(r) {
+			return true
+		}
+		switch r {
+		case 'j':
+			e.TreeMove(true)
+		case 'k':
+			e.TreeMove(false)
+		case 'o':
+			e.TreeOpen()
+		case 'a':
+			e.TreeCreate()
+		case 'r':
+			e.TreeRename()
+		case 'd':
+			e.TreeDelete()
+		case 'm':
+			e.TreeMoveNode()
+		case 'q':
+			s.toggleTree()
+		case ':':
+			e.StartCommandLine(':')
+		default:
+			e.CancelPending()
+		}
+	}
+	return true
+}
+
+func (s *Screen) toggleTree() {
+	if err := s.editor.ToggleFileTree(); err != nil {
+		s.editor.SetMessage(err.Error())
+	}
+}
+
+// fileTick wakes the event loop so the file tree looks for files changed outside the editor.
+type fileTick struct{}
+
+const fileTickInterval = time.Second
+
+func (s *Screen) watchFiles() {
+	ticker := time.NewTicker(fileTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			_ = s.screen.PostEvent(tcell.NewEventInterrupt(fileTick{}))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package screen
+
+import "github.com/gdamore/tcell/v2"
+
+// renderWildmenu draws the Tab completion candidates over the status line above the command line, like Vim's wildmenu.
+// When they don't fit, the row scrolls to keep the selected one visible and "<" or ">" marks what's cut off.
+func (s *Screen) renderWildmenu() {
+	items, selected, ok := s.editor.GetCompletion()
+	if !ok || len(items) == 0 {
+		return
+	}
+	row := s.height - cmdlineHeight - 1
+	if row < 0 {
+		return
+	}
+
+	// Find the first item to show: move the window right until the selected item fits
+	first := 0
+	for selected >= 0 && first < selected && !s.wildmenuFits(items, first, selected) {
+		first++
+	}
+
+	style := s.palette.StyleForWildmenu()
+	s.drawLine(0, row, "", style)
+
+	col := 0
+	if first > 0 {
+		s.drawLine(0, row, "< ", style)
+		col = 2
+	}
+	for i := first; i < len(items); i++ {
+		width := len([]rune(items[i]))
+		if col+width > s.width-2 && i != first {
+			s.drawText(s.width-1, row, ">", style)
+			break
+		}
+		itemStyle := style
+		if i == selected {
+			itemStyle = s.palette.StyleForWildmenuSelected()
+		}
+		s.drawText(col, row, items[i], itemStyle)
+		col += width + 2
+	}
+}
+
+// wildmenuFits reports whether items first..last fit on one row after a "< " marker.
+func (s *Screen) wildmenuFits(items []string, first, last int) bool {
+	col := 2
+	for i := first; i <= last; i++ {
+		col += len([]rune(items[i])) + 2
+	}
+	return col <= s.width-2
+}
+
+// drawText draws text without filling the rest of the row.
+func (s *Screen) drawText(x, y int, text string, style tcell.Style) {
+	col := x
+	for _, ch := range text {
+		if col >= s.width {
+			break
+		}
+		s.screen.SetContent(col, y, ch, nil, style)
+		col++
+	}
+}
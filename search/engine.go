@@ -0,0 +1,334 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Source is text addressed by rune position, e.g. a gap buffer. Matching reads it rune by rune,
+// so the text is never copied into one big string.
+type Source interface {
+	Length() int
+	CharAt(pos int) rune
+}
+
+// LiteralFinder is a Source with its own fast plain text search. Literal patterns use it instead of the regexp.
+type LiteralFinder interface {
+	FindIn(needle string, start, end int) []int
+}
+
+// Range is a match in rune positions, End is exclusive.
+type Range struct {
+	Start int
+	End   int
+}
+
+// Options controls how a pattern is read.
+type Options struct {
+	Literal    bool // Pattern is plain text, nothing in it is special
+	IgnoreCase bool
+	SmartCase  bool // With IgnoreCase, an uppercase letter in the pattern makes it match case
+}
+
+// ErrCancelled is returned when the context ends before the search does.
+var ErrCancelled = errors.New("search cancelled")
+
+// checkEvery is how many runes the reader passes between context checks.
+const checkEvery = 4096
+
+// Pattern is a compiled search pattern that finds matches in a Source.
+type Pattern struct {
+	re        *regexp.Regexp // Used when the search starts at the beginning of the text
+	inContext *regexp.Regexp // Same pattern after one rune of context, so "^" and "\<" see what comes before
+	wordStart bool           // Pattern started with "\<"
+	wordEnd   bool           // Pattern ended with "\>"
+	words     []wordGroup    // "\<" and "\>" inside the pattern, checked on the text once the regexp matched
+	literal   string         // Set when the pattern is plain text matched case sensitively
+	multiline bool           // Pattern can match a line break
+}
+
+// wordGroup is the regexp group a "\<" (start) or "\>" inside a pattern turned into.
+type wordGroup struct {
+	index int
+	start bool
+}
+
+// NewPattern compiles a Vim pattern (or plain text with Literal) for searching.
+func NewPattern(pattern string, opts Options) (*Pattern, error) {
+	p := &Pattern{}
+	ignoreCase := IgnoreCase(pattern, opts)
+
+	var translated string
+	if opts.Literal {
+		translated = regexp.QuoteMeta(pattern)
+		p.multiline = strings.Contains(pattern, "\n")
+		if !ignoreCase {
+			p.literal = pattern
+		}
+	} else {
+		// Word boundaries are checked on runes, Go's \b only knows ASCII words. The ones at the ends are cut off so
+		// "*" on a plain word still takes the literal path
+		if rest, ok := strings.CutPrefix(pattern, `\<`); ok {
+			p.wordStart, pattern = true, rest
+		}
+		if rest, ok := strings.CutSuffix(pattern, `\>`); ok && !strings.HasSuffix(rest, `\`) {
+			p.wordEnd, pattern = true, rest
+		}
+
+		var flags caseFlags
+		var err error
+		translated, flags, err = TranslateVim(pattern)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case flags.forceIgnoreCase:
+			ignoreCase = true
+		case flags.forceMatchCase:
+			ignoreCase = false
+		}
+		p.multiline = strings.Contains(pattern, `\n`) || strings.Contains(pattern, `\_`)
+		if !ignoreCase && pattern != "" && !strings.ContainsAny(pattern, `\^$.*[~`) {
+			p.literal = pattern
+		}
+	}
+
+	prefix := "(?m)"
+	if ignoreCase {
+		prefix = "(?mi)"
+	}
+	re, err := regexp.Compile(prefix + "(" + translated + ")")
+	if err != nil {
+		return nil, errInvalid(pattern)
+	}
+	inContext, err := regexp.Compile(prefix + "(?s:.)(" + translated + ")")
+	if err != nil {
+		return nil, errInvalid(pattern)
+	}
+	p.re, p.inContext = re, inContext
+	// Both regexps number their groups the same, the context rune isn't in one
+	for i, name := range re.SubexpNames() {
+		switch name {
+		case wordStartGroup:
+			p.words = append(p.words, wordGroup{index: i, start: true})
+		case wordEndGroup:
+			p.words = append(p.words, wordGroup{index: i})
+		}
+	}
+	return p, nil
+}
+
+// IgnoreCase resolves ignorecase and smartcase for one pattern. "\c" and "\C" in the pattern still win over it.
+func IgnoreCase(pattern string, opts Options) bool {
+	return opts.IgnoreCase && !(opts.SmartCase && hasUpper(pattern, opts.Literal))
+}
+
+// hasUpper reports whether a pattern has an uppercase letter of its own, escapes like "\S" don't count.
+func hasUpper(pattern string, literal bool) bool {
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && !literal:
+			escaped = true
+		case unicode.IsUpper(r):
+			return true
+		}
+	}
+	return false
+}
+
+// Multiline reports whether matches can span lines. Only single line patterns can be cached per line.
+func (p *Pattern) Multiline() bool {
+	return p.multiline
+}
+
+// Next finds the first match starting at from or later.
+func (p *Pattern) Next(ctx context.Context, src Source, from int) (Range, bool, error) {
+	length := src.Length()
+	for from <= length {
+		m, ok, err := p.find(ctx, src, from)
+		if err != nil || !ok {
+			return Range{}, false, err
+		}
+		if p.isWord(src, m) {
+			return m, true, nil
+		}
+		from = m.Start + 1
+	}
+	return Range{}, false, nil
+}
+
+// All returns the matches starting in from..to, to exclusive unless it's the end of the text.
+// Empty matches count, but only once at each position.
+func (p *Pattern) All(ctx context.Context, src Source, from, to int) ([]Range, error) {
+	if finder, ok := src.(LiteralFinder); ok && p.literal != "" {
+		return p.allLiteral(ctx, finder, src, from, to)
+	}
+
+	var matches []Range
+	for from <= to {
+		m, ok, err := p.Next(ctx, src, from)
+		if err != nil {
+			return matches, err
+		}
+		if !ok || m.Start > to || (m.Start == to && to < src.Length()) {
+			break
+		}
+		matches = append(matches, m)
+		from = m.End
+		if m.End == m.Start {
+			from++
+		}
+	}
+	return matches, nil
+}
+
+// allLiteral is All for plain text patterns, the source finds the candidates without going through the regexp.
+func (p *Pattern) allLiteral(ctx context.Context, finder LiteralFinder, src Source, from, to int) ([]Range, error) {
+	if ctx.Err() != nil {
+		return nil, ErrCancelled
+	}
+
+	n := utf8.RuneCountInString(p.literal)
+	var matches []Range
+	next := from
+	for _, pos := range finder.FindIn(p.literal, from, min(src.Length(), to+n-1)) {
+		// The finder reports overlapping matches, a search steps over each one it takes
+		if pos < next {
+			continue
+		}
+		m := Range{Start: pos, End: pos + n}
+		if p.isWord(src, m) {
+			matches = append(matches, m)
+			next = m.End
+		}
+	}
+	return matches, nil
+}
+
+// find runs the regexp from one rune before from, so the match knows what precedes it. Matches whose "\<" or
+// "\>" inside the pattern aren't on a word boundary are skipped.
+func (p *Pattern) find(ctx context.Context, src Source, from int) (Range, bool, error) {
+	for from <= src.Length() {
+		re, start := p.re, from
+		if from > 0 {
+			re, start = p.inContext, from-1
+		}
+
+		reader := &runeReader{ctx: ctx, src: src, pos: start}
+		loc := re.FindReaderSubmatchIndex(reader)
+		if reader.err != nil {
+			return Range{}, false, reader.err
+		}
+		if loc == nil {
+			return Range{}, false, nil
+		}
+
+		// Offsets are bytes of what the reader handed out, walk the runes again to turn them into positions
+		offsets := newOffsetWalker(src, start)
+		m := Range{Start: offsets.toPos(loc[2])}
+		m.End = offsets.toPos(loc[3])
+		if p.wordsHold(src, loc, m.Start, loc[2]) {
+			return m, true, nil
+		}
+		from = m.Start + 1
+	}
+	return Range{}, false, nil
+}
+
+// wordsHold checks the word groups of a match found at pos, which is byte offset offset of what the reader read.
+func (p *Pattern) wordsHold(src Source, loc []int, pos, offset int) bool {
+	for _, w := range p.words {
+		if loc[2*w.index] < 0 {
+			continue // In a branch that didn't match
+		}
+		// Groups can come in any order, each walks from the match start
+		walker := &offsetWalker{src: src, pos: pos, bytes: offset}
+		at := walker.toPos(loc[2*w.index])
+		if w.start && !isWordStart(src, at) || !w.start && !isWordEnd(src, at) {
+			return false
+		}
+	}
+	return true
+}
+
+// isWord checks the "\<" and "\>" the pattern was wrapped in.
+func (p *Pattern) isWord(src Source, m Range) bool {
+	return (!p.wordStart || isWordStart(src, m.Start)) && (!p.wordEnd || isWordEnd(src, m.End))
+}
+
+// isWordStart reports whether a keyword starts at pos.
+func isWordStart(src Source, pos int) bool {
+	return pos < src.Length() && isKeyword(src.CharAt(pos)) && (pos == 0 || !isKeyword(src.CharAt(pos-1)))
+}
+
+// isWordEnd reports whether a keyword ends right before pos.
+func isWordEnd(src Source, pos int) bool {
+	return pos > 0 && isKeyword(src.CharAt(pos-1)) && (pos >= src.Length() || !isKeyword(src.CharAt(pos)))
+}
+
+func isKeyword(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func errInvalid(pattern string) error {
+	return errors.New("E383: Invalid search string: " + pattern)
+}
+
+// runeReader hands a Source to the regexp engine. It gives up when the context is done.
+type runeReader struct {
+	ctx  context.Context
+	src  Source
+	pos  int
+	read int
+	err  error
+}
+
+func (r *runeReader) ReadRune() (rune, int, error) {
+	if r.err != nil {
+		return 0, 0, r.err
+	}
+	if r.read++; r.read%checkEvery == 0 && r.ctx.Err() != nil {
+		r.err = ErrCancelled
+		return 0, 0, io.EOF
+	}
+	if r.pos >= r.src.Length() {
+		return 0, 0, io.EOF
+	}
+	ch := r.src.CharAt(r.pos)
+	r.pos++
+	return ch, runeSize(ch), nil
+}
+
+func runeSize(r rune) int {
+	if n := utf8.RuneLen(r); n > 0 {
+		return n
+	}
+	return utf8.RuneLen(utf8.RuneError)
+}
+
+// offsetWalker turns byte offsets from the reader back into rune positions. Offsets must come in increasing order.
+type offsetWalker struct {
+	src   Source
+	pos   int
+	bytes int
+}
+
+func newOffsetWalker(src Source, start int) *offsetWalker {
+	return &offsetWalker{src: src, pos: start}
+}
+
+func (w *offsetWalker) toPos(offset int) int {
+	for w.bytes < offset && w.pos < w.src.Length() {
+		w.bytes += runeSize(w.src.CharAt(w.pos))
+		w.pos++
+	}
+	return w.pos
+}
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type runeSource []rune
+
+func (s runeSource) Length() int         { return len(s) }
+func (s runeSource) CharAt(pos int) rune { return s[pos] }
+
+func allMatches(t *testing.T, pattern, text string, opts Options) []Range {
+	t.Helper()
+	p, err := NewPattern(pattern, opts)
+	require.NoError(t, err)
+	src := runeSource(text)
+	matches, err := p.All(context.Background(), src, 0, src.Length())
+	require.NoError(t, err)
+	return matches
+}
+
+func TestPatternRanges(t *testing.T) {
+	require.Equal(t, []Range{{0, 3}, {7, 10}}, allMatches(t, "çay", "çay ve çay", Options{}))
+	require.Equal(t, []Range{{2, 5}}, allMatches(t, `a.b`, "x a.b", Options{Literal: true}))
+	require.Nil(t, allMatches(t, `a.b`, "x axb", Options{Literal: true}))
+	require.Equal(t, []Range{{0, 1}, {4, 5}}, allMatches(t, `^.`, "abc\ndef", Options{}))
+}
+
+func TestPatternWholeWord(t *testing.T) {
+	require.Equal(t, []Range{{0, 3}, {11, 14}}, allMatches(t, `\<çay\>`, "çay çaylar çay", Options{}))
+	require.Equal(t, []Range{{5, 8}}, allMatches(t, `\<foo`, "xfoo foo", Options{}))
+
+	// Inside the pattern too, with letters \b doesn't know
+	require.Equal(t, []Range{{0, 5}}, allMatches(t, `\(\<çay\>\|x\) ş`, "çay şey çayş ş", Options{}))
+	require.Equal(t, []Range{{8, 14}}, allMatches(t, `\<ü1_é\> ö`, "ü1_éx ö ü1_é ö", Options{}))
+	require.Equal(t, []Range{{0, 2}, {3, 4}}, allMatches(t, `\v<ça>|<é>`, "ça.é éé", Options{}))
+}
+
+func TestPatternMultiline(t *testing.T) {
+	require.Equal(t, []Range{{2, 8}}, allMatches(t, `b\nc\_s*d`, "a b\nc  d", Options{}))
+	require.Equal(t, []Range{{0, 5}}, allMatches(t, `a\_.*b`, "a\n\nxb", Options{}))
+}
+
+func TestPatternCase(t *testing.T) {
+	require.Len(t, allMatches(t, "foo", "Foo FOO foo", Options{IgnoreCase: true}), 3)
+	require.Len(t, allMatches(t, "Foo", "Foo FOO foo", Options{IgnoreCase: true, SmartCase: true}), 1)
+	require.Len(t, allMatches(t, `\Sfoo`, "xFOO", Options{IgnoreCase: true, SmartCase: true}), 1)
+	require.Len(t, allMatches(t, `foo\C`, "Foo FOO foo", Options{IgnoreCase: true}), 1)
+}
+
+func TestPatternNextFrom(t *testing.T) {
+	p, err := NewPattern(`^b`, Options{})
+	require.NoError(t, err)
+	src := runeSource("ab\nb")
+
+	// Starting mid line must not make "^" match there
+	m, ok, err := p.Next(context.Background(), src, 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, Range{3, 4}, m)
+}
+
+func TestPatternCancelled(t *testing.T) {
+	p, err := NewPattern(`x`, Options{})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	src := make(runeSource, checkEvery*2)
+	for i := range src {
+		src[i] = 'a'
+	}
+	_, _, err = p.Next(ctx, src, 0)
+	require.ErrorIs(t, err, ErrCancelled)
+}
@@ -0,0 +1,158 @@
+package search
+
+import (
+	"context"
+)
+
+// Lines is a Source that also knows where its lines start.
+type Lines interface {
+	Source
+	LineCount() int
+	LineToChar(line int) int
+}
+
+// Index caches the matches of one single line pattern, line by line. Edits only drop the lines they touch,
+// so finding every match again after typing a char searches one line instead of the whole buffer.
+type Index struct {
+	key     string
+	pattern *Pattern
+	lines   []lineMatches
+}
+
+type lineMatches struct {
+	valid   bool
+	matches []Range // Columns within the line
+}
+
+func NewIndex() *Index {
+	return &Index{}
+}
+
+// Use points the index at a pattern. key identifies the pattern and its options, a new key drops the cache.
+func (x *Index) Use(key string, p *Pattern) {
+	if x.key == key && x.pattern != nil {
+		return
+	}
+	x.key, x.pattern = key, p
+	x.lines = nil
+}
+
+// Reset drops every cached line, e.g. after the whole text was replaced.
+func (x *Index) Reset() {
+	x.lines = nil
+}
+
+// Edit records that lines line..line+removed were replaced by inserted+1 new lines. An edit running past the last
+// line, typing at the end of the text, keeps the lines above it.
+func (x *Index) Edit(line, removed, inserted int) {
+	if x.lines == nil {
+		return
+	}
+	if line < 0 || line >= len(x.lines) {
+		x.lines = nil
+		return
+	}
+	var rest []lineMatches
+	if line+removed+1 < len(x.lines) {
+		rest = x.lines[line+removed+1:]
+	}
+	fresh := make([]lineMatches, inserted+1)
+	x.lines = append(x.lines[:line], append(fresh, rest...)...)
+}
+
+// Line returns the matches on one line, in buffer positions.
+func (x *Index) Line(ctx context.Context, src Lines, line int) ([]Range, error) {
+	if err := x.sync(src); err != nil {
+		return nil, err
+	}
+	if line < 0 || line >= len(x.lines) {
+		return nil, nil
+	}
+
+	start := src.LineToChar(line)
+	if !x.lines[line].valid {
+		matches, err := x.search(ctx, src, line, start)
+		if err != nil {
+			return nil, err
+		}
+		x.lines[line] = lineMatches{valid: true, matches: matches}
+	}
+
+	cached := x.lines[line].matches
+	if len(cached) == 0 {
+		return nil, nil
+	}
+	out := make([]Range, len(cached))
+	for i, m := range cached {
+		out[i] = Range{Start: start + m.Start, End: start + m.End}
+	}
+	return out, nil
+}
+
+// All returns every match in the text, searching only the lines that aren't cached.
+func (x *Index) All(ctx context.Context, src Lines) ([]Range, error) {
+	if err := x.sync(src); err != nil {
+		return nil, err
+	}
+	var all []Range
+	for line := range x.lines {
+		matches, err := x.Line(ctx, src, line)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, matches...)
+	}
+	return all, nil
+}
+
+// sync starts over when the line count no longer fits, which means an edit went unreported.
+func (x *Index) sync(src Lines) error {
+	if x.pattern == nil {
+		return errInvalid("")
+	}
+	if len(x.lines) != src.LineCount() {
+		x.lines = make([]lineMatches, src.LineCount())
+	}
+	return nil
+}
+
+// search finds the matches of one line. The source is cut off at the line end so nothing runs into the next one.
+func (x *Index) search(ctx context.Context, src Lines, line, start int) ([]Range, error) {
+	end := src.Length()
+	if line+1 < src.LineCount() {
+		end = src.LineToChar(line+1) - 1
+	}
+
+	var view Source = lineView{Source: src, end: end}
+	if finder, ok := src.(LiteralFinder); ok {
+		view = literalLineView{lineView: lineView{Source: src, end: end}, finder: finder}
+	}
+	matches, err := x.pattern.All(ctx, view, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for i := range matches {
+		matches[i].Start -= start
+		matches[i].End -= start
+	}
+	return matches, nil
+}
+
+// lineView is a Source that ends where a line ends.
+type lineView struct {
+	Source
+	end int
+}
+
+func (v lineView) Length() int {
+	return v.end
+}
+
+type literalLineView struct {
+	lineView
+	finder LiteralFinder
+}
+
+func (v literalLineView) FindIn(needle string, start, end int) []int {
+	return v.finder.FindIn(needle, start, min(end, v.end))
+}
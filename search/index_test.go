@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// textLines is a Lines over a string that counts how often each line is searched.
+type textLines struct {
+	runeSource
+	starts []int
+	reads  int
+}
+
+func newTextLines(text string) *textLines {
+	t := &textLines{runeSource: runeSource(text), starts: []int{0}}
+	for i, r := range t.runeSource {
+		if r == '\n' {
+			t.starts = append(t.starts, i+1)
+		}
+	}
+	return t
+}
+
+func (t *textLines) LineCount() int          { return len(t.starts) }
+func (t *textLines) LineToChar(line int) int { return t.starts[line] }
+func (t *textLines) CharAt(pos int) rune {
+	t.reads++
+	return t.runeSource.CharAt(pos)
+}
+
+func TestIndexAll(t *testing.T) {
+	p, err := NewPattern(`o\+$`, Options{})
+	require.NoError(t, err)
+	x := NewIndex()
+	x.Use("o+", p)
+
+	src := newTextLines("foo\nbar\nzoo")
+	matches, err := x.All(context.Background(), src)
+	require.NoError(t, err)
+	require.Equal(t, []Range{{1, 3}, {9, 11}}, matches)
+}
+
+func TestIndexEditOnlySearchesTouchedLines(t *testing.T) {
+	p, err := NewPattern(`foo`, Options{})
+	require.NoError(t, err)
+	x := NewIndex()
+	x.Use("foo", p)
+
+	text := strings.Repeat("foo bar\n", 50) + "end"
+	src := newTextLines(text)
+	_, err = x.All(context.Background(), src)
+	require.NoError(t, err)
+
+	// Line 10 becomes two lines
+	src = newTextLines(strings.Repeat("foo bar\n", 10) + "foo\nfoo bar\n" + strings.Repeat("foo bar\n", 39) + "end")
+	x.Edit(10, 0, 1)
+	src.reads = 0
+	matches, err := x.All(context.Background(), src)
+	require.NoError(t, err)
+	require.Len(t, matches, 51)
+	require.Less(t, src.reads, 40, "Cached lines are not searched again")
+}
+
+func TestIndexNewKeyDropsCache(t *testing.T) {
+	src := newTextLines("ab\nba")
+	x := NewIndex()
+
+	a, _ := NewPattern("a", Options{})
+	x.Use("a", a)
+	matches, _ := x.All(context.Background(), src)
+	require.Equal(t, []Range{{0, 1}, {4, 5}}, matches)
+
+	b, _ := NewPattern("b", Options{})
+	x.Use("b", b)
+	matches, _ = x.All(context.Background(), src)
+	require.Equal(t, []Range{{1, 2}, {3, 4}}, matches)
+}
+
+func TestIndexEditLastLine(t *testing.T) {
+	p, err := NewPattern(`foo`, Options{})
+	require.NoError(t, err)
+	x := NewIndex()
+	x.Use("foo", p)
+
+	text := strings.Repeat("foo bar\n", 50) + "end"
+	src := newTextLines(text)
+	_, err = x.All(context.Background(), src)
+	require.NoError(t, err)
+
+	// Typing on the last line, splitting it and joining it again
+	for _, edit := range []struct {
+		text                    string
+		line, removed, inserted int
+	}{
+		{text: text + " foo", line: 50},
+		{text: text + " foo\nfoo", line: 50, inserted: 1},
+		{text: text + " foo", line: 50, removed: 1},
+		// Reaching past the lines the index knows keeps the ones above
+		{text: text + " foo foo", line: 50, removed: 5},
+	} {
+		src = newTextLines(edit.text)
+		x.Edit(edit.line, edit.removed, edit.inserted)
+		src.reads = 0
+		matches, err := x.All(context.Background(), src)
+		require.NoError(t, err)
+		require.Len(t, matches, 50+strings.Count(edit.text[len(text):], "foo"))
+		require.Less(t, src.reads, 40, "lines above the edit are not searched again")
+	}
+}
@@ -0,0 +1,72 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ExpandReplacement builds the replacement text for one match. It understands Vim's replacement syntax:
+// "&" and "\0" for the whole match, "\1".."\9" for groups, "\u"/"\l" to change the case of the next char,
+// "\U"/"\L" until "\E" or "\e", and "\r" or "\n" for a line break. groups[0] is the whole match.
+func ExpandReplacement(replacement string, groups []string) string {
+	var out strings.Builder
+	runes := []rune(replacement)
+
+	var oneShot, persistent rune // 'u' or 'l'
+	write := func(s string) {
+		for _, r := range s {
+			switch {
+			case oneShot == 'u':
+				r = unicode.ToUpper(r)
+				oneShot = 0
+			case oneShot == 'l':
+				r = unicode.ToLower(r)
+				oneShot = 0
+			case persistent == 'U':
+				r = unicode.ToUpper(r)
+			case persistent == 'L':
+				r = unicode.ToLower(r)
+			}
+			out.WriteRune(r)
+		}
+	}
+	group := func(n int) string {
+		if n < len(groups) {
+			return groups[n]
+		}
+		return ""
+	}
+
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		if ch == '&' {
+			write(group(0))
+			continue
+		}
+		if ch != '\\' || i+1 >= len(runes) {
+			write(string(ch))
+			continue
+		}
+
+		i++
+		next := runes[i]
+		switch {
+		case next >= '0' && next <= '9':
+			write(group(int(next - '0')))
+		case next == 'u' || next == 'l':
+			oneShot = next
+		case next == 'U' || next == 'L':
+			persistent = next
+		case next == 'E' || next == 'e':
+			persistent = 0
+		case next == 'r' || next == 'n':
+			out.WriteRune('\n')
+		case next == 't':
+			out.WriteRune('\t')
+		default:
+			// "\&", "\\", "\/" and anything else is taken literally
+			write(string(next))
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,274 @@
+// Package search turns Vim flavoured patterns into Go regular expressions and finds matches in buffer text.
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Regexp is a compiled Vim pattern that matches in a string, like a line for ":s" and ":g". "\<" and "\>" are
+// checked on runes the way Pattern checks them, and groups are numbered as the pattern wrote them.
+type Regexp struct {
+	re        *regexp.Regexp // Used for matches at the start of the string
+	inContext *regexp.Regexp // Same pattern after one rune of context, so "^" and "\<" see what comes before
+	words     []wordGroup
+	groups    []int // Regexp group of the whole match and of each "\(\)", word groups left out
+}
+
+// Compile translates a Vim pattern and compiles it. "\c" anywhere in the pattern forces ignore case,
+// "\C" forces match case, otherwise ignoreCase decides.
+func Compile(pattern string, ignoreCase bool) (*Regexp, error) {
+	translated, flags, err := TranslateVim(pattern)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case flags.forceIgnoreCase:
+		ignoreCase = true
+	case flags.forceMatchCase:
+		ignoreCase = false
+	}
+
+	// Multiline so ^ and $ match at every line when a pattern runs over more than one line
+	prefix := "(?m)"
+	if ignoreCase {
+		prefix = "(?mi)"
+	}
+	re, err := regexp.Compile(prefix + "(" + translated + ")")
+	if err != nil {
+		return nil, fmt.Errorf("E383: Invalid search string: %s", pattern)
+	}
+	inContext, err := regexp.Compile(prefix + "(?s:.)(" + translated + ")")
+	if err != nil {
+		return nil, fmt.Errorf("E383: Invalid search string: %s", pattern)
+	}
+
+	r := &Regexp{re: re, inContext: inContext}
+	for i, name := range re.SubexpNames() {
+		switch {
+		case i == 0:
+		case name == wordStartGroup:
+			r.words = append(r.words, wordGroup{index: i, start: true})
+		case name == wordEndGroup:
+			r.words = append(r.words, wordGroup{index: i})
+		default:
+			r.groups = append(r.groups, i)
+		}
+	}
+	return r, nil
+}
+
+// MatchString reports whether s has a match.
+func (r *Regexp) MatchString(s string) bool {
+	return r.find(s, 0) != nil
+}
+
+// FindAllStringSubmatchIndex is regexp's method of the same name: up to n matches (all when n < 0), each as byte
+// offsets of the whole match followed by those of its groups.
+func (r *Regexp) FindAllStringSubmatchIndex(s string, n int) [][]int {
+	var all [][]int
+	for from, prevEnd := 0, -1; from <= len(s) && (n < 0 || len(all) < n); {
+		loc := r.find(s, from)
+		if loc == nil {
+			break
+		}
+		// Like regexp, an empty match right after the previous match doesn't count
+		if loc[0] != loc[1] || loc[0] != prevEnd {
+			all = append(all, loc)
+		}
+		prevEnd, from = loc[1], loc[1]
+		if loc[0] == loc[1] {
+			from += max(1, runeLen(s, from))
+		}
+	}
+	return all
+}
+
+// find returns the first match starting at byte from or later whose "\<" and "\>" are on word boundaries.
+func (r *Regexp) find(s string, from int) []int {
+	for from <= len(s) {
+		re, start := r.re, from
+		if from > 0 {
+			_, size := utf8.DecodeLastRuneInString(s[:from])
+			re, start = r.inContext, from-size
+		}
+		loc := re.FindStringSubmatchIndex(s[start:])
+		if loc == nil {
+			return nil
+		}
+		for i := range loc {
+			if loc[i] >= 0 {
+				loc[i] += start
+			}
+		}
+		if r.wordsHold(s, loc) {
+			out := make([]int, 0, 2*len(r.groups))
+			for _, g := range r.groups {
+				out = append(out, loc[2*g], loc[2*g+1])
+			}
+			return out
+		}
+		from = loc[2] + max(1, runeLen(s, loc[2]))
+	}
+	return nil
+}
+
+func (r *Regexp) wordsHold(s string, loc []int) bool {
+	for _, w := range r.words {
+		at := loc[2*w.index]
+		if at < 0 {
+			continue // In a branch that didn't match
+		}
+		before, _ := utf8.DecodeLastRuneInString(s[:at])
+		after, _ := utf8.DecodeRuneInString(s[at:])
+		startsWord := at < len(s) && isKeyword(after) && (at == 0 || !isKeyword(before))
+		endsWord := at > 0 && isKeyword(before) && (at == len(s) || !isKeyword(after))
+		if w.start && !startsWord || !w.start && !endsWord {
+			return false
+		}
+	}
+	return true
+}
+
+func runeLen(s string, i int) int {
+	_, size := utf8.DecodeRuneInString(s[i:])
+	return size
+}
+
+type caseFlags struct {
+	forceIgnoreCase bool
+	forceMatchCase  bool
+}
+
+// Vim "magic" mode: these are literal unless escaped
+var magicWhenEscaped = map[rune]string{
+	'(': "(", ')': ")", '|': "|", '+': "+", '?': "?", '=': "?", '{': "{",
+}
+
+var classes = map[rune]string{
+	's': `\s`, 'S': `\S`, 'd': `\d`, 'D': `\D`, 'w': `\w`, 'W': `\W`,
+	'a': `[A-Za-z]`, 'A': `[^A-Za-z]`, 'l': `[a-z]`, 'L': `[^a-z]`, 'u': `[A-Z]`, 'U': `[^A-Z]`,
+	'x': `[0-9A-Fa-f]`, 'X': `[^0-9A-Fa-f]`, 'h': `[A-Za-z_]`, 'H': `[^A-Za-z_]`,
+	'n': `\n`, 't': `\t`, 'e': `\x1b`, 'r': `\r`,
+}
+
+// Group names "\<" and "\>" become, the caller checks them on the text since Go's \b only knows ASCII words.
+const (
+	wordStartGroup = "wordstart"
+	wordEndGroup   = "wordend"
+)
+
+// TranslateVim converts a Vim pattern in magic mode (the default) into Go regexp syntax.
+// "\v" switches to very magic where Go syntax is used as is, "\V" to very nomagic where only escapes are special.
+// "\<" and "\>" become empty groups named wordStartGroup and wordEndGroup, for the caller to check on the text.
+func TranslateVim(pattern string) (string, caseFlags, error) {
+	var out strings.Builder
+	var flags caseFlags
+	runes := []rune(pattern)
+	mode := 'm'
+	wordBound := func(ch rune) string {
+		if ch == '<' {
+			return "(?P<" + wordStartGroup + ">)"
+		}
+		return "(?P<" + wordEndGroup + ">)"
+	}
+
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+
+		if ch != '\\' {
+			switch {
+			case mode == 'v' && (ch == '<' || ch == '>'):
+				out.WriteString(wordBound(ch))
+			case mode == 'v':
+				out.WriteRune(ch)
+			case mode == 'V' && ch != '^' && ch != '$':
+				out.WriteString(regexp.QuoteMeta(string(ch)))
+			case strings.ContainsRune("()|+?{}", ch):
+				out.WriteString(`\` + string(ch))
+			default:
+				out.WriteRune(ch)
+			}
+			continue
+		}
+
+		if i+1 >= len(runes) {
+			out.WriteString(`\\`)
+			break
+		}
+		i++
+		next := runes[i]
+
+		switch {
+		case next == 'v' || next == 'm' || next == 'V' || next == 'M':
+			mode = next
+			if next == 'M' {
+				mode = 'm'
+			}
+		case next == '_' && i+1 < len(runes):
+			// "\_." and "\_s" are "." and "\s" that also match a line break
+			i++
+			switch {
+			case runes[i] == '.':
+				out.WriteString(`(?s:.)`)
+			case classes[runes[i]] != "":
+				out.WriteString(`(?:` + classes[runes[i]] + `|\n)`)
+			default:
+				return "", flags, fmt.Errorf("E63: Invalid use of \\_: %s", pattern)
+			}
+		case next == 'c':
+			flags.forceIgnoreCase = true
+		case next == 'C':
+			flags.forceMatchCase = true
+		case next == '<' || next == '>':
+			out.WriteString(wordBound(next))
+		case next == '{' && mode != 'v':
+			// "\{n,m}" counts, "\{-n,m}" is the lazy version
+			end := indexFrom(runes, i, '}')
+			if end < 0 {
+				return "", flags, fmt.Errorf("E60: Too many \\{...}: %s", pattern)
+			}
+			body := strings.TrimSuffix(string(runes[i+1:end]), `\`)
+			lazy := strings.HasPrefix(body, "-")
+			body = strings.TrimPrefix(body, "-")
+			switch {
+			case body == "":
+				out.WriteString("*")
+			case strings.HasPrefix(body, ","):
+				// Go reads "{,m}" as literal text, Vim means "{0,m}"
+				out.WriteString("{0" + body + "}")
+			default:
+				out.WriteString("{" + body + "}")
+			}
+			if lazy {
+				out.WriteString("?")
+			}
+			i = end
+		case mode == 'm' && magicWhenEscaped[next] != "":
+			out.WriteString(magicWhenEscaped[next])
+		case mode == 'V' && strings.ContainsRune("()|+?=.*[", next):
+			if next == '=' {
+				next = '?'
+			}
+			out.WriteRune(next)
+		case classes[next] != "":
+			out.WriteString(classes[next])
+		case next == 'z':
+			return "", flags, fmt.Errorf("E68: Invalid character after \\z: %s", pattern)
+		default:
+			out.WriteString(regexp.QuoteMeta(string(next)))
+		}
+	}
+	return out.String(), flags, nil
+}
+
+func indexFrom(runes []rune, from int, target rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == target {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,116 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateVim(t *testing.T) {
+	tests := map[string]string{
+		`foo`:            `foo`,
+		`\(ab\)\+`:       `(ab)+`,
+		`(a|b)`:          `\(a\|b\)`,
+		`a\|b`:           `a|b`,
+		`\<word\>`:       `(?P<wordstart>)word(?P<wordend>)`,
+		`a\{2,3}`:        `a{2,3}`,
+		`a\{-1,}`:        `a{1,}?`,
+		`a\{-}`:          `a*?`,
+		`a\{,3}`:         `a{0,3}`,
+		`a\{-,3}`:        `a{0,3}?`,
+		`a\{2}`:          `a{2}`,
+		`a\{-2,}`:        `a{2,}?`,
+		`\d\+\.\d*`:      `\d+\.\d*`,
+		`\vfoo(bar)+<x>`: `foo(bar)+(?P<wordstart>)x(?P<wordend>)`,
+		`\Va.b*`:         `a\.b\*`,
+		`x\=`:            `x?`,
+		`\u\l\a`:         `[A-Z][a-z][A-Za-z]`,
+		`a\_.b`:          `a(?s:.)b`,
+		`\_s`:            `(?:\s|\n)`,
+	}
+	for vim, want := range tests {
+		got, _, err := TranslateVim(vim)
+		require.NoError(t, err, vim)
+		require.Equal(t, want, got, vim)
+	}
+}
+
+func TestCompileCaseFlags(t *testing.T) {
+	re, err := Compile(`hello\c`, false)
+	require.NoError(t, err)
+	require.True(t, re.MatchString("HELLO"))
+
+	re, err = Compile(`\Chello`, true)
+	require.NoError(t, err)
+	require.False(t, re.MatchString("HELLO"))
+
+	_, err = Compile(`\(`, false)
+	require.Error(t, err)
+}
+
+func TestCompileCounts(t *testing.T) {
+	re, err := Compile(`^a\{,3}$`, false)
+	require.NoError(t, err)
+	require.True(t, re.MatchString(""))
+	require.True(t, re.MatchString("aa"))
+	require.False(t, re.MatchString("aaaa"))
+	require.False(t, re.MatchString("a{,3}"))
+
+	re, err = Compile(`a\{-2,}`, false)
+	require.NoError(t, err)
+	require.Equal(t, [][]int{{0, 2}}, re.FindAllStringSubmatchIndex("aaa", -1))
+}
+
+func TestCompileWordBoundaries(t *testing.T) {
+	re, err := Compile(`\<über\>`, false)
+	require.NoError(t, err)
+	require.True(t, re.MatchString("das über alles"))
+	require.False(t, re.MatchString("darüber"))
+	require.False(t, re.MatchString("überall"))
+
+	// "\>" is the end of a word, not any boundary
+	re, err = Compile(`é\>`, false)
+	require.NoError(t, err)
+	require.True(t, re.MatchString("café noir"))
+	require.False(t, re.MatchString("cafés"))
+	re, err = Compile(`\>é`, false)
+	require.NoError(t, err)
+	require.False(t, re.MatchString("é café"))
+
+	// A failed boundary moves on to the next candidate
+	re, err = Compile(`\<ä\w*`, false)
+	require.NoError(t, err)
+	require.Equal(t, [][]int{{9, 12}}, re.FindAllStringSubmatchIndex("bär ist äb", -1))
+}
+
+func TestCompileGroups(t *testing.T) {
+	// Word groups don't count, "\1" and "\2" are the pattern's own
+	re, err := Compile(`\<\(\w\+\) \(\w\+\)\>`, false)
+	require.NoError(t, err)
+	require.Equal(t, [][]int{{0, 10, 0, 4, 5, 10}}, re.FindAllStringSubmatchIndex("john smith", -1))
+
+	// Empty matches right after a match are skipped like regexp does
+	re, err = Compile(`x*`, false)
+	require.NoError(t, err)
+	require.Equal(t, [][]int{{0, 2}, {3, 3}}, re.FindAllStringSubmatchIndex("xxa", -1))
+}
+
+func TestExpandReplacement(t *testing.T) {
+	groups := []string{"john smith", "john", "smith"}
+
+	tests := map[string]string{
+		`&!`:             "john smith!",
+		`\2, \1`:         "smith, john",
+		`\u\1 \u\2`:      "John Smith",
+		`\U\1\E \2`:      "JOHN smith",
+		`\L\U\1`:         "JOHN",
+		`<\0>`:           "<john smith>",
+		`\&\\`:           `&\`,
+		`\1\r\2`:         "john\nsmith",
+		`\9missing`:      "missing",
+		`\U\1 \l\2 done`: "JOHN sMITH DONE",
+	}
+	for rep, want := range tests {
+		require.Equal(t, want, ExpandReplacement(rep, groups), rep)
+	}
+}
@@ -58,10 +58,13 @@ func (tb *TextBuffer) Insert(pos int, ch rune) {
 }
 
 func (tb *TextBuffer) InsertString(pos int, text string) {
+	// Positions are in runes, so walk the text as runes rather than using byte offsets from range
+	runes := []rune(text)
+
 	if pos >= tb.Length() {
 		// Fast path, inserting at end, no existing lines to shift
 		tb.gBuf.InsertStringAt(pos, text)
-		for i, ch := range text {
+		for i, ch := range runes {
 			if ch == '\n' {
 				tb.lineStarts = append(tb.lineStarts, pos+i+1)
 			}
@@ -69,7 +72,7 @@ func (tb *TextBuffer) InsertString(pos int, text string) {
 	} else {
 		// Slow path, inserting in middle, need to shift existing lines
 		tb.gBuf.InsertStringAt(pos, text)
-		textLen := len(text)
+		textLen := len(runes)
 
 		// Shift all existing line starts that come after insertion point
 		for i := range tb.lineStarts {
@@ -79,7 +82,7 @@ func (tb *TextBuffer) InsertString(pos int, text string) {
 		}
 
 		// Add new line starts from inserted text
-		for i, ch := range text {
+		for i, ch := range runes {
 			if ch == '\n' {
 				newLinePos := pos + i + 1
 				insertPos := sort.SearchInts(tb.lineStarts, newLinePos)
@@ -122,10 +125,20 @@ func (tb *TextBuffer) CharToLine(pos int) int {
 	return line
 }
 
+// Snapshot freezes the text for reading from another goroutine, see GapBuffer.Snapshot.
+func (tb *TextBuffer) Snapshot() *gBuf.Snapshot {
+	return tb.gBuf.Snapshot()
+}
+
 func (tb *TextBuffer) Find(needle string) []int {
 	return tb.gBuf.Find(needle)
 }
 
+// FindIn returns the start of every occurrence of needle lying entirely inside start..end.
+func (tb *TextBuffer) FindIn(needle string, start, end int) []int {
+	return tb.gBuf.FindIn(needle, start, end)
+}
+
 func (tb *TextBuffer) Substring(start, end int) string {
 	return tb.gBuf.Substring(start, end)
 }
@@ -301,3 +301,18 @@ func TestBufferDeleteRange(t *testing.T) {
 	require.Equal(t, "a\nb", tb3.String())
 	require.Equal(t, []int{0, 2}, tb3.lineStarts)
 }
+
+func TestBufferInsertStringMultibyte(t *testing.T) {
+	tb, err := NewTextBuffer(10)
+	require.NoError(t, err)
+
+	tb.InsertString(0, "héllo\nwörld")
+	require.Equal(t, []int{0, 6}, tb.lineStarts)
+	require.Equal(t, "wörld", tb.Line(1))
+
+	// Middle insert shifts by rune count, not byte count
+	tb.InsertString(1, "ü\nç")
+	require.Equal(t, "hü\nçéllo\nwörld", tb.String())
+	require.Equal(t, []int{0, 3, 9}, tb.lineStarts)
+	require.Equal(t, "wörld", tb.Line(2))
+}
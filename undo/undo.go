@@ -0,0 +1,135 @@
+// Package undo keeps the undo/redo history of a buffer as groups of text edits.
+// It only records what changed, applying the edits back to the buffer is up to the caller.
+package undo
+
+// Edit replaces Deleted with Inserted at Pos. A pure insert has an empty Deleted and vice versa.
+type Edit struct {
+	Pos      int
+	Deleted  string
+	Inserted string
+}
+
+// Change is one undo step. Everything between Begin and End, e.g. a whole insert session or a paste, is one Change.
+type Change struct {
+	Edits  []Edit
+	Cursor int // Cursor position before the change, restored on undo
+}
+
+type History struct {
+	undoStack []Change
+	redoStack []Change
+
+	current *Change
+	depth   int // Begin/End nesting, only the outermost pair closes the change
+	limit   int
+}
+
+func NewHistory(limit int) *History {
+	return &History{limit: limit}
+}
+
+// Begin opens a change. Calls can nest, so a macro can wrap commands that group on their own.
+func (h *History) Begin(cursor int) {
+	h.depth++
+	if h.depth == 1 {
+		h.current = &Change{Cursor: cursor}
+	}
+}
+
+// End closes the change opened by the matching Begin. Empty changes are dropped.
+func (h *History) End() {
+	if h.depth == 0 {
+		return
+	}
+	h.depth--
+	if h.depth > 0 {
+		return
+	}
+
+	change := h.current
+	h.current = nil
+	if len(change.Edits) == 0 {
+		return
+	}
+	h.push(*change)
+}
+
+func (h *History) InGroup() bool {
+	return h.depth > 0
+}
+
+// Record adds an edit. Outside of Begin/End the edit becomes a change on its own.
+func (h *History) Record(edit Edit, cursor int) {
+	if edit.Deleted == "" && edit.Inserted == "" {
+		return
+	}
+	if h.current == nil {
+		h.push(Change{Edits: []Edit{edit}, Cursor: cursor})
+		return
+	}
+	h.current.Edits = merge(h.current.Edits, edit)
+}
+
+// merge folds typing into the previous edit so an insert session doesn't keep one edit per key.
+func merge(edits []Edit, edit Edit) []Edit {
+	if len(edits) == 0 {
+		return append(edits, edit)
+	}
+	last := &edits[len(edits)-1]
+	lastLen := len([]rune(last.Inserted))
+
+	switch {
+	// Typing right after the previous insert
+	case edit.Deleted == "" && last.Deleted == "" && edit.Pos == last.Pos+lastLen:
+		last.Inserted += edit.Inserted
+		return edits
+	// Backspacing over text that was just typed
+	case edit.Inserted == "" && last.Deleted == "" && lastLen > 0 &&
+		edit.Pos == last.Pos+lastLen-len([]rune(edit.Deleted)) && edit.Pos >= last.Pos &&
+		string([]rune(last.Inserted)[edit.Pos-last.Pos:]) == edit.Deleted:
+		last.Inserted = string([]rune(last.Inserted)[:edit.Pos-last.Pos])
+		if last.Inserted == "" {
+			return edits[:len(edits)-1]
+		}
+		return edits
+	}
+	return append(edits, edit)
+}
+
+func (h *History) push(change Change) {
+	h.undoStack = append(h.undoStack, change)
+	h.redoStack = h.redoStack[:0]
+	if h.limit > 0 && len(h.undoStack) > h.limit {
+		h.undoStack = h.undoStack[len(h.undoStack)-h.limit:]
+	}
+}
+
+// Undo pops the latest change. Its edits have to be reverted in reverse order.
+func (h *History) Undo() (Change, bool) {
+	if len(h.undoStack) == 0 {
+		return Change{}, false
+	}
+	change := h.undoStack[len(h.undoStack)-1]
+	h.undoStack = h.undoStack[:len(h.undoStack)-1]
+	h.redoStack = append(h.redoStack, change)
+	return change, true
+}
+
+// Redo pops the latest undone change. Its edits have to be applied in order.
+func (h *History) Redo() (Change, bool) {
+	if len(h.redoStack) == 0 {
+		return Change{}, false
+	}
+	change := h.redoStack[len(h.redoStack)-1]
+	h.redoStack = h.redoStack[:len(h.redoStack)-1]
+	h.undoStack = append(h.undoStack, change)
+	return change, true
+}
+
+func (h *History) CanUndo() bool {
+	return len(h.undoStack) > 0
+}
+
+func (h *History) CanRedo() bool {
+	return len(h.redoStack) > 0
+}
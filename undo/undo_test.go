@@ -0,0 +1,89 @@
+package undo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordOutsideGroup(t *testing.T) {
+	h := NewHistory(0)
+
+	h.Record(Edit{Pos: 0, Inserted: "a"}, 0)
+	h.Record(Edit{Pos: 1, Inserted: "b"}, 1)
+
+	change, ok := h.Undo()
+	require.True(t, ok)
+	require.Equal(t, []Edit{{Pos: 1, Inserted: "b"}}, change.Edits)
+	require.Equal(t, 1, change.Cursor)
+
+	change, ok = h.Redo()
+	require.True(t, ok)
+	require.Equal(t, []Edit{{Pos: 1, Inserted: "b"}}, change.Edits)
+
+	_, ok = h.Redo()
+	require.False(t, ok)
+}
+
+func TestGroupMergesTyping(t *testing.T) {
+	h := NewHistory(0)
+
+	h.Begin(5)
+	for i, ch := range "hello" {
+		h.Record(Edit{Pos: 5 + i, Inserted: string(ch)}, 5+i)
+	}
+	// Backspace twice over "lo"
+	h.Record(Edit{Pos: 9, Deleted: "o"}, 10)
+	h.Record(Edit{Pos: 8, Deleted: "l"}, 9)
+	h.End()
+
+	change, ok := h.Undo()
+	require.True(t, ok)
+	require.Equal(t, []Edit{{Pos: 5, Inserted: "hel"}}, change.Edits)
+	require.Equal(t, 5, change.Cursor)
+	require.False(t, h.CanUndo())
+}
+
+func TestNestedGroups(t *testing.T) {
+	h := NewHistory(0)
+
+	h.Begin(0)
+	h.Record(Edit{Pos: 0, Inserted: "a"}, 0)
+	h.Begin(1)
+	h.Record(Edit{Pos: 4, Deleted: "x"}, 1)
+	h.End()
+	require.False(t, h.CanUndo(), "inner End must not close the outer group")
+	h.End()
+
+	change, ok := h.Undo()
+	require.True(t, ok)
+	require.Len(t, change.Edits, 2)
+}
+
+func TestEmptyGroupAndNewEditClearsRedo(t *testing.T) {
+	h := NewHistory(0)
+
+	h.Begin(0)
+	h.End()
+	require.False(t, h.CanUndo())
+
+	h.Record(Edit{Pos: 0, Inserted: "a"}, 0)
+	h.Undo()
+	require.True(t, h.CanRedo())
+
+	h.Record(Edit{Pos: 0, Inserted: "b"}, 0)
+	require.False(t, h.CanRedo())
+}
+
+func TestLimit(t *testing.T) {
+	h := NewHistory(2)
+
+	h.Record(Edit{Pos: 0, Inserted: "a"}, 0)
+	h.Record(Edit{Pos: 5, Inserted: "b"}, 0)
+	h.Record(Edit{Pos: 9, Inserted: "c"}, 0)
+
+	h.Undo()
+	h.Undo()
+	_, ok := h.Undo()
+	require.False(t, ok)
+}